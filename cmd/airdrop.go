@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/AleMoz97/merkle-tree-go/merkletree"
+)
+
+// runAirdrop implementa `merkletree airdrop [-total n] [-out file] [-shards dir] balances.csv`
+func runAirdrop(args []string) int {
+	flags := flag.NewFlagSet("airdrop", flag.ContinueOnError)
+	expectedTotal := flags.String("total", "", "totale atteso degli importi (base 10)")
+	output := flags.String("out", "", "file in cui scrivere il JSON merkle-distributor (default: stdout)")
+	shardsDir := flags.String("shards", "", "directory in cui scrivere un file di proof per ogni indirizzo")
+	prefixLength := flags.Int("prefix", 2, "caratteri esadecimali dell'indirizzo usati come prefisso degli shard")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: merkletree airdrop [-total n] [-out file] [-shards dir] [-prefix n] balances.csv")
+		fmt.Fprintln(flags.Output(), "Il CSV contiene righe account,amount[,index].")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var options merkletree.AirdropOptions
+	if *expectedTotal != "" {
+		total, ok := new(big.Int).SetString(*expectedTotal, 10)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "❌ Totale non valido: %q\n", *expectedTotal)
+			return 2
+		}
+		options.ExpectedTotal = total
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del file: %v\n", err)
+		return 1
+	}
+	entries, err := merkletree.ParseAirdropCSV(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ CSV non valido: %v\n", err)
+		return 1
+	}
+
+	distribution, _, err := merkletree.BuildAirdrop(entries, options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	encoded, err := json.MarshalIndent(distribution, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella serializzazione JSON: %v\n", err)
+		return 1
+	}
+	if *output == "" {
+		fmt.Println(string(encoded))
+	} else if err := os.WriteFile(*output, encoded, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella scrittura del file: %v\n", err)
+		return 1
+	}
+
+	if *shardsDir != "" {
+		if err := distribution.WriteShardedClaims(*shardsDir, *prefixLength); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Errore nella scrittura degli shard: %v\n", err)
+			return 1
+		}
+	}
+
+	if *output != "" {
+		fmt.Printf("✅ Root %s, %d claim, totale %s\n", distribution.MerkleRoot, len(distribution.Claims), distribution.TokenTotal)
+	}
+	return 0
+}
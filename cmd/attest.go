@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/AleMoz97/merkle-tree-go/merkletree"
+)
+
+// keystorePasswordEnv è la variabile d'ambiente letta se non viene indicato -password-file
+const keystorePasswordEnv = "MERKLETREE_KEYSTORE_PASSWORD"
+
+// runSignRoot implementa `merkletree sign-root -keystore file -epoch n -chain-id n -contract addr tree.json`
+func runSignRoot(args []string) int {
+	flags := flag.NewFlagSet("sign-root", flag.ContinueOnError)
+	keystorePath := flags.String("keystore", "", "file keystore (JSON cifrato) con la chiave del firmatario")
+	passwordFile := flags.String("password-file", "", "file con la password del keystore (default: $"+keystorePasswordEnv+")")
+	epoch := flags.Uint64("epoch", 0, "epoca della root")
+	chainID := flags.Uint64("chain-id", 1, "chainId del dominio EIP-712")
+	contract := flags.String("contract", "", "indirizzo del contratto che userà la root")
+	output := flags.String("out", "", "file in cui scrivere l'attestazione firmata (default: stdout)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: merkletree sign-root -keystore file -epoch n -chain-id n -contract 0x... [-out file] tree.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *keystorePath == "" || !common.IsHexAddress(*contract) {
+		flags.Usage()
+		return 2
+	}
+
+	root, leafCount, err := loadDumpRoot(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	password, err := keystorePassword(*passwordFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	keyJSON, err := os.ReadFile(*keystorePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del keystore: %v\n", err)
+		return 1
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella decifratura del keystore: %v\n", err)
+		return 1
+	}
+
+	attestation := merkletree.RootAttestation{
+		Root:         root,
+		LeafCount:    uint64(leafCount),
+		LeafEncoding: merkletree.StandardLeafEncoding,
+		Epoch:        *epoch,
+		ChainID:      *chainID,
+		Contract:     common.HexToAddress(*contract),
+	}
+	signed, err := merkletree.SignRootAttestation(attestation, key.PrivateKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella firma: %v\n", err)
+		return 1
+	}
+
+	encoded, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella serializzazione JSON: %v\n", err)
+		return 1
+	}
+	if *output == "" {
+		fmt.Println(string(encoded))
+		return 0
+	}
+	if err := os.WriteFile(*output, encoded, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella scrittura del file: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Root %s firmata da %s\n", signed.Root, signed.Signer.Hex())
+	return 0
+}
+
+// runVerifyRoot implementa `merkletree verify-root -allow addr[,addr...] [-tree tree.json] attestation.json`
+func runVerifyRoot(args []string) int {
+	flags := flag.NewFlagSet("verify-root", flag.ContinueOnError)
+	allow := flags.String("allow", "", "indirizzi autorizzati a firmare, separati da virgola")
+	treePath := flags.String("tree", "", "dump dell'albero: se indicato, root e numero di foglie devono corrispondere")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: merkletree verify-root -allow 0x...[,0x...] [-tree tree.json] attestation.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *allow == "" {
+		flags.Usage()
+		return 2
+	}
+
+	var allowlist []common.Address
+	for _, address := range strings.Split(*allow, ",") {
+		address = strings.TrimSpace(address)
+		if !common.IsHexAddress(address) {
+			fmt.Fprintf(os.Stderr, "❌ Indirizzo non valido: %q\n", address)
+			return 2
+		}
+		allowlist = append(allowlist, common.HexToAddress(address))
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del file: %v\n", err)
+		return 1
+	}
+	var signed merkletree.SignedRootAttestation
+	if err := json.Unmarshal(data, &signed); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Attestazione non valida: %v\n", err)
+		return 1
+	}
+
+	if *treePath != "" {
+		root, leafCount, err := loadDumpRoot(*treePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		if root != signed.Root || uint64(leafCount) != signed.LeafCount {
+			fmt.Fprintf(os.Stderr, "❌ L'attestazione (root %s, %d foglie) non corrisponde all'albero (root %s, %d foglie)\n", signed.Root, signed.LeafCount, root, leafCount)
+			return 1
+		}
+	}
+
+	signer, err := merkletree.VerifyRootAttestation(signed, allowlist)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Root %s (epoca %d) firmata da %s\n", signed.Root, signed.Epoch, signer.Hex())
+	return 0
+}
+
+// loadDumpRoot legge un dump (standard-v1 o simple-v1), verifica i nodi interni
+// e restituisce la root e il numero di foglie
+func loadDumpRoot(path string) (merkletree.HexString, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("errore nella lettura del file: %w", err)
+	}
+	var dump struct {
+		Format string
+		Tree   []merkletree.HexString
+	}
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return "", 0, fmt.Errorf("dump non valido %s: %w", path, err)
+	}
+	if dump.Format != "standard-v1" && dump.Format != "simple-v1" {
+		return "", 0, fmt.Errorf("formato non supportato: %q", dump.Format)
+	}
+	if !merkletree.IsValidMerkleTree(dump.Tree, merkletree.StandardNodeHash) {
+		return "", 0, fmt.Errorf("l'albero in %s non è valido", path)
+	}
+	return dump.Tree[0], (len(dump.Tree) + 1) / 2, nil
+}
+
+// keystorePassword legge la password del keystore dal file indicato o dalla variabile d'ambiente
+func keystorePassword(passwordFile string) (string, error) {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("errore nella lettura della password: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if password, found := os.LookupEnv(keystorePasswordEnv); found {
+		return password, nil
+	}
+	return "", errors.New("password del keystore mancante: usa -password-file o $" + keystorePasswordEnv)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/AleMoz97/merkle-tree-go/merkletree"
+)
+
+// runDiff implementa `merkletree diff [-key colonna] [-json] old.json new.json`
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	keyFlag := flags.String("key", "", "colonna (indice o nome del campo) da usare come chiave dei valori")
+	jsonOutput := flags.Bool("json", false, "stampa il risultato in formato JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: merkletree diff [-key colonna] [-json] old.json new.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	oldData, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del file: %v\n", err)
+		return 1
+	}
+	newData, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del file: %v\n", err)
+		return 1
+	}
+
+	var oldFormat, newFormat struct{ Format string }
+	if err := json.Unmarshal(oldData, &oldFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Dump non valido %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+	if err := json.Unmarshal(newData, &newFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Dump non valido %s: %v\n", flags.Arg(1), err)
+		return 1
+	}
+	if oldFormat.Format != newFormat.Format {
+		fmt.Fprintf(os.Stderr, "❌ Formati diversi: %q e %q\n", oldFormat.Format, newFormat.Format)
+		return 1
+	}
+
+	key := func(value interface{}) string { return valueKey(value, *keyFlag) }
+
+	switch oldFormat.Format {
+	case "simple-v1":
+		oldTree, newTree, err := loadSimpleDumps(oldData, newData)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		return printDiff(&oldTree.MerkleTreeImpl, &newTree.MerkleTreeImpl, func(v merkletree.BytesLike) string { return key(v) }, *jsonOutput)
+	default:
+		oldTree, newTree, err := loadStandardDumps(oldData, newData)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		return printDiff(&oldTree.MerkleTreeImpl, &newTree.MerkleTreeImpl, func(v interface{}) string { return key(v) }, *jsonOutput)
+	}
+}
+
+func loadStandardDumps(oldData []byte, newData []byte) (*merkletree.StandardMerkleTree[interface{}], *merkletree.StandardMerkleTree[interface{}], error) {
+	var oldDump, newDump merkletree.StandardMerkleTreeData[interface{}]
+	if err := json.Unmarshal(oldData, &oldDump); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(newData, &newDump); err != nil {
+		return nil, nil, err
+	}
+	oldTree, err := merkletree.LoadStandardMerkleTree(oldDump)
+	if err != nil {
+		return nil, nil, err
+	}
+	newTree, err := merkletree.LoadStandardMerkleTree(newDump)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldTree, newTree, nil
+}
+
+func loadSimpleDumps(oldData []byte, newData []byte) (*merkletree.SimpleMerkleTree, *merkletree.SimpleMerkleTree, error) {
+	var oldDump, newDump merkletree.SimpleMerkleTreeData
+	if err := json.Unmarshal(oldData, &oldDump); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(newData, &newDump); err != nil {
+		return nil, nil, err
+	}
+	oldTree, err := merkletree.LoadSimpleMerkleTree(oldDump, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	newTree, err := merkletree.LoadSimpleMerkleTree(newDump, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldTree, newTree, nil
+}
+
+// valueKey estrae la chiave di un valore: una colonna per gli array, un campo per gli oggetti
+func valueKey(value interface{}, column string) string {
+	if column != "" {
+		switch v := value.(type) {
+		case []interface{}:
+			if index, err := strconv.Atoi(column); err == nil && index >= 0 && index < len(v) {
+				return fmt.Sprint(v[index])
+			}
+		case map[string]interface{}:
+			if field, found := v[column]; found {
+				return fmt.Sprint(field)
+			}
+		}
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func printDiff[T any](oldTree *merkletree.MerkleTreeImpl[T], newTree *merkletree.MerkleTreeImpl[T], key func(T) string, jsonOutput bool) int {
+	diff, err := merkletree.Diff(oldTree, newTree, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	if jsonOutput {
+		encoded, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Errore nella serializzazione JSON: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(encoded))
+		return 0
+	}
+
+	fmt.Println("Root precedente:", diff.OldRoot)
+	fmt.Println("Root nuova:     ", diff.NewRoot)
+	if diff.ChangedNodes != nil {
+		fmt.Println("Nodi modificati:", *diff.ChangedNodes)
+	} else {
+		fmt.Println("Nodi modificati: n/d (alberi di dimensione diversa)")
+	}
+	fmt.Printf("\nAggiunti (%d):\n", len(diff.Added))
+	for _, v := range diff.Added {
+		fmt.Printf("  + %s\n", formatValue(v))
+	}
+	fmt.Printf("\nRimossi (%d):\n", len(diff.Removed))
+	for _, v := range diff.Removed {
+		fmt.Printf("  - %s\n", formatValue(v))
+	}
+	fmt.Printf("\nModificati (%d):\n", len(diff.Modified))
+	for _, change := range diff.Modified {
+		fmt.Printf("  ~ %s: %s -> %s\n", change.Key, formatValue(change.Old), formatValue(change.New))
+	}
+	return 0
+}
+
+func formatValue(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
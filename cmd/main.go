@@ -4,12 +4,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 
 	"github.com/AleMoz97/merkle-tree-go/merkletree"
 )
 
 func main() {
+	// La libreria è silenziosa di default: la CLI mostra avvisi ed errori su stderr
+	merkletree.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "sign-root":
+			os.Exit(runSignRoot(os.Args[2:]))
+		case "verify-root":
+			os.Exit(runVerifyRoot(os.Args[2:]))
+		case "airdrop":
+			os.Exit(runAirdrop(os.Args[2:]))
+		case "rewards":
+			os.Exit(runRewards(os.Args[2:]))
+		case "cmt-replay":
+			os.Exit(runReplay(os.Args[2:]))
+		}
+	}
+
+	runDemo()
+}
+
+// runDemo esegue la dimostrazione di SimpleMerkleTree
+func runDemo() {
 	fmt.Println("🚀 Inizio test per SimpleMerkleTree")
 
 	// 1️⃣ Creiamo un array di dati da includere nell'albero
@@ -43,7 +69,7 @@ func main() {
 	for i, p := range proof {
 		proofVal, err := merkletree.ToBytes(p)
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			log.Fatalf("❌ Errore nella conversione della proof: %v", err)
 		}
 		proofBytes[i] = proofVal
 	}
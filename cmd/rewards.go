@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/AleMoz97/merkle-tree-go/merkletree"
+)
+
+// runRewards implementa `merkletree rewards [-prev prev.json] -out new.json [-report file] [-allow-decrease] delta.csv`
+func runRewards(args []string) int {
+	flags := flag.NewFlagSet("rewards", flag.ContinueOnError)
+	previousPath := flags.String("prev", "", "dump dell'epoca precedente (omesso per la prima epoca)")
+	output := flags.String("out", "", "file in cui scrivere il dump della nuova epoca")
+	reportPath := flags.String("report", "", "file in cui scrivere il report JSON (default: riepilogo su stdout)")
+	allowDecrease := flags.Bool("allow-decrease", false, "consente di ridurre l'importo cumulativo di un account")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: merkletree rewards [-prev prev.json] -out new.json [-report report.json] [-allow-decrease] delta.csv")
+		fmt.Fprintln(flags.Output(), "Il CSV contiene righe account,delta.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *output == "" {
+		flags.Usage()
+		return 2
+	}
+
+	var previous *merkletree.StandardMerkleTree[[]byte]
+	if *previousPath != "" {
+		data, err := os.ReadFile(*previousPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del file: %v\n", err)
+			return 1
+		}
+		var dump merkletree.StandardMerkleTreeData[[]byte]
+		if err := json.Unmarshal(data, &dump); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Dump non valido %s: %v\n", *previousPath, err)
+			return 1
+		}
+		if previous, err = merkletree.LoadStandardMerkleTree(dump); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella lettura del file: %v\n", err)
+		return 1
+	}
+	deltas, err := merkletree.ParseRewardDeltasCSV(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ CSV non valido: %v\n", err)
+		return 1
+	}
+
+	tree, report, buildErr := merkletree.BuildCumulativeEpoch(previous, deltas, merkletree.CumulativeOptions{AllowDecrease: *allowDecrease})
+	if buildErr != nil && !errors.Is(buildErr, merkletree.ErrCumulativeDecrease) {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", buildErr)
+		return 1
+	}
+
+	if *reportPath != "" {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Errore nella serializzazione JSON: %v\n", err)
+			return 1
+		}
+		if err := os.WriteFile(*reportPath, encoded, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Errore nella scrittura del file: %v\n", err)
+			return 1
+		}
+	} else {
+		printRewardsReport(report)
+	}
+
+	if buildErr != nil {
+		for _, change := range report.Decreases {
+			fmt.Fprintf(os.Stderr, "  %s: %s -> %s\n", change.Account.Hex(), change.Previous, change.Current)
+		}
+		fmt.Fprintf(os.Stderr, "❌ %v (usa -allow-decrease per procedere comunque)\n", buildErr)
+		return 1
+	}
+
+	encoded, err := json.MarshalIndent(tree.Dump(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella serializzazione JSON: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*output, encoded, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Errore nella scrittura del file: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Nuova epoca salvata in %s (root %s)\n", *output, report.Root)
+	return 0
+}
+
+func printRewardsReport(report merkletree.CumulativeReport) {
+	if report.PreviousRoot != "" {
+		fmt.Println("Root precedente:  ", report.PreviousRoot)
+	}
+	fmt.Println("Account:          ", report.Accounts)
+	fmt.Println("Totale precedente:", report.PreviousTotal)
+	fmt.Println("Totale nuovo:     ", report.Total)
+	fmt.Println("Da distribuire:   ", report.DeltaTotal)
+	fmt.Printf("\nVariazioni (%d):\n", len(report.Changes))
+	for _, change := range report.Changes {
+		marker := "~"
+		if change.New {
+			marker = "+"
+		}
+		fmt.Printf("  %s %s: %s -> %s (%+d)\n", marker, change.Account.Hex(), change.Previous, change.Current, change.Delta)
+	}
+}
@@ -6,11 +6,27 @@ toolchain go1.23.7
 
 require (
 	github.com/ethereum/go-ethereum v1.15.5
+	github.com/fxamacker/cbor/v2 v2.9.0
+	github.com/iden3/go-iden3-crypto v0.0.17
+	github.com/klauspost/compress v1.18.0
 	golang.org/x/crypto v0.32.0
+	golang.org/x/sys v0.29.0
 )
 
 require (
+	github.com/bits-and-blooms/bitset v1.17.0 // indirect
+	github.com/consensys/bavard v0.1.22 // indirect
+	github.com/consensys/gnark-crypto v0.14.0 // indirect
+	github.com/crate-crypto/go-ipa v0.0.0-20240724233137-53bbb0ceb27a // indirect
+	github.com/crate-crypto/go-kzg-4844 v1.1.0 // indirect
+	github.com/deckarep/golang-set/v2 v2.6.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
+	github.com/ethereum/go-verkle v0.2.2 // indirect
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/google/uuid v1.3.0 // indirect
 	github.com/holiman/uint256 v1.3.2 // indirect
-	golang.org/x/sys v0.29.0 // indirect
+	github.com/mmcloughlin/addchain v0.4.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	rsc.io/tmplfunc v0.0.3 // indirect
 )
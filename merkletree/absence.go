@@ -0,0 +1,233 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Le proof di esclusione richiedono che la posizione di ogni foglia sia vincolata alla root.
+// Con StandardNodeHash i figli vengono ordinati prima dell'hash, quindi scambiarli non cambia
+// la root e due foglie non possono essere dimostrate adiacenti. SortedMerkleTree usa invece:
+//   - OrderedNodeHash, che rispetta l'ordine sinistro/destro dei figli;
+//   - SortedLeafHash (doppio keccak256), così una foglia non può essere confusa con un nodo interno.
+
+// SortedLeafHash calcola l'hash di una foglia come keccak256(keccak256(abi.encodePacked(value)))
+func SortedLeafHash[T any](value T) HexString {
+	return keccak256Hex(StandardLeafHash(value))
+}
+
+// OrderedNodeHash calcola l'hash di un nodo come keccak256(left || right), senza ordinare i figli
+func OrderedNodeHash(left BytesLike, right BytesLike) HexString {
+	concatenated, err := Concat(left, right)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: nodo non valido: %v", err))
+	}
+	return keccak256Hex(concatenated)
+}
+
+// SortedMerkleTree è un Merkle Tree con foglie ordinate e posizioni vincolate alla root,
+// che permette di dimostrare che un valore NON è presente (ProveAbsence / VerifyAbsence)
+type SortedMerkleTree[T any] struct {
+	MerkleTreeImpl[T]
+}
+
+// NewSortedMerkleTree crea un nuovo SortedMerkleTree con i valori dati
+func NewSortedMerkleTree[T any](values []T) *SortedMerkleTree[T] {
+	options := MerkleTreeOptions{SortLeaves: true}
+	tree, indexedValues := PrepareMerkleTree(values, options, SortedLeafHash[T], OrderedNodeHash)
+
+	sortedTree := &SortedMerkleTree[T]{
+		MerkleTreeImpl: MerkleTreeImpl[T]{
+			Tree:     tree,
+			Values:   indexedValues,
+			LeafHash: SortedLeafHash[T],
+			NodeHash: OrderedNodeHash,
+			Options:  options,
+		},
+	}
+	sortedTree.rebuildHashLookup()
+
+	return sortedTree
+}
+
+// VerifySortedMerkleTree verifica una proof di inclusione per un valore specifico.
+// La posizione della foglia è necessaria perché OrderedNodeHash non è simmetrica.
+func VerifySortedMerkleTree[T any](root BytesLike, leaf T, treeIndex int, proof []BytesLike) bool {
+	if treeIndex < 0 || len(proof) != treeDepth(treeIndex) {
+		return false
+	}
+	rootHex, err := ToHex(root)
+	if err != nil {
+		return false
+	}
+	return ProcessProofAt(SortedLeafHash(leaf), treeIndex, proof, OrderedNodeHash) == rootHex
+}
+
+// AbsenceBoundary è una foglia adiacente al valore assente, con la sua inclusion proof
+type AbsenceBoundary struct {
+	TreeIndex    int         `json:"treeIndex"`
+	LeafPreimage HexString   `json:"leafPreimage"` // keccak256(abi.encodePacked(value)): dimostra che il nodo è una foglia
+	Proof        []HexString `json:"proof"`
+}
+
+// AbsenceProof dimostra che un valore non è presente in un SortedMerkleTree.
+// Left e Right sono le foglie adiacenti che racchiudono l'hash del valore; se il valore
+// precede (o segue) tutte le foglie, manca Left (o Right) e Anchor contiene la foglia
+// all'estremo opposto, che vincola LeafCount alla root.
+type AbsenceProof struct {
+	LeafCount int              `json:"leafCount"`
+	Left      *AbsenceBoundary `json:"left,omitempty"`
+	Right     *AbsenceBoundary `json:"right,omitempty"`
+	Anchor    *AbsenceBoundary `json:"anchor,omitempty"`
+}
+
+// ProveAbsence genera una proof di esclusione per un valore non presente nell'albero
+func (m *SortedMerkleTree[T]) ProveAbsence(value T) (AbsenceProof, error) {
+	hash := m.LeafHash(value)
+	if _, found := m.HashLookup[hash]; found {
+		return AbsenceProof{}, errors.New("il valore è presente nell'albero")
+	}
+
+	store := m.nodes()
+	leafCount := m.leafCount()
+	firstLeaf := leafCount - 1
+
+	// Ricerca binaria della prima foglia maggiore dell'hash del valore
+	var searchErr error
+	position := sort.Search(leafCount, func(i int) bool {
+		leaf, err := store.Get(firstLeaf + i)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return compareHex(leaf, hash) > 0
+	})
+	if searchErr != nil {
+		return AbsenceProof{}, searchErr
+	}
+	if position > 0 {
+		leaf, err := store.Get(firstLeaf + position - 1)
+		if err != nil {
+			return AbsenceProof{}, err
+		}
+		if leaf == hash {
+			return AbsenceProof{}, errors.New("il valore è presente nell'albero")
+		}
+	}
+
+	proof := AbsenceProof{LeafCount: leafCount}
+	var err error
+	if position > 0 {
+		if proof.Left, err = m.absenceBoundary(firstLeaf + position - 1); err != nil {
+			return AbsenceProof{}, err
+		}
+	} else if proof.Anchor, err = m.absenceBoundary(store.Len() - 1); err != nil {
+		return AbsenceProof{}, err
+	}
+	if position < leafCount {
+		if proof.Right, err = m.absenceBoundary(firstLeaf + position); err != nil {
+			return AbsenceProof{}, err
+		}
+	} else if proof.Anchor, err = m.absenceBoundary(firstLeaf); err != nil {
+		return AbsenceProof{}, err
+	}
+
+	return proof, nil
+}
+
+// absenceBoundary costruisce la foglia di confine con indice `treeIndex` nell'albero
+func (m *SortedMerkleTree[T]) absenceBoundary(treeIndex int) (*AbsenceBoundary, error) {
+	for _, v := range m.Values {
+		if v.TreeIndex != treeIndex {
+			continue
+		}
+		proof, err := m.GetProofAt(treeIndex)
+		if err != nil {
+			return nil, err
+		}
+		return &AbsenceBoundary{
+			TreeIndex:    treeIndex,
+			LeafPreimage: StandardLeafHash(v.Value),
+			Proof:        proof,
+		}, nil
+	}
+	return nil, fmt.Errorf("nessun valore per la foglia %d", treeIndex)
+}
+
+// VerifyAbsence verifica che `value` non sia presente nel SortedMerkleTree con la root data
+func VerifyAbsence[T any](root BytesLike, value T, proof AbsenceProof) bool {
+	rootHex, err := ToHex(root)
+	if err != nil || proof.LeafCount <= 0 {
+		return false
+	}
+	hash := SortedLeafHash(value)
+	firstLeaf := proof.LeafCount - 1
+	lastLeaf := 2*proof.LeafCount - 2
+
+	switch {
+	case proof.Left != nil && proof.Right != nil:
+		if proof.Right.TreeIndex != proof.Left.TreeIndex+1 {
+			return false
+		}
+	case proof.Right != nil:
+		// Il valore precede tutte le foglie: Right è la prima, Anchor l'ultima
+		if proof.Right.TreeIndex != firstLeaf || proof.Anchor == nil || proof.Anchor.TreeIndex != lastLeaf {
+			return false
+		}
+	case proof.Left != nil:
+		// Il valore segue tutte le foglie: Left è l'ultima, Anchor la prima
+		if proof.Left.TreeIndex != lastLeaf || proof.Anchor == nil || proof.Anchor.TreeIndex != firstLeaf {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for _, boundary := range []*AbsenceBoundary{proof.Left, proof.Right, proof.Anchor} {
+		if boundary != nil && !verifyAbsenceBoundary(rootHex, boundary, firstLeaf, lastLeaf) {
+			return false
+		}
+	}
+
+	if proof.Left != nil && compareHex(keccak256Hex(proof.Left.LeafPreimage), hash) >= 0 {
+		return false
+	}
+	if proof.Right != nil && compareHex(hash, keccak256Hex(proof.Right.LeafPreimage)) >= 0 {
+		return false
+	}
+	return true
+}
+
+// verifyAbsenceBoundary verifica che il confine sia una foglia dell'albero nella posizione dichiarata
+func verifyAbsenceBoundary(root HexString, boundary *AbsenceBoundary, firstLeaf int, lastLeaf int) bool {
+	if boundary.TreeIndex < firstLeaf || boundary.TreeIndex > lastLeaf {
+		return false
+	}
+	if !IsValidMerkleNode(boundary.LeafPreimage) || len(boundary.Proof) != treeDepth(boundary.TreeIndex) {
+		return false
+	}
+	proof := make([]BytesLike, len(boundary.Proof))
+	for i, node := range boundary.Proof {
+		if !IsValidMerkleNode(node) {
+			return false
+		}
+		proof[i] = node
+	}
+	leaf := keccak256Hex(boundary.LeafPreimage)
+	return ProcessProofAt(leaf, boundary.TreeIndex, proof, OrderedNodeHash) == root
+}
+
+// keccak256Hex calcola il keccak256 di un valore in byte
+func keccak256Hex(value BytesLike) HexString {
+	valueBytes, err := ToBytes(value)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: valore non valido: %v", err))
+	}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(valueBytes)
+	hashed, _ := ToHex(hash.Sum(nil))
+	return hashed
+}
@@ -0,0 +1,135 @@
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSortedMerkleTreeInclusion(t *testing.T) {
+	tree := NewSortedMerkleTree(testValues(11))
+	for _, v := range tree.Values {
+		proof, err := tree.GetProofAt(v.TreeIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bytesProof := make([]BytesLike, len(proof))
+		for i, node := range proof {
+			bytesProof[i] = node
+		}
+		if !VerifySortedMerkleTree(tree.Root(), v.Value, v.TreeIndex, bytesProof) {
+			t.Fatalf("inclusione di %s non verificata", v.Value)
+		}
+		// La funzione ordinata vincola la posizione: la stessa proof non vale per la foglia accanto
+		if VerifySortedMerkleTree(tree.Root(), v.Value, v.TreeIndex^1, bytesProof) {
+			t.Fatalf("inclusione di %s accettata in una posizione diversa", v.Value)
+		}
+	}
+}
+
+func TestAbsenceProofs(t *testing.T) {
+	tree := NewSortedMerkleTree(testValues(11))
+	root := tree.Root()
+
+	// Abbastanza valori assenti da cadere prima, tra e dopo le foglie
+	before, after := 0, 0
+	for i := 0; i < 200; i++ {
+		value := fmt.Sprintf("assente-%d", i)
+		proof, err := tree.ProveAbsence(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Left == nil {
+			before++
+		}
+		if proof.Right == nil {
+			after++
+		}
+		if !VerifyAbsence(root, value, proof) {
+			t.Fatalf("esclusione di %s non verificata", value)
+		}
+	}
+	if before == 0 || after == 0 {
+		t.Fatalf("casi agli estremi non esercitati (prima %d, dopo %d)", before, after)
+	}
+
+	for _, v := range tree.Values {
+		if _, err := tree.ProveAbsence(v.Value); err == nil {
+			t.Fatalf("esclusione generata per il valore presente %s", v.Value)
+		}
+	}
+}
+
+func TestAbsenceRejectsForgedProofs(t *testing.T) {
+	values := testValues(11)
+	tree := NewSortedMerkleTree(values)
+	root := tree.Root()
+
+	var proof AbsenceProof
+	var absent string
+	for i := 0; ; i++ {
+		absent = fmt.Sprintf("assente-%d", i)
+		var err error
+		if proof, err = tree.ProveAbsence(absent); err != nil {
+			t.Fatal(err)
+		}
+		if proof.Left != nil && proof.Right != nil {
+			break
+		}
+	}
+
+	// La proof di un valore assente non vale per un valore presente
+	for _, value := range values {
+		if VerifyAbsence(root, value, proof) {
+			t.Fatalf("esclusione accettata per il valore presente %s", value)
+		}
+	}
+
+	// Confini non adiacenti: si salta una foglia che potrebbe contenere il valore
+	if proof.Left.TreeIndex > tree.leafCount()-1 {
+		skipped, err := tree.absenceBoundary(proof.Left.TreeIndex - 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged := proof
+		forged.Left = skipped
+		if VerifyAbsence(root, absent, forged) {
+			t.Fatal("esclusione accettata con confini non adiacenti")
+		}
+	}
+
+	forged := proof
+	forged.Right = nil
+	if VerifyAbsence(root, absent, forged) {
+		t.Fatal("esclusione accettata senza il confine destro né l'ancora")
+	}
+}
+
+func TestAbsenceEdgeProofBindsLeafCount(t *testing.T) {
+	tree := NewSortedMerkleTree(testValues(11))
+	root := tree.Root()
+
+	for i := 0; ; i++ {
+		absent := fmt.Sprintf("assente-%d", i)
+		proof, err := tree.ProveAbsence(absent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Left != nil && proof.Right != nil {
+			continue
+		}
+		// Agli estremi l'ancora fissa la prima o l'ultima foglia: un LeafCount diverso le sposta
+		for _, leafCount := range []int{proof.LeafCount - 1, proof.LeafCount + 1} {
+			forged := proof
+			forged.LeafCount = leafCount
+			if VerifyAbsence(root, absent, forged) {
+				t.Fatalf("esclusione agli estremi accettata con %d foglie", leafCount)
+			}
+		}
+		forged := proof
+		forged.Anchor = nil
+		if VerifyAbsence(root, absent, forged) {
+			t.Fatal("esclusione agli estremi accettata senza ancora")
+		}
+		return
+	}
+}
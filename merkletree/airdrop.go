@@ -0,0 +1,240 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// AirdropEntry è una riga della distribuzione: account, importo e indice opzionale
+type AirdropEntry struct {
+	Account string
+	Amount  *big.Int
+	Index   *uint64 // Se nil, l'indice viene assegnato in ordine di indirizzo
+}
+
+// AirdropClaim è la claim di un account nel formato di Uniswap merkle-distributor
+type AirdropClaim struct {
+	Index  uint64      `json:"index"`
+	Amount string      `json:"amount"` // Esadecimale, come in merkle-distributor
+	Proof  []HexString `json:"proof"`
+}
+
+// AirdropDistribution è il file JSON di Uniswap merkle-distributor
+type AirdropDistribution struct {
+	MerkleRoot HexString               `json:"merkleRoot"`
+	TokenTotal string                  `json:"tokenTotal"`
+	Claims     map[string]AirdropClaim `json:"claims"` // Indirizzi in formato EIP-55
+}
+
+// AirdropOptions definisce le opzioni del builder
+type AirdropOptions struct {
+	ExpectedTotal *big.Int // Se indicato, la somma degli importi deve coincidere
+}
+
+// AirdropLeaf codifica una claim come abi.encodePacked(uint256 index, address account, uint256 amount):
+// con StandardLeafHash si ottiene la foglia verificata dal contratto MerkleDistributor
+func AirdropLeaf(index uint64, account common.Address, amount *big.Int) []byte {
+	leaf := make([]byte, 0, 32+common.AddressLength+32)
+	leaf = append(leaf, math.U256Bytes(new(big.Int).SetUint64(index))...)
+	leaf = append(leaf, account.Bytes()...)
+	leaf = append(leaf, math.U256Bytes(new(big.Int).Set(amount))...)
+	return leaf
+}
+
+// BuildAirdrop costruisce l'albero e il file delle claim. Gli indirizzi vengono normalizzati
+// in EIP-55 e non possono ripetersi; gli importi devono essere positivi e stare in un uint256.
+// Il contratto verifica le proof con hash dei nodi ordinati, quindi il layout a heap
+// dell'albero è compatibile anche se la root differisce da quella dello script di Uniswap.
+func BuildAirdrop(entries []AirdropEntry, options AirdropOptions) (*AirdropDistribution, *StandardMerkleTree[[]byte], error) {
+	if len(entries) == 0 {
+		return nil, nil, errors.New("nessuna riga nella distribuzione")
+	}
+
+	type claim struct {
+		account common.Address
+		amount  *big.Int
+		index   uint64
+	}
+	claims := make([]claim, 0, len(entries))
+	seenAccounts := make(map[common.Address]bool, len(entries))
+	withIndex := entries[0].Index != nil
+	total := new(big.Int)
+
+	for i, entry := range entries {
+		if !common.IsHexAddress(entry.Account) {
+			return nil, nil, fmt.Errorf("riga %d: indirizzo non valido %q", i, entry.Account)
+		}
+		account := common.HexToAddress(entry.Account)
+		if seenAccounts[account] {
+			return nil, nil, fmt.Errorf("riga %d: indirizzo duplicato %s", i, account.Hex())
+		}
+		seenAccounts[account] = true
+
+		if entry.Amount == nil || entry.Amount.Sign() <= 0 || entry.Amount.BitLen() > 256 {
+			return nil, nil, fmt.Errorf("riga %d: importo non valido per %s", i, account.Hex())
+		}
+		if (entry.Index != nil) != withIndex {
+			return nil, nil, fmt.Errorf("riga %d: l'indice deve essere indicato per tutte le righe o per nessuna", i)
+		}
+
+		c := claim{account: account, amount: new(big.Int).Set(entry.Amount)}
+		if withIndex {
+			c.index = *entry.Index
+		}
+		claims = append(claims, c)
+		total.Add(total, entry.Amount)
+	}
+
+	if options.ExpectedTotal != nil && total.Cmp(options.ExpectedTotal) != 0 {
+		return nil, nil, fmt.Errorf("totale degli importi %s diverso da quello atteso %s", total, options.ExpectedTotal)
+	}
+	if total.BitLen() > 256 {
+		return nil, nil, fmt.Errorf("totale degli importi %s fuori dai limiti di uint256", total)
+	}
+
+	sort.Slice(claims, func(i, j int) bool {
+		return bytes.Compare(claims[i].account.Bytes(), claims[j].account.Bytes()) < 0
+	})
+	seenIndices := make(map[uint64]bool, len(claims))
+	for i := range claims {
+		if !withIndex {
+			claims[i].index = uint64(i)
+		}
+		if seenIndices[claims[i].index] {
+			return nil, nil, fmt.Errorf("indice duplicato %d", claims[i].index)
+		}
+		seenIndices[claims[i].index] = true
+	}
+
+	leaves := make([][]byte, len(claims))
+	for i, c := range claims {
+		leaves[i] = AirdropLeaf(c.index, c.account, c.amount)
+	}
+	tree := NewStandardMerkleTree(leaves, MerkleTreeOptions{})
+
+	distribution := &AirdropDistribution{
+		MerkleRoot: tree.Root(),
+		TokenTotal: hexutil.EncodeBig(total),
+		Claims:     make(map[string]AirdropClaim, len(claims)),
+	}
+	checkTotal := new(big.Int)
+	for i, c := range claims {
+		proof, err := tree.GetProofAt(tree.Values[i].TreeIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		distribution.Claims[c.account.Hex()] = AirdropClaim{
+			Index:  c.index,
+			Amount: hexutil.EncodeBig(c.amount),
+			Proof:  proof,
+		}
+		checkTotal.Add(checkTotal, c.amount)
+	}
+	if checkTotal.Cmp(total) != 0 {
+		return nil, nil, fmt.Errorf("totale delle claim %s diverso dal totale %s", checkTotal, total)
+	}
+
+	return distribution, tree, nil
+}
+
+// VerifyAirdropClaim verifica la claim di un account rispetto alla root della distribuzione
+func VerifyAirdropClaim(root BytesLike, account common.Address, claim AirdropClaim) bool {
+	amount, err := hexutil.DecodeBig(claim.Amount)
+	if err != nil {
+		return false
+	}
+	proof := make([]BytesLike, len(claim.Proof))
+	for i, node := range claim.Proof {
+		if !IsValidMerkleNode(node) {
+			return false
+		}
+		proof[i] = node
+	}
+	return VerifyStandardMerkleTree(root, AirdropLeaf(claim.Index, account, amount), proof)
+}
+
+// WriteShardedClaims scrive un file JSON per ogni account in dir/<prefisso>/<indirizzo>.json,
+// dove il prefisso sono i primi `prefixLength` caratteri esadecimali dell'indirizzo.
+// I nomi di file e directory sono in minuscolo, per l'hosting statico.
+func (d *AirdropDistribution) WriteShardedClaims(dir string, prefixLength int) error {
+	if prefixLength < 1 || prefixLength > 2*common.AddressLength {
+		return fmt.Errorf("lunghezza del prefisso %d non valida", prefixLength)
+	}
+
+	type shardedClaim struct {
+		MerkleRoot HexString `json:"merkleRoot"`
+		Account    string    `json:"account"`
+		AirdropClaim
+	}
+	for account, claim := range d.Claims {
+		name := strings.ToLower(strings.TrimPrefix(account, "0x"))
+		if len(name) != 2*common.AddressLength {
+			return fmt.Errorf("indirizzo non valido %q", account)
+		}
+		shardDir := filepath.Join(dir, name[:prefixLength])
+		if err := os.MkdirAll(shardDir, 0o755); err != nil {
+			return err
+		}
+		data, err := json.Marshal(shardedClaim{MerkleRoot: d.MerkleRoot, Account: account, AirdropClaim: claim})
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(shardDir, "0x"+name+".json"), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAirdropCSV legge righe "account,amount[,index]"; una prima riga di intestazione
+// che inizia con "account" viene ignorata. Gli importi sono in base 10.
+func ParseAirdropCSV(r io.Reader) ([]AirdropEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []AirdropEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "account") {
+			continue
+		}
+		if len(record) != 2 && len(record) != 3 {
+			return nil, fmt.Errorf("riga %d: attese 2 o 3 colonne, trovate %d", line, len(record))
+		}
+
+		amount, ok := new(big.Int).SetString(strings.TrimSpace(record[1]), 10)
+		if !ok {
+			return nil, fmt.Errorf("riga %d: importo non valido %q", line, record[1])
+		}
+		entry := AirdropEntry{Account: strings.TrimSpace(record[0]), Amount: amount}
+		if len(record) == 3 {
+			index, err := strconv.ParseUint(strings.TrimSpace(record[2]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("riga %d: indice non valido %q", line, record[2])
+			}
+			entry.Index = &index
+		}
+		entries = append(entries, entry)
+	}
+}
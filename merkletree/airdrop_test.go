@@ -0,0 +1,154 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testAirdropCSV = `account,amount
+0x00000000000000000000000000000000000000c3, 300
+0x00000000000000000000000000000000000000a1, 100
+0x00000000000000000000000000000000000000b2, 200
+0x00000000000000000000000000000000000000d4, 57896044618658097711785492504343953926634992332820282019728792003956564819968
+`
+
+func TestAirdropLeafEncoding(t *testing.T) {
+	account := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	leaf := AirdropLeaf(2, account, big.NewInt(256))
+	expected := strings.Repeat("00", 31) + "02" + strings.Repeat("00", 19) + "a1" + strings.Repeat("00", 30) + "0100"
+	if hex.EncodeToString(leaf) != expected {
+		t.Fatalf("foglia %x, attesa %s", leaf, expected)
+	}
+	// Il contratto MerkleDistributor verifica keccak256(abi.encodePacked(index, account, amount))
+	if StandardLeafHash(leaf) != HexString("0x"+hex.EncodeToString(crypto.Keccak256(leaf))) {
+		t.Fatal("l'hash della foglia non è il keccak256 della codifica packed")
+	}
+}
+
+func TestBuildAirdropFromCSV(t *testing.T) {
+	entries, err := ParseAirdropCSV(strings.NewReader(testAirdropCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("%d righe, attese 4", len(entries))
+	}
+	distribution, _, err := BuildAirdrop(entries, AirdropOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Senza indici espliciti gli indici seguono l'ordine degli indirizzi
+	order := []string{"a1", "b2", "c3", "d4"}
+	for index, suffix := range order {
+		account := common.HexToAddress("0x" + strings.Repeat("00", 19) + suffix)
+		claim, found := distribution.Claims[account.Hex()]
+		if !found {
+			t.Fatalf("claim di %s mancante", account.Hex())
+		}
+		if claim.Index != uint64(index) {
+			t.Fatalf("indice di %s = %d, atteso %d", suffix, claim.Index, index)
+		}
+		if !VerifyAirdropClaim(distribution.MerkleRoot, account, claim) {
+			t.Fatalf("claim di %s non valida", suffix)
+		}
+		tampered := claim
+		tampered.Amount = "0x1"
+		if VerifyAirdropClaim(distribution.MerkleRoot, account, tampered) {
+			t.Fatalf("claim di %s accettata con un altro importo", suffix)
+		}
+	}
+
+	total, _ := new(big.Int).SetString("57896044618658097711785492504343953926634992332820282019728792003956564819968", 10)
+	total.Add(total, big.NewInt(600))
+	if distribution.TokenTotal != "0x"+total.Text(16) {
+		t.Fatalf("totale %s", distribution.TokenTotal)
+	}
+}
+
+func TestBuildAirdropRejectsInvalidEntries(t *testing.T) {
+	index := uint64(0)
+	account := "0x00000000000000000000000000000000000000a1"
+	other := "0x00000000000000000000000000000000000000b2"
+	cases := map[string]struct {
+		entries []AirdropEntry
+		options AirdropOptions
+	}{
+		"indirizzo non valido": {entries: []AirdropEntry{{Account: "0x1234", Amount: big.NewInt(1)}}},
+		"indirizzo duplicato": {entries: []AirdropEntry{
+			{Account: account, Amount: big.NewInt(1)},
+			{Account: "0x00000000000000000000000000000000000000A1", Amount: big.NewInt(1)},
+		}},
+		"importo zero": {entries: []AirdropEntry{{Account: account, Amount: big.NewInt(0)}}},
+		"indici misti": {entries: []AirdropEntry{
+			{Account: account, Amount: big.NewInt(1), Index: &index},
+			{Account: other, Amount: big.NewInt(1)},
+		}},
+		"indice duplicato": {entries: []AirdropEntry{
+			{Account: account, Amount: big.NewInt(1), Index: &index},
+			{Account: other, Amount: big.NewInt(1), Index: &index},
+		}},
+		"totale oltre uint256": {entries: []AirdropEntry{
+			{Account: account, Amount: new(big.Int).Lsh(big.NewInt(1), 255)},
+			{Account: other, Amount: new(big.Int).Lsh(big.NewInt(1), 255)},
+		}},
+		"totale atteso": {
+			entries: []AirdropEntry{{Account: account, Amount: big.NewInt(1)}},
+			options: AirdropOptions{ExpectedTotal: big.NewInt(2)},
+		},
+	}
+	for name, c := range cases {
+		if _, _, err := BuildAirdrop(c.entries, c.options); err == nil {
+			t.Errorf("%s: distribuzione accettata", name)
+		}
+	}
+}
+
+func TestWriteShardedClaims(t *testing.T) {
+	entries, err := ParseAirdropCSV(strings.NewReader(testAirdropCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	distribution, _, err := BuildAirdrop(entries, AirdropOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := distribution.WriteShardedClaims(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for account, claim := range distribution.Claims {
+		name := strings.ToLower(account)
+		data, err := os.ReadFile(filepath.Join(dir, name[2:4], name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sharded struct {
+			MerkleRoot HexString `json:"merkleRoot"`
+			Account    string    `json:"account"`
+			AirdropClaim
+		}
+		if err := json.Unmarshal(data, &sharded); err != nil {
+			t.Fatal(err)
+		}
+		if sharded.MerkleRoot != distribution.MerkleRoot || sharded.Account != account || sharded.Index != claim.Index {
+			t.Fatalf("file della claim di %s non valido: %s", account, data)
+		}
+		if !VerifyAirdropClaim(sharded.MerkleRoot, common.HexToAddress(account), sharded.AirdropClaim) {
+			t.Fatalf("claim di %s letta dal file non valida", account)
+		}
+	}
+
+	if err := distribution.WriteShardedClaims(dir, 0); err == nil {
+		t.Fatal("prefisso vuoto accettato")
+	}
+}
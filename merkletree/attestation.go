@@ -0,0 +1,161 @@
+package merkletree
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Dominio EIP-712 delle attestazioni di root
+const (
+	AttestationDomainName    = "MerkleRootAttestation"
+	AttestationDomainVersion = "1"
+)
+
+// attestationTypes descrive i tipi EIP-712 dell'attestazione
+var attestationTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"RootAttestation": {
+		{Name: "root", Type: "bytes32"},
+		{Name: "leafCount", Type: "uint256"},
+		{Name: "leafEncoding", Type: "string"},
+		{Name: "epoch", Type: "uint256"},
+	},
+}
+
+// RootAttestation contiene la root di un albero e i metadati firmati insieme ad essa
+type RootAttestation struct {
+	Root         HexString      `json:"root"`
+	LeafCount    uint64         `json:"leafCount"`
+	LeafEncoding string         `json:"leafEncoding"`
+	Epoch        uint64         `json:"epoch"`
+	ChainID      uint64         `json:"chainId"`
+	Contract     common.Address `json:"contract"` // Contratto che userà la root (verifyingContract)
+}
+
+// SignedRootAttestation è un'attestazione con la firma EIP-712 del firmatario
+type SignedRootAttestation struct {
+	RootAttestation
+	Signer    common.Address `json:"signer"`
+	Signature HexString      `json:"signature"` // 65 byte r || s || v, con v = 27 o 28
+}
+
+// NewRootAttestation crea l'attestazione per la root di un albero
+func NewRootAttestation[T any](m *MerkleTreeImpl[T], epoch uint64, chainID uint64, contract common.Address) RootAttestation {
+	return RootAttestation{
+		Root:         m.Root(),
+		LeafCount:    uint64(m.leafCount()),
+		LeafEncoding: StandardLeafEncoding,
+		Epoch:        epoch,
+		ChainID:      chainID,
+		Contract:     contract,
+	}
+}
+
+// TypedData restituisce l'attestazione come typed data EIP-712
+func (a RootAttestation) TypedData() apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       attestationTypes,
+		PrimaryType: "RootAttestation",
+		Domain: apitypes.TypedDataDomain{
+			Name:              AttestationDomainName,
+			Version:           AttestationDomainVersion,
+			ChainId:           (*math.HexOrDecimal256)(new(big.Int).SetUint64(a.ChainID)),
+			VerifyingContract: a.Contract.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"root":         string(a.Root),
+			"leafCount":    strconv.FormatUint(a.LeafCount, 10),
+			"leafEncoding": a.LeafEncoding,
+			"epoch":        strconv.FormatUint(a.Epoch, 10),
+		},
+	}
+}
+
+// Hash calcola il digest EIP-712 dell'attestazione: keccak256("\x19\x01" || domainSeparator || hashStruct)
+func (a RootAttestation) Hash() ([]byte, error) {
+	if !IsValidMerkleNode(a.Root) {
+		return nil, fmt.Errorf("root %q non valida", a.Root)
+	}
+	hash, _, err := apitypes.TypedDataAndHash(a.TypedData())
+	return hash, err
+}
+
+// SignRootAttestation firma un'attestazione con la chiave privata data
+func SignRootAttestation(a RootAttestation, key *ecdsa.PrivateKey) (SignedRootAttestation, error) {
+	hash, err := a.Hash()
+	if err != nil {
+		return SignedRootAttestation{}, err
+	}
+	signature, err := crypto.Sign(hash, key)
+	if err != nil {
+		return SignedRootAttestation{}, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return SignedRootAttestation{
+		RootAttestation: a,
+		Signer:          crypto.PubkeyToAddress(key.PublicKey),
+		Signature:       HexString(hexutil.Encode(signature)),
+	}, nil
+}
+
+// RecoverAttestationSigner ricava l'indirizzo che ha firmato l'attestazione.
+// Il campo Signer dell'attestazione viene ignorato.
+func RecoverAttestationSigner(s SignedRootAttestation) (common.Address, error) {
+	hash, err := s.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	signature, err := ToBytes(s.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("firma di %d byte, attesi %d", len(signature), crypto.SignatureLength)
+	}
+	signature = append([]byte(nil), signature...)
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// ErrSignerNotAllowed indica che l'attestazione è firmata da un indirizzo non autorizzato
+var ErrSignerNotAllowed = errors.New("firmatario non autorizzato")
+
+// VerifyRootAttestation ricava il firmatario e verifica che sia nella allowlist.
+// Restituisce un errore anche se il campo Signer non corrisponde al firmatario ricavato.
+func VerifyRootAttestation(s SignedRootAttestation, allowlist []common.Address) (common.Address, error) {
+	signer, err := RecoverAttestationSigner(s)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if s.Signer != (common.Address{}) && s.Signer != signer {
+		return signer, fmt.Errorf("firmatario dichiarato %s diverso da quello ricavato %s", s.Signer.Hex(), signer.Hex())
+	}
+	for _, allowed := range allowlist {
+		if allowed == signer {
+			return signer, nil
+		}
+	}
+	return signer, fmt.Errorf("%w: %s", ErrSignerNotAllowed, signer.Hex())
+}
@@ -0,0 +1,101 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testAttestation(t *testing.T) RootAttestation {
+	t.Helper()
+	tree := NewStandardMerkleTree(testValues(5), MerkleTreeOptions{})
+	return NewRootAttestation(&tree.MerkleTreeImpl, 7, 1, common.HexToAddress("0x00000000000000000000000000000000000000aa"))
+}
+
+// eip712Word codifica un intero come parola da 32 byte
+func eip712Word(v uint64) []byte {
+	return common.LeftPadBytes(new(big.Int).SetUint64(v).Bytes(), 32)
+}
+
+func TestRootAttestationHashMatchesEIP712(t *testing.T) {
+	a := testAttestation(t)
+	hash, err := a.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Digest ricalcolato a mano secondo EIP-712, senza apitypes
+	domainType := crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	domainSeparator := crypto.Keccak256(
+		domainType,
+		crypto.Keccak256([]byte(AttestationDomainName)),
+		crypto.Keccak256([]byte(AttestationDomainVersion)),
+		eip712Word(a.ChainID),
+		common.LeftPadBytes(a.Contract.Bytes(), 32),
+	)
+	structType := crypto.Keccak256([]byte("RootAttestation(bytes32 root,uint256 leafCount,string leafEncoding,uint256 epoch)"))
+	root, _ := ToBytes(a.Root)
+	structHash := crypto.Keccak256(
+		structType,
+		root,
+		eip712Word(a.LeafCount),
+		crypto.Keccak256([]byte(a.LeafEncoding)),
+		eip712Word(a.Epoch),
+	)
+	expected := crypto.Keccak256([]byte("\x19\x01"), domainSeparator, structHash)
+	if !bytes.Equal(hash, expected) {
+		t.Fatalf("digest %x, atteso %x", hash, expected)
+	}
+}
+
+func TestRootAttestationSignAndVerify(t *testing.T) {
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerAddress := crypto.PubkeyToAddress(key.PublicKey)
+	other := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	signed, err := SignRootAttestation(testAttestation(t), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, _ := ToBytes(signed.Signature)
+	if v := signature[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+		t.Fatalf("v = %d, atteso 27 o 28", v)
+	}
+
+	if signer, err := VerifyRootAttestation(signed, []common.Address{other, signerAddress}); err != nil || signer != signerAddress {
+		t.Fatalf("firmatario %s, errore %v", signer.Hex(), err)
+	}
+	if _, err := VerifyRootAttestation(signed, []common.Address{other}); !errors.Is(err, ErrSignerNotAllowed) {
+		t.Fatalf("errore %v, atteso ErrSignerNotAllowed", err)
+	}
+
+	declared := signed
+	declared.Signer = other
+	if _, err := VerifyRootAttestation(declared, []common.Address{other, signerAddress}); err == nil {
+		t.Fatal("accettato un firmatario dichiarato diverso da quello ricavato")
+	}
+
+	// Ogni campo firmato cambia il firmatario ricavato
+	tampered := []func(*SignedRootAttestation){
+		func(s *SignedRootAttestation) { s.Epoch++ },
+		func(s *SignedRootAttestation) { s.LeafCount++ },
+		func(s *SignedRootAttestation) { s.ChainID++ },
+		func(s *SignedRootAttestation) { s.Contract = other },
+		func(s *SignedRootAttestation) { s.Root = StandardLeafHash("altra root") },
+	}
+	for i, tamper := range tampered {
+		modified := signed
+		modified.Signer = common.Address{}
+		tamper(&modified)
+		if signer, err := RecoverAttestationSigner(modified); err == nil && signer == signerAddress {
+			t.Fatalf("modifica %d: firma ancora valida", i)
+		}
+	}
+}
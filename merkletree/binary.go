@@ -0,0 +1,640 @@
+package merkletree
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"reflect"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// binaryMagic identifica i file binari dell'albero
+const binaryMagic = "MKTB"
+
+// BinaryFormatVersion è la versione corrente del formato binario
+const BinaryFormatVersion = 1
+
+// StandardLeafEncoding descrive la codifica delle foglie usata da StandardLeafHash
+const StandardLeafEncoding = "abi-packed-keccak256"
+
+// Compression indica l'algoritmo di compressione della sezione dati
+type Compression uint8
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZstd
+)
+
+// HashAlgorithm identifica la funzione di hash dei nodi salvata nell'header
+type HashAlgorithm uint8
+
+const (
+	HashAlgorithmCustom          HashAlgorithm = iota // Funzione fornita dall'utente, non ricostruibile
+	HashAlgorithmKeccak256Sorted                      // StandardNodeHash
+	HashAlgorithmRFC6962                              // RFC6962NodeHash
+)
+
+// BinaryOptions definisce le opzioni di scrittura del formato binario
+type BinaryOptions struct {
+	Compression Compression
+}
+
+// DefaultBinaryOptions è usata da MarshalBinary e WriteTo
+var DefaultBinaryOptions = BinaryOptions{Compression: CompressionNone}
+
+// MaxBinaryBodySize è la dimensione massima, in byte, della sezione dati decompressa accettata
+// dalla decodifica: limita la memoria usata da file corrotti o malevoli (es. zip bomb)
+var MaxBinaryBodySize int64 = 1 << 30
+
+// Bit del byte flags dell'header
+const (
+	binaryFlagSortLeaves byte = 1 << iota
+	binaryFlagFixedDepth      // La sezione dati contiene profondità (u8) e foglia zero (32 byte)
+)
+
+// binaryValueMinSize è la dimensione minima di un valore nella sezione dati (treeIndex, tag, len)
+const binaryValueMinSize = 8 + 1 + 4
+
+// Tag di tipo dei valori nella sezione valori
+const (
+	binaryValueString byte = iota + 1
+	binaryValueBytes
+	binaryValueHexString
+	binaryValueUint8
+	binaryValueUint16
+	binaryValueUint32
+	binaryValueUint64
+	binaryValueInt8
+	binaryValueInt16
+	binaryValueInt32
+	binaryValueInt64
+)
+
+// binaryTree contiene i campi letti da un file binario
+type binaryTree[T any] struct {
+	Format        string
+	LeafEncoding  string
+	HashAlgorithm HashAlgorithm
+	Options       MerkleTreeOptions
+	Tree          []HexString
+	Values        []struct {
+		Value     T
+		TreeIndex int
+	}
+}
+
+// MarshalBinary codifica l'albero nel formato binario (implementa encoding.BinaryMarshaler)
+func (m *StandardMerkleTree[T]) MarshalBinary() ([]byte, error) {
+	return m.MarshalBinaryWithOptions(DefaultBinaryOptions)
+}
+
+// MarshalBinaryWithOptions codifica l'albero nel formato binario con le opzioni date
+func (m *StandardMerkleTree[T]) MarshalBinaryWithOptions(options BinaryOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := writeTreeBinary(&buf, &m.MerkleTreeImpl, "standard-v1", options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo scrive l'albero nel formato binario (implementa io.WriterTo)
+func (m *StandardMerkleTree[T]) WriteTo(w io.Writer) (int64, error) {
+	return writeTreeBinary(w, &m.MerkleTreeImpl, "standard-v1", DefaultBinaryOptions)
+}
+
+// UnmarshalBinary decodifica un albero dal formato binario (implementa encoding.BinaryUnmarshaler)
+func (m *StandardMerkleTree[T]) UnmarshalBinary(data []byte) error {
+	decoded, err := decodeTreeBinary[T](data)
+	if err != nil {
+		return err
+	}
+	if decoded.Format != "standard-v1" || decoded.HashAlgorithm != HashAlgorithmKeccak256Sorted {
+		return fmt.Errorf("formato %q con hash %d non compatibile con StandardMerkleTree", decoded.Format, decoded.HashAlgorithm)
+	}
+
+	m.MerkleTreeImpl = MerkleTreeImpl[T]{
+		Tree:     decoded.Tree,
+		Values:   decoded.Values,
+		LeafHash: StandardLeafHash[T],
+		NodeHash: StandardNodeHash,
+		Options:  decoded.Options,
+	}
+	m.rebuildHashLookup()
+	return nil
+}
+
+// ReadFrom legge un albero nel formato binario (implementa io.ReaderFrom)
+func (m *StandardMerkleTree[T]) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	return int64(len(data)), m.UnmarshalBinary(data)
+}
+
+// MarshalBinary codifica l'albero nel formato binario (implementa encoding.BinaryMarshaler)
+func (m *SimpleMerkleTree) MarshalBinary() ([]byte, error) {
+	return m.MarshalBinaryWithOptions(DefaultBinaryOptions)
+}
+
+// MarshalBinaryWithOptions codifica l'albero nel formato binario con le opzioni date
+func (m *SimpleMerkleTree) MarshalBinaryWithOptions(options BinaryOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := writeTreeBinary(&buf, &m.MerkleTreeImpl, "simple-v1", options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo scrive l'albero nel formato binario (implementa io.WriterTo)
+func (m *SimpleMerkleTree) WriteTo(w io.Writer) (int64, error) {
+	return writeTreeBinary(w, &m.MerkleTreeImpl, "simple-v1", DefaultBinaryOptions)
+}
+
+// UnmarshalBinary decodifica un albero dal formato binario (implementa encoding.BinaryUnmarshaler).
+// Se l'albero è stato scritto con una NodeHash personalizzata, viene mantenuta quella già
+// impostata sul ricevente.
+func (m *SimpleMerkleTree) UnmarshalBinary(data []byte) error {
+	decoded, err := decodeTreeBinary[BytesLike](data)
+	if err != nil {
+		return err
+	}
+	if decoded.Format != "simple-v1" {
+		return fmt.Errorf("formato %q non compatibile con SimpleMerkleTree", decoded.Format)
+	}
+
+	nodeHash := m.NodeHash
+	switch decoded.HashAlgorithm {
+	case HashAlgorithmKeccak256Sorted:
+		nodeHash = nil
+	case HashAlgorithmRFC6962:
+		nodeHash = RFC6962NodeHash
+	default:
+		if nodeHash == nil {
+			return errors.New("l'albero usa una NodeHash personalizzata: impostarla prima di UnmarshalBinary")
+		}
+	}
+
+	m.MerkleTreeImpl = MerkleTreeImpl[BytesLike]{
+		Tree:     decoded.Tree,
+		Values:   decoded.Values,
+		LeafHash: FormatLeaf,
+		NodeHash: nodeHash,
+		Options:  decoded.Options,
+	}
+	m.rebuildHashLookup()
+	return nil
+}
+
+// ReadFrom legge un albero nel formato binario (implementa io.ReaderFrom)
+func (m *SimpleMerkleTree) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	return int64(len(data)), m.UnmarshalBinary(data)
+}
+
+// HashAlgorithmOf restituisce l'identificativo della funzione di hash dei nodi
+func HashAlgorithmOf(nodeHash NodeHash) HashAlgorithm {
+	switch reflect.ValueOf(nodeHash).Pointer() {
+	case reflect.ValueOf(NodeHash(nil)).Pointer(), reflect.ValueOf(StandardNodeHash).Pointer():
+		return HashAlgorithmKeccak256Sorted
+	case reflect.ValueOf(RFC6962NodeHash).Pointer():
+		return HashAlgorithmRFC6962
+	default:
+		return HashAlgorithmCustom
+	}
+}
+
+// countingWriter conta i byte scritti
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeTreeBinary scrive header, sezione dati (eventualmente compressa) e checksum finale.
+//
+//	magic[4] | version u8 | compression u8 | hashAlgorithm u8 | flags u8 |
+//	dati: format, leafEncoding (u16 + byte), [fixedDepth u8, zeroLeaf 32 byte se flags&2],
+//	      nodeCount u64, nodi da 32 byte,
+//	      valueCount u64, valori (treeIndex u64, tag u8, len u32, ABI packed) |
+//	crc32 (IEEE, big-endian) di tutti i byte precedenti
+func writeTreeBinary[T any](w io.Writer, m *MerkleTreeImpl[T], format string, options BinaryOptions) (int64, error) {
+	counter := &countingWriter{w: w}
+	checksum := crc32.NewIEEE()
+	out := io.MultiWriter(counter, checksum)
+
+	var flags byte
+	if m.Options.SortLeaves {
+		flags |= binaryFlagSortLeaves
+	}
+	if m.Options.FixedDepth > 0 {
+		flags |= binaryFlagFixedDepth
+	}
+	header := []byte(binaryMagic)
+	header = append(header, BinaryFormatVersion, byte(options.Compression), byte(HashAlgorithmOf(m.NodeHash)), flags)
+	if _, err := out.Write(header); err != nil {
+		return counter.n, err
+	}
+
+	body, err := compressWriter(out, options.Compression)
+	if err != nil {
+		return counter.n, err
+	}
+	if err := writeTreeBody(body, m, format); err != nil {
+		return counter.n, err
+	}
+	if err := body.Close(); err != nil {
+		return counter.n, err
+	}
+
+	if err := binary.Write(counter, binary.BigEndian, checksum.Sum32()); err != nil {
+		return counter.n, err
+	}
+	return counter.n, nil
+}
+
+// writeTreeBody scrive la sezione dati non compressa
+func writeTreeBody[T any](w io.Writer, m *MerkleTreeImpl[T], format string) error {
+	buffered := newBinaryWriter(w)
+	buffered.writeString(format)
+	buffered.writeString(StandardLeafEncoding)
+	if m.Options.FixedDepth > 0 {
+		if m.Options.FixedDepth > MaxFixedDepth {
+			return fmt.Errorf("profondità %d non valida (max %d)", m.Options.FixedDepth, MaxFixedDepth)
+		}
+		zeroLeaf, err := nodeRecord(m.Options.zeroLeaf())
+		if err != nil {
+			return fmt.Errorf("foglia zero: %w", err)
+		}
+		buffered.write([]byte{byte(m.Options.FixedDepth)})
+		buffered.write(zeroLeaf)
+	}
+
+	store := m.nodes()
+	buffered.writeUint64(uint64(store.Len()))
+	for i := 0; i < store.Len(); i++ {
+		node, err := store.Get(i)
+		if err != nil {
+			return err
+		}
+		record, err := nodeRecord(node)
+		if err != nil {
+			return err
+		}
+		buffered.write(record)
+	}
+
+	buffered.writeUint64(uint64(len(m.Values)))
+	for i, v := range m.Values {
+		tag, encoded, err := encodeBinaryValue(v.Value)
+		if err != nil {
+			return fmt.Errorf("valore %d: %w", i, err)
+		}
+		buffered.writeUint64(uint64(v.TreeIndex))
+		buffered.write([]byte{tag})
+		buffered.writeUint32(uint32(len(encoded)))
+		buffered.write(encoded)
+	}
+	return buffered.err
+}
+
+// decodeTreeBinary verifica il checksum e decodifica un albero dal formato binario
+func decodeTreeBinary[T any](data []byte) (binaryTree[T], error) {
+	var decoded binaryTree[T]
+	const headerSize = len(binaryMagic) + 4
+	if len(data) < headerSize+4 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return decoded, errors.New("file binario dell'albero non valido")
+	}
+	payload, trailer := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(trailer) {
+		return decoded, errors.New("checksum del file binario non valido")
+	}
+
+	header := payload[len(binaryMagic):headerSize]
+	if header[0] != BinaryFormatVersion {
+		return decoded, fmt.Errorf("versione del formato %d non supportata", header[0])
+	}
+	decoded.HashAlgorithm = HashAlgorithm(header[2])
+	decoded.Options.SortLeaves = header[3]&binaryFlagSortLeaves != 0
+	fixedDepth := header[3]&binaryFlagFixedDepth != 0
+
+	body, err := decompressBody(payload[headerSize:], Compression(header[1]))
+	if err != nil {
+		return decoded, err
+	}
+
+	// Contatori e lunghezze vengono confrontati con i byte rimanenti prima di allocare
+	reader := newBinaryReader(body)
+	decoded.Format = reader.readString()
+	decoded.LeafEncoding = reader.readString()
+	if reader.err == nil && decoded.LeafEncoding != StandardLeafEncoding {
+		return decoded, fmt.Errorf("codifica delle foglie %q non supportata", decoded.LeafEncoding)
+	}
+	if fixedDepth {
+		decoded.Options.FixedDepth = int(reader.read(1)[0])
+		zeroLeaf, _ := ToHex(reader.read(NodeSize))
+		if reader.err == nil && decoded.Options.FixedDepth == 0 {
+			return decoded, errors.New("profondità fissa nulla con il flag FixedDepth attivo")
+		}
+		if zeroLeaf != ZeroLeaf {
+			decoded.Options.ZeroLeaf = zeroLeaf
+		}
+	}
+
+	nodeCount := reader.readCount(NodeSize)
+	decoded.Tree = make([]HexString, 0, nodeCount)
+	for i := 0; i < nodeCount && reader.err == nil; i++ {
+		node, _ := ToHex(reader.read(NodeSize))
+		decoded.Tree = append(decoded.Tree, node)
+	}
+
+	valueCount := reader.readCount(binaryValueMinSize)
+	decoded.Values = make([]struct {
+		Value     T
+		TreeIndex int
+	}, 0, valueCount)
+	for i := 0; i < valueCount && reader.err == nil; i++ {
+		treeIndex := reader.readUint64()
+		tag := reader.read(1)
+		encoded := reader.read(reader.readLength())
+		if reader.err != nil {
+			break
+		}
+		value, err := decodeBinaryValue[T](tag[0], encoded)
+		if err != nil {
+			return decoded, fmt.Errorf("valore %d: %w", i, err)
+		}
+		decoded.Values = append(decoded.Values, struct {
+			Value     T
+			TreeIndex int
+		}{Value: value, TreeIndex: int(treeIndex)})
+	}
+	if reader.err != nil {
+		return decoded, fmt.Errorf("file binario troncato: %w", reader.err)
+	}
+
+	if err := checkDumpIndices(decoded.Tree, len(decoded.Values), func(i int) int { return decoded.Values[i].TreeIndex }); err != nil {
+		return decoded, err
+	}
+	if err := checkFixedDepthTree(decoded.Tree, decoded.Options.FixedDepth); err != nil {
+		return decoded, err
+	}
+	return decoded, nil
+}
+
+// encodeBinaryValue restituisce il tag di tipo e la codifica ABI packed di un valore
+func encodeBinaryValue(value interface{}) (byte, []byte, error) {
+	var tag byte
+	switch v := value.(type) {
+	case string:
+		tag = binaryValueString
+	case []byte:
+		tag = binaryValueBytes
+	case HexString:
+		return binaryValueHexString, []byte(v), nil
+	case uint8:
+		tag = binaryValueUint8
+	case uint16:
+		tag = binaryValueUint16
+	case uint32:
+		tag = binaryValueUint32
+	case uint64:
+		tag = binaryValueUint64
+	case int8:
+		return binaryValueInt8, []byte{byte(v)}, nil
+	case int16:
+		return binaryValueInt16, uintToBytes(uint16(v)), nil
+	case int32:
+		return binaryValueInt32, uintToBytes(uint32(v)), nil
+	case int64:
+		return binaryValueInt64, uintToBytes(uint64(v)), nil
+	default:
+		return 0, nil, fmt.Errorf("tipo non supportato: %T", value)
+	}
+	encoded, err := abiEncodePacked(value)
+	return tag, encoded, err
+}
+
+// decodeBinaryValue ricostruisce un valore di tipo T dal tag e dalla codifica ABI packed
+func decodeBinaryValue[T any](tag byte, data []byte) (T, error) {
+	var zero T
+	var value interface{}
+	switch tag {
+	case binaryValueString:
+		value = string(data)
+	case binaryValueBytes:
+		value = append([]byte{}, data...)
+	case binaryValueHexString:
+		value = HexString(data)
+	case binaryValueUint8, binaryValueInt8:
+		if len(data) != 1 {
+			return zero, errors.New("lunghezza non valida")
+		}
+		if tag == binaryValueUint8 {
+			value = data[0]
+		} else {
+			value = int8(data[0])
+		}
+	case binaryValueUint16, binaryValueInt16:
+		if len(data) != 2 {
+			return zero, errors.New("lunghezza non valida")
+		}
+		if tag == binaryValueUint16 {
+			value = binary.BigEndian.Uint16(data)
+		} else {
+			value = int16(binary.BigEndian.Uint16(data))
+		}
+	case binaryValueUint32, binaryValueInt32:
+		if len(data) != 4 {
+			return zero, errors.New("lunghezza non valida")
+		}
+		if tag == binaryValueUint32 {
+			value = binary.BigEndian.Uint32(data)
+		} else {
+			value = int32(binary.BigEndian.Uint32(data))
+		}
+	case binaryValueUint64, binaryValueInt64:
+		if len(data) != 8 {
+			return zero, errors.New("lunghezza non valida")
+		}
+		if tag == binaryValueUint64 {
+			value = binary.BigEndian.Uint64(data)
+		} else {
+			value = int64(binary.BigEndian.Uint64(data))
+		}
+	default:
+		return zero, fmt.Errorf("tag di tipo %d sconosciuto", tag)
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("valore di tipo %T non assegnabile a %T", value, zero)
+	}
+	return typed, nil
+}
+
+// nopWriteCloser adatta un io.Writer senza compressione
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func compressWriter(w io.Writer, compression Compression) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("compressione %d non supportata", compression)
+	}
+}
+
+// decompressBody decomprime la sezione dati rifiutando quelle oltre MaxBinaryBodySize
+func decompressBody(data []byte, compression Compression) ([]byte, error) {
+	if compression == CompressionNone {
+		if int64(len(data)) > MaxBinaryBodySize {
+			return nil, fmt.Errorf("sezione dati oltre il limite di %d byte", MaxBinaryBodySize)
+		}
+		return data, nil
+	}
+	body, err := decompressReader(bytes.NewReader(data), compression)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(body, MaxBinaryBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decompressed)) > MaxBinaryBodySize {
+		return nil, fmt.Errorf("sezione dati decompressa oltre il limite di %d byte", MaxBinaryBodySize)
+	}
+	return decompressed, nil
+}
+
+func decompressReader(r io.Reader, compression Compression) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return io.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("compressione %d non supportata", compression)
+	}
+}
+
+// binaryWriter accumula il primo errore di scrittura
+type binaryWriter struct {
+	w   io.Writer
+	err error
+}
+
+func newBinaryWriter(w io.Writer) *binaryWriter {
+	return &binaryWriter{w: w}
+}
+
+func (b *binaryWriter) write(p []byte) {
+	if b.err == nil {
+		_, b.err = b.w.Write(p)
+	}
+}
+
+func (b *binaryWriter) writeUint32(v uint32) {
+	b.write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (b *binaryWriter) writeUint64(v uint64) {
+	b.write(binary.BigEndian.AppendUint64(nil, v))
+}
+
+func (b *binaryWriter) writeString(s string) {
+	b.write(binary.BigEndian.AppendUint16(nil, uint16(len(s))))
+	b.write([]byte(s))
+}
+
+// binaryReader legge da un buffer in memoria e accumula il primo errore di lettura; le
+// lunghezze oltre i byte rimanenti sono un errore e non vengono allocate
+type binaryReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func newBinaryReader(data []byte) *binaryReader {
+	return &binaryReader{r: bytes.NewReader(data)}
+}
+
+func (b *binaryReader) read(n int) []byte {
+	if b.err == nil && (n < 0 || n > b.r.Len()) {
+		b.err = fmt.Errorf("%w: richiesti %d byte, rimanenti %d", io.ErrUnexpectedEOF, n, b.r.Len())
+	}
+	if b.err != nil {
+		// Buffer azzerato per i chiamanti che leggono campi a lunghezza fissa
+		return make([]byte, min(max(n, 0), NodeSize))
+	}
+	buf := make([]byte, n)
+	_, b.err = io.ReadFull(b.r, buf)
+	return buf
+}
+
+func (b *binaryReader) readUint32() uint32 {
+	return binary.BigEndian.Uint32(b.read(4))
+}
+
+func (b *binaryReader) readUint64() uint64 {
+	return binary.BigEndian.Uint64(b.read(8))
+}
+
+func (b *binaryReader) readString() string {
+	length := binary.BigEndian.Uint16(b.read(2))
+	return string(b.read(int(length)))
+}
+
+// readLength legge una lunghezza u32, rifiutando quelle oltre i byte rimanenti
+func (b *binaryReader) readLength() int {
+	length := uint64(b.readUint32())
+	if b.err == nil && length > uint64(b.r.Len()) {
+		b.err = fmt.Errorf("%w: lunghezza %d oltre i %d byte rimanenti", io.ErrUnexpectedEOF, length, b.r.Len())
+	}
+	if b.err != nil {
+		return 0
+	}
+	return int(length)
+}
+
+// readCount legge un contatore u64 di elementi da almeno `minSize` byte, rifiutando quelli
+// che non possono stare nei byte rimanenti
+func (b *binaryReader) readCount(minSize int) int {
+	count := b.readUint64()
+	if b.err == nil && count > uint64(b.r.Len()/minSize) {
+		b.err = fmt.Errorf("%w: %d elementi non possono stare in %d byte", io.ErrUnexpectedEOF, count, b.r.Len())
+	}
+	if b.err != nil {
+		return 0
+	}
+	return int(count)
+}
@@ -0,0 +1,150 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+// craftTreeBinary costruisce un file binario non compresso con la sezione dati data e un checksum valido
+func craftTreeBinary(body []byte) []byte {
+	data := append([]byte(binaryMagic), BinaryFormatVersion, byte(CompressionNone), byte(HashAlgorithmKeccak256Sorted), 1)
+	data = append(data, body...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+// craftTreeBody restituisce l'inizio della sezione dati fino al contatore dei nodi escluso
+func craftTreeBody() []byte {
+	var buf bytes.Buffer
+	writer := newBinaryWriter(&buf)
+	writer.writeString("standard-v1")
+	writer.writeString(StandardLeafEncoding)
+	return buf.Bytes()
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(9), MerkleTreeOptions{})
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		data, err := tree.MarshalBinaryWithOptions(BinaryOptions{Compression: compression})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded StandardMerkleTree[string]
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("compressione %d: %v", compression, err)
+		}
+		if decoded.Root() != tree.Root() || len(decoded.Values) != len(tree.Values) {
+			t.Fatalf("compressione %d: albero decodificato diverso", compression)
+		}
+		for i, v := range tree.Values {
+			if decoded.Values[i] != v {
+				t.Fatalf("compressione %d: valore %d = %+v, atteso %+v", compression, i, decoded.Values[i], v)
+			}
+		}
+	}
+}
+
+func TestBinaryRoundTripFixedDepth(t *testing.T) {
+	zeroLeaf := StandardLeafHash("vuota")
+	for _, options := range []MerkleTreeOptions{{FixedDepth: 3}, {FixedDepth: 4, ZeroLeaf: zeroLeaf}} {
+		tree := NewStandardMerkleTree(testValues(5), options)
+		for _, compression := range []Compression{CompressionNone, CompressionZstd} {
+			data, err := tree.MarshalBinaryWithOptions(BinaryOptions{Compression: compression})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded StandardMerkleTree[string]
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatalf("profondità %d: %v", options.FixedDepth, err)
+			}
+			if decoded.Options != tree.Options || decoded.Root() != tree.Root() {
+				t.Fatalf("profondità %d: opzioni %+v, attese %+v", options.FixedDepth, decoded.Options, tree.Options)
+			}
+
+			// L'albero decodificato resta a profondità fissa: gli append non cambiano la struttura
+			if err := decoded.AppendLeaves("valore-z"); err != nil {
+				t.Fatal(err)
+			}
+			expected := NewStandardMerkleTree(append(testValues(5), "valore-z"), options)
+			if decoded.Root() != expected.Root() || len(decoded.Tree) != len(expected.Tree) {
+				t.Fatalf("profondità %d: dopo AppendLeaves root %s, attesa %s", options.FixedDepth, decoded.Root(), expected.Root())
+			}
+		}
+	}
+
+	// Un flag FixedDepth con una profondità che non corrisponde ai nodi viene rifiutato
+	tree := NewStandardMerkleTree(testValues(5), MerkleTreeOptions{FixedDepth: 3})
+	tree.Options.FixedDepth = 4
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded StandardMerkleTree[string]
+	if err := decoded.UnmarshalBinary(data); err == nil {
+		t.Fatal("profondità incoerente con i nodi accettata")
+	}
+}
+
+func TestBinaryRejectsOversizedCounts(t *testing.T) {
+	node := bytes.Repeat([]byte{0xab}, NodeSize)
+
+	hugeNodes := binary.BigEndian.AppendUint64(craftTreeBody(), 1<<40)
+	hugeNodes = append(hugeNodes, node...)
+
+	oneNode := append(binary.BigEndian.AppendUint64(craftTreeBody(), 1), node...)
+	hugeValues := binary.BigEndian.AppendUint64(append([]byte{}, oneNode...), 1<<40)
+
+	hugeLength := binary.BigEndian.AppendUint64(append([]byte{}, oneNode...), 1)
+	hugeLength = binary.BigEndian.AppendUint64(hugeLength, 0)
+	hugeLength = append(hugeLength, binaryValueString)
+	hugeLength = binary.BigEndian.AppendUint32(hugeLength, 0xffffffff)
+	hugeLength = append(hugeLength, "valore"...)
+
+	cases := map[string][]byte{
+		"nodeCount":    hugeNodes,
+		"valueCount":   hugeValues,
+		"value length": hugeLength,
+	}
+	for name, body := range cases {
+		var decoded StandardMerkleTree[string]
+		err := decoded.UnmarshalBinary(craftTreeBinary(body))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: errore %v, atteso io.ErrUnexpectedEOF", name, err)
+		}
+	}
+}
+
+func TestBinaryRejectsOversizedBody(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(64), MerkleTreeOptions{})
+	data, err := tree.MarshalBinaryWithOptions(BinaryOptions{Compression: CompressionGzip})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := MaxBinaryBodySize
+	MaxBinaryBodySize = 1024
+	defer func() { MaxBinaryBodySize = previous }()
+
+	var decoded StandardMerkleTree[string]
+	if err := decoded.UnmarshalBinary(data); err == nil {
+		t.Fatal("sezione dati decompressa oltre il limite accettata")
+	}
+}
+
+func TestBinaryRejectsTruncated(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(4), MerkleTreeOptions{})
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := data[len(binaryMagic)+4 : len(data)-4]
+	for _, cut := range []int{1, NodeSize, len(body) / 2} {
+		var decoded StandardMerkleTree[string]
+		if err := decoded.UnmarshalBinary(craftTreeBinary(body[:len(body)-cut])); err == nil {
+			t.Errorf("file troncato di %d byte accettato", cut)
+		}
+	}
+}
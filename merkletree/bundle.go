@@ -0,0 +1,326 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// proofBundleMagic identifica i proof bundle nel formato binario
+const proofBundleMagic = "MKPB"
+
+// ProofBundleVersion è la versione corrente del formato binario dei proof bundle
+const ProofBundleVersion = 1
+
+// Nomi delle funzioni di hash dei nodi usati nei proof bundle
+const (
+	HashFunctionKeccak256Sorted = "keccak256-sorted-pairs"
+	HashFunctionRFC6962         = "rfc6962-sha256"
+)
+
+// ProofBundle è una proof autodescrittiva: contiene tutto il necessario per essere
+// verificata offline con una sola chiamata a VerifyBundle
+type ProofBundle struct {
+	Root         HexString        `json:"root"`
+	Value        HexString        `json:"value"`     // Codifica ABI packed del valore
+	ValueType    string           `json:"valueType"` // Tipo del valore, es. "string" o "uint64"
+	LeafHash     HexString        `json:"leafHash"`
+	Proof        []HexString      `json:"proof"`
+	HashFunction string           `json:"hashFunction"`
+	LeafEncoding string           `json:"leafEncoding"`
+	Tree         *ProofBundleTree `json:"tree,omitempty"`
+}
+
+// ProofBundleTree contiene i metadati opzionali dell'albero da cui proviene la proof
+type ProofBundleTree struct {
+	LeafCount  int  `json:"leafCount"`
+	TreeIndex  int  `json:"treeIndex"`
+	SortLeaves bool `json:"sortLeaves"`
+}
+
+// nomi dei tipi di valore, indicizzati per tag del formato binario. HexString non è incluso:
+// StandardLeafHash non sa codificarlo, quindi l'hash della foglia non sarebbe ricalcolabile.
+var bundleValueTypes = map[byte]string{
+	binaryValueString: "string",
+	binaryValueBytes:  "bytes",
+	binaryValueUint8:  "uint8",
+	binaryValueUint16: "uint16",
+	binaryValueUint32: "uint32",
+	binaryValueUint64: "uint64",
+	binaryValueInt8:   "int8",
+	binaryValueInt16:  "int16",
+	binaryValueInt32:  "int32",
+	binaryValueInt64:  "int64",
+}
+
+// GetProofBundle genera un proof bundle per un valore (o un indice) dell'albero
+func (m *MerkleTreeImpl[T]) GetProofBundle(leaf interface{}) (ProofBundle, error) {
+	hashFunction, err := hashFunctionName(m.NodeHash)
+	if err != nil {
+		return ProofBundle{}, err
+	}
+
+	valueIndex := m.getLeafIndex(leaf)
+	m.validateValueAt(valueIndex)
+	tag, encoded, err := encodeBinaryValue(m.Values[valueIndex].Value)
+	if err != nil {
+		return ProofBundle{}, err
+	}
+	if _, found := bundleValueTypes[tag]; !found {
+		return ProofBundle{}, fmt.Errorf("valori di tipo %T non supportati nei proof bundle", m.Values[valueIndex].Value)
+	}
+	value, _ := ToHex(encoded)
+
+	treeIndex := m.Values[valueIndex].TreeIndex
+	proof, err := m.GetProofAt(treeIndex)
+	if err != nil {
+		return ProofBundle{}, err
+	}
+
+	return ProofBundle{
+		Root:         m.Root(),
+		Value:        value,
+		ValueType:    bundleValueTypes[tag],
+		LeafHash:     m.node(treeIndex),
+		Proof:        proof,
+		HashFunction: hashFunction,
+		LeafEncoding: StandardLeafEncoding,
+		Tree: &ProofBundleTree{
+			LeafCount:  m.leafCount(),
+			TreeIndex:  treeIndex,
+			SortLeaves: m.Options.SortLeaves,
+		},
+	}, nil
+}
+
+// DecodedValue restituisce il valore del bundle con il suo tipo Go originale
+func (b ProofBundle) DecodedValue() (interface{}, error) {
+	tag, found := bundleValueTag(b.ValueType)
+	if !found {
+		return nil, fmt.Errorf("tipo di valore %q sconosciuto", b.ValueType)
+	}
+	encoded, err := ToBytes(b.Value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBinaryValue[interface{}](tag, encoded)
+}
+
+// VerifyBundle verifica un proof bundle: ricalcola l'hash della foglia dal valore e la root
+// dalla proof. Restituisce un errore se il bundle è malformato o usa descrittori sconosciuti.
+func VerifyBundle(bundle ProofBundle) (bool, error) {
+	if bundle.LeafEncoding != StandardLeafEncoding {
+		return false, fmt.Errorf("codifica delle foglie %q non supportata", bundle.LeafEncoding)
+	}
+	var nodeHash NodeHash
+	switch bundle.HashFunction {
+	case HashFunctionKeccak256Sorted:
+		nodeHash = StandardNodeHash
+	case HashFunctionRFC6962:
+		nodeHash = RFC6962NodeHash
+	default:
+		return false, fmt.Errorf("funzione di hash %q non supportata", bundle.HashFunction)
+	}
+
+	value, err := bundle.DecodedValue()
+	if err != nil {
+		return false, err
+	}
+	if !IsValidMerkleNode(bundle.LeafHash) || !IsValidMerkleNode(bundle.Root) {
+		return false, errors.New("root o hash della foglia non validi")
+	}
+	proof := make([]BytesLike, len(bundle.Proof))
+	for i, node := range bundle.Proof {
+		if !IsValidMerkleNode(node) {
+			return false, fmt.Errorf("nodo %d della proof non valido", i)
+		}
+		proof[i] = node
+	}
+
+	if StandardLeafHash(value) != bundle.LeafHash {
+		return false, nil
+	}
+
+	var computedRoot HexString
+	switch {
+	case bundle.Tree != nil:
+		if bundle.Tree.TreeIndex < 0 || len(proof) != treeDepth(bundle.Tree.TreeIndex) {
+			return false, nil
+		}
+		computedRoot = ProcessProofAt(bundle.LeafHash, bundle.Tree.TreeIndex, proof, nodeHash)
+	case bundle.HashFunction == HashFunctionKeccak256Sorted:
+		computedRoot = ProcessProof(bundle.LeafHash, proof, nodeHash)
+	default:
+		return false, errors.New("la posizione della foglia è necessaria per una funzione di hash non simmetrica")
+	}
+
+	return computedRoot == bundle.Root, nil
+}
+
+// EncodeJSON codifica il bundle in JSON
+func (b ProofBundle) EncodeJSON() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// EncodeCBOR codifica il bundle in CBOR (RFC 8949)
+func (b ProofBundle) EncodeCBOR() ([]byte, error) {
+	return cbor.Marshal(b)
+}
+
+// MarshalBinary codifica il bundle nel formato binario compatto (implementa encoding.BinaryMarshaler)
+func (b ProofBundle) MarshalBinary() ([]byte, error) {
+	tag, found := bundleValueTag(b.ValueType)
+	if !found {
+		return nil, fmt.Errorf("tipo di valore %q sconosciuto", b.ValueType)
+	}
+	root, err := nodeRecord(b.Root)
+	if err != nil {
+		return nil, err
+	}
+	leafHash, err := nodeRecord(b.LeafHash)
+	if err != nil {
+		return nil, err
+	}
+	value, err := ToBytes(b.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := newBinaryWriter(&buf)
+	writer.write([]byte(proofBundleMagic))
+	writer.write([]byte{ProofBundleVersion})
+	writer.write(root)
+	writer.write(leafHash)
+	writer.writeString(b.HashFunction)
+	writer.writeString(b.LeafEncoding)
+	writer.write([]byte{tag})
+	writer.writeUint32(uint32(len(value)))
+	writer.write(value)
+	writer.write(binary.BigEndian.AppendUint16(nil, uint16(len(b.Proof))))
+	for _, node := range b.Proof {
+		record, err := nodeRecord(node)
+		if err != nil {
+			return nil, err
+		}
+		writer.write(record)
+	}
+	if b.Tree != nil {
+		var sortLeaves byte
+		if b.Tree.SortLeaves {
+			sortLeaves = 1
+		}
+		writer.write([]byte{1, sortLeaves})
+		writer.writeUint64(uint64(b.Tree.LeafCount))
+		writer.writeUint64(uint64(b.Tree.TreeIndex))
+	} else {
+		writer.write([]byte{0})
+	}
+	if writer.err != nil {
+		return nil, writer.err
+	}
+
+	return binary.BigEndian.AppendUint32(buf.Bytes(), crc32.ChecksumIEEE(buf.Bytes())), nil
+}
+
+// UnmarshalBinary decodifica il bundle dal formato binario compatto (implementa encoding.BinaryUnmarshaler)
+func (b *ProofBundle) UnmarshalBinary(data []byte) error {
+	if len(data) < len(proofBundleMagic)+5 || string(data[:len(proofBundleMagic)]) != proofBundleMagic {
+		return errors.New("proof bundle binario non valido")
+	}
+	payload, trailer := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(trailer) {
+		return errors.New("checksum del proof bundle non valido")
+	}
+	if payload[len(proofBundleMagic)] != ProofBundleVersion {
+		return fmt.Errorf("versione del proof bundle %d non supportata", payload[len(proofBundleMagic)])
+	}
+
+	reader := newBinaryReader(payload[len(proofBundleMagic)+1:])
+	var decoded ProofBundle
+	decoded.Root, _ = ToHex(reader.read(NodeSize))
+	decoded.LeafHash, _ = ToHex(reader.read(NodeSize))
+	decoded.HashFunction = reader.readString()
+	decoded.LeafEncoding = reader.readString()
+	tag := reader.read(1)[0]
+	valueType, found := bundleValueTypes[tag]
+	if reader.err == nil && !found {
+		return fmt.Errorf("tag di tipo %d non supportato nei proof bundle", tag)
+	}
+	decoded.ValueType = valueType
+	decoded.Value, _ = ToHex(reader.read(reader.readLength()))
+	proofLength := int(binary.BigEndian.Uint16(reader.read(2)))
+	decoded.Proof = make([]HexString, 0, min(proofLength, reader.r.Len()/NodeSize))
+	for i := 0; i < proofLength && reader.err == nil; i++ {
+		node, _ := ToHex(reader.read(NodeSize))
+		decoded.Proof = append(decoded.Proof, node)
+	}
+	if reader.read(1)[0] == 1 {
+		sortLeaves := reader.read(1)[0] == 1
+		decoded.Tree = &ProofBundleTree{
+			SortLeaves: sortLeaves,
+			LeafCount:  int(reader.readUint64()),
+			TreeIndex:  int(reader.readUint64()),
+		}
+	}
+	if reader.err != nil {
+		return fmt.Errorf("proof bundle troncato: %w", reader.err)
+	}
+
+	*b = decoded
+	return nil
+}
+
+// DecodeProofBundle decodifica un bundle riconoscendo automaticamente il formato
+// (binario, JSON o CBOR)
+func DecodeProofBundle(data []byte) (ProofBundle, error) {
+	var bundle ProofBundle
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte(proofBundleMagic)):
+		err := bundle.UnmarshalBinary(data)
+		return bundle, err
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err := json.Unmarshal(trimmed, &bundle)
+		return bundle, err
+	default:
+		err := cbor.Unmarshal(data, &bundle)
+		return bundle, err
+	}
+}
+
+// hashFunctionName restituisce il nome della funzione di hash dei nodi per i proof bundle
+func hashFunctionName(nodeHash NodeHash) (string, error) {
+	switch HashAlgorithmOf(nodeHash) {
+	case HashAlgorithmKeccak256Sorted:
+		return HashFunctionKeccak256Sorted, nil
+	case HashAlgorithmRFC6962:
+		return HashFunctionRFC6962, nil
+	default:
+		return "", errors.New("impossibile descrivere una NodeHash personalizzata in un proof bundle")
+	}
+}
+
+func bundleValueTag(valueType string) (byte, bool) {
+	for tag, name := range bundleValueTypes {
+		if name == valueType {
+			return tag, true
+		}
+	}
+	return 0, false
+}
+
+// treeDepth restituisce la profondità del nodo con indice `index` nel layout a heap
+func treeDepth(index int) int {
+	depth := 0
+	for index > 0 {
+		index = ParentIndex(index)
+		depth++
+	}
+	return depth
+}
@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+func TestProofBundleRoundTrip(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(7), MerkleTreeOptions{})
+	bundle, err := tree.GetProofBundle(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := VerifyBundle(bundle); err != nil || !valid {
+		t.Fatalf("bundle non valido: %v", err)
+	}
+
+	binaryData, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := bundle.EncodeJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cborData, err := bundle.EncodeCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"binario": binaryData, "JSON": jsonData, "CBOR": cborData} {
+		decoded, err := DecodeProofBundle(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if valid, err := VerifyBundle(decoded); err != nil || !valid {
+			t.Fatalf("%s: bundle decodificato non valido: %v", name, err)
+		}
+		if value, err := decoded.DecodedValue(); err != nil || value != tree.Values[3].Value {
+			t.Fatalf("%s: valore %v, atteso %s", name, value, tree.Values[3].Value)
+		}
+	}
+}
+
+func TestProofBundleRejectsHexValues(t *testing.T) {
+	tree := NewStandardMerkleTree([]HexString{"0x01", "0x02"}, MerkleTreeOptions{})
+	if _, err := tree.GetProofBundle(0); err == nil {
+		t.Fatal("bundle con un valore HexString generato: l'hash della foglia non è ricalcolabile")
+	}
+
+	bundle := ProofBundle{ValueType: "hex"}
+	if _, err := bundle.MarshalBinary(); err == nil {
+		t.Fatal("bundle con tipo hex codificato")
+	}
+	if _, err := VerifyBundle(ProofBundle{ValueType: "hex", LeafEncoding: StandardLeafEncoding, HashFunction: HashFunctionKeccak256Sorted}); err == nil {
+		t.Fatal("bundle con tipo hex verificato")
+	}
+}
+
+func TestProofBundleRejectsOversizedValue(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(4), MerkleTreeOptions{})
+	bundle, err := tree.GetProofBundle(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La lunghezza del valore segue magic, versione, root, hash della foglia, due stringhe e il tag
+	offset := len(proofBundleMagic) + 1 + 2*NodeSize
+	offset += 2 + len(bundle.HashFunction) + 2 + len(bundle.LeafEncoding) + 1
+	payload := append([]byte{}, data[:len(data)-4]...)
+	binary.BigEndian.PutUint32(payload[offset:], 0xffffffff)
+	crafted := binary.BigEndian.AppendUint32(payload, crc32.ChecksumIEEE(payload))
+
+	var decoded ProofBundle
+	if err := decoded.UnmarshalBinary(crafted); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("errore %v, atteso io.ErrUnexpectedEOF", err)
+	}
+}
@@ -26,7 +26,7 @@ func ToBytes(value BytesLike) ([]byte, error) {
 			hexData := v[2:] // Rimuove "0x"
 			decoded, err := hex.DecodeString(hexData)
 			if err != nil {
-				fmt.Println("Errore nella decodifica esadecimale:", err)
+				Logger().Debug("decodifica esadecimale non riuscita", "err", err)
 				return nil, errors.New("stringa esadecimale non valida")
 			}
 			return decoded, nil
@@ -39,7 +39,7 @@ func ToBytes(value BytesLike) ([]byte, error) {
 		}
 		return bytes, nil
 	default:
-		fmt.Println("Errore: tipo non supportato in ToBytes")
+		Logger().Debug("tipo non supportato in ToBytes", "tipo", fmt.Sprintf("%T", value))
 		return nil, errors.New("tipo non supportato in ToBytes")
 	}
 }
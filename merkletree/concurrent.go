@@ -0,0 +1,572 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ConcurrentMaxDepth è la profondità massima supportata dal programma on-chain
+const ConcurrentMaxDepth = 30
+
+// Errori del Concurrent Merkle Tree, come ConcurrentMerkleTreeError del programma on-chain
+var (
+	ErrConcurrentTreeFull          = errors.New("l'albero è pieno")
+	ErrConcurrentIndexOutOfBounds  = errors.New("indice della foglia fuori dai limiti")
+	ErrConcurrentRootNotFound      = errors.New("root non trovata nel changelog")
+	ErrConcurrentLeafModified      = errors.New("il contenuto della foglia è stato modificato")
+	ErrConcurrentInvalidProof      = errors.New("proof non valida")
+	ErrConcurrentCannotAppendEmpty = errors.New("impossibile aggiungere una foglia vuota")
+)
+
+// concurrentErrorCodes associa i nomi delle varianti di ConcurrentMerkleTreeError,
+// usati nel campo `error` delle fixture, agli errori corrispondenti
+var concurrentErrorCodes = map[string]error{
+	"TreeFull":              ErrConcurrentTreeFull,
+	"LeafIndexOutOfBounds":  ErrConcurrentIndexOutOfBounds,
+	"RootNotFound":          ErrConcurrentRootNotFound,
+	"LeafContentsModified":  ErrConcurrentLeafModified,
+	"InvalidProof":          ErrConcurrentInvalidProof,
+	"CannotAppendEmptyNode": ErrConcurrentCannotAppendEmpty,
+}
+
+// ConcurrentChangeLog è una voce del changelog: il percorso scritto da un'operazione
+type ConcurrentChangeLog struct {
+	Root  HexString   `json:"root"`
+	Path  []HexString `json:"path"` // Path[0] è la foglia, Path[i] il nodo al livello i (root esclusa)
+	Index uint32      `json:"index"`
+}
+
+// ConcurrentPath è la proof della foglia più a destra, usata dagli append
+type ConcurrentPath struct {
+	Proof []HexString `json:"proof"`
+	Leaf  HexString   `json:"leaf"`
+	Index uint32      `json:"index"` // Indice della prossima foglia libera
+}
+
+// cmtNode è un nodo del Concurrent Merkle Tree
+type cmtNode [32]byte
+
+// cmtChangeLog è la rappresentazione interna di ConcurrentChangeLog
+type cmtChangeLog struct {
+	root  cmtNode
+	path  []cmtNode
+	index uint32
+}
+
+// ConcurrentMerkleTree replica il Concurrent Merkle Tree di SPL account-compression usato
+// dai compressed NFT su Solana: profondità fissa, keccak256 su coppie non ordinate, foglie
+// vuote a zero. Il changelog circolare permette di applicare proof calcolate su root recenti
+// (fast-forward), e la proof della foglia più a destra permette gli append senza proof.
+type ConcurrentMerkleTree struct {
+	maxDepth       int
+	maxBufferSize  int
+	sequenceNumber uint64
+	activeIndex    uint64
+	bufferSize     uint64
+	changeLogs     []cmtChangeLog
+	rightmostProof []cmtNode
+	rightmostLeaf  cmtNode
+	rightmostIndex uint32
+	emptyNodes     []cmtNode
+}
+
+// NewConcurrentMerkleTree crea un albero vuoto, come l'istruzione init_empty_merkle_tree.
+// `maxBufferSize` deve essere una potenza di due.
+func NewConcurrentMerkleTree(maxDepth int, maxBufferSize int) (*ConcurrentMerkleTree, error) {
+	if maxDepth < 1 || maxDepth > ConcurrentMaxDepth {
+		return nil, fmt.Errorf("profondità %d non valida (1-%d)", maxDepth, ConcurrentMaxDepth)
+	}
+	if maxBufferSize < 1 || maxBufferSize&(maxBufferSize-1) != 0 {
+		return nil, fmt.Errorf("dimensione del buffer %d non valida: deve essere una potenza di due", maxBufferSize)
+	}
+
+	t := &ConcurrentMerkleTree{
+		maxDepth:       maxDepth,
+		maxBufferSize:  maxBufferSize,
+		changeLogs:     make([]cmtChangeLog, maxBufferSize),
+		rightmostProof: make([]cmtNode, maxDepth),
+		emptyNodes:     make([]cmtNode, maxDepth+1),
+	}
+	for i := 1; i <= maxDepth; i++ {
+		t.emptyNodes[i] = cmtHash(t.emptyNodes[i-1], t.emptyNodes[i-1])
+	}
+	for i := range t.changeLogs {
+		t.changeLogs[i].path = make([]cmtNode, maxDepth)
+	}
+
+	// Inizializzazione: la proof più a destra e il primo changelog contengono i nodi vuoti
+	copy(t.rightmostProof, t.emptyNodes[:maxDepth])
+	copy(t.changeLogs[0].path, t.emptyNodes[:maxDepth])
+	t.changeLogs[0].root = t.emptyNodes[maxDepth]
+	t.bufferSize = 1
+	return t, nil
+}
+
+// Root restituisce la root corrente
+func (t *ConcurrentMerkleTree) Root() HexString {
+	return t.changeLogs[t.activeIndex].root.hex()
+}
+
+// MaxDepth restituisce la profondità dell'albero
+func (t *ConcurrentMerkleTree) MaxDepth() int {
+	return t.maxDepth
+}
+
+// SequenceNumber restituisce il numero di operazioni applicate
+func (t *ConcurrentMerkleTree) SequenceNumber() uint64 {
+	return t.sequenceNumber
+}
+
+// ActiveIndex restituisce la posizione del changelog più recente nel buffer circolare
+func (t *ConcurrentMerkleTree) ActiveIndex() uint64 {
+	return t.activeIndex
+}
+
+// BufferSize restituisce il numero di voci valide nel changelog
+func (t *ConcurrentMerkleTree) BufferSize() uint64 {
+	return t.bufferSize
+}
+
+// RightmostProof restituisce la proof della foglia più a destra
+func (t *ConcurrentMerkleTree) RightmostProof() ConcurrentPath {
+	return ConcurrentPath{
+		Proof: cmtHexNodes(t.rightmostProof),
+		Leaf:  t.rightmostLeaf.hex(),
+		Index: t.rightmostIndex,
+	}
+}
+
+// ChangeLogs restituisce le voci valide del changelog, dalla più vecchia alla più recente
+func (t *ConcurrentMerkleTree) ChangeLogs() []ConcurrentChangeLog {
+	mask := uint64(t.maxBufferSize - 1)
+	changeLogs := make([]ConcurrentChangeLog, 0, t.bufferSize)
+	for i := t.bufferSize; i > 0; i-- {
+		changeLog := t.changeLogs[(t.activeIndex-(i-1))&mask]
+		changeLogs = append(changeLogs, ConcurrentChangeLog{
+			Root:  changeLog.root.hex(),
+			Path:  cmtHexNodes(changeLog.path),
+			Index: changeLog.index,
+		})
+	}
+	return changeLogs
+}
+
+// Append aggiunge una foglia nella prima posizione libera usando la proof più a destra
+// e restituisce la nuova root
+func (t *ConcurrentMerkleTree) Append(leaf BytesLike) (HexString, error) {
+	node, err := toCMTNode(leaf)
+	if err != nil {
+		return "", err
+	}
+	if node == (cmtNode{}) {
+		return "", ErrConcurrentCannotAppendEmpty
+	}
+	if uint64(t.rightmostIndex) >= 1<<t.maxDepth {
+		return "", ErrConcurrentTreeFull
+	}
+
+	if t.rightmostIndex == 0 {
+		// Primo append: equivale a scrivere la foglia 0 con la proof dell'albero vuoto
+		proof := append([]cmtNode{}, t.rightmostProof...)
+		if cmtRecompute(cmtNode{}, proof, 0) != t.emptyNodes[t.maxDepth] {
+			return "", ErrConcurrentLeafModified
+		}
+		root, err := t.tryApplyProof(t.emptyNodes[t.maxDepth], cmtNode{}, node, proof, 0, false)
+		if err != nil {
+			return "", err
+		}
+		return root.hex(), nil
+	}
+
+	// Il nuovo percorso si unisce all'albero esistente al livello `intersection`
+	leafNode := node
+	previousIndex := t.rightmostIndex - 1
+	intersection := bits.TrailingZeros32(t.rightmostIndex)
+	changeList := make([]cmtNode, t.maxDepth)
+	intersectionNode := t.rightmostLeaf
+	for i := 0; i < t.maxDepth; i++ {
+		changeList[i] = node
+		switch {
+		case i < intersection:
+			// Sotto l'intersezione il nuovo nodo ha solo sibling vuoti
+			sibling := t.emptyNodes[i]
+			intersectionNode = cmtHashToParent(intersectionNode, t.rightmostProof[i], (previousIndex>>i)&1 == 0)
+			node = cmtHashToParent(node, sibling, true)
+			t.rightmostProof[i] = sibling
+		case i == intersection:
+			node = cmtHashToParent(node, intersectionNode, false)
+			t.rightmostProof[intersection] = intersectionNode
+		default:
+			node = cmtHashToParent(node, t.rightmostProof[i], (previousIndex>>i)&1 == 0)
+		}
+	}
+
+	t.incrementActiveIndex()
+	changeLog := &t.changeLogs[t.activeIndex]
+	changeLog.root = node
+	copy(changeLog.path, changeList)
+	changeLog.index = t.rightmostIndex
+	t.sequenceNumber++
+	t.rightmostIndex++
+	t.rightmostLeaf = leafNode
+	return node.hex(), nil
+}
+
+// SetLeaf sostituisce `previousLeaf` con `newLeaf` in posizione `index`, come l'istruzione
+// replace_leaf. La proof può essere calcolata su una root precedente ancora nel changelog
+// (o, se non trovata, viene aggiornata con l'intero buffer); i nodi mancanti in coda alla
+// proof (canopy) sono considerati vuoti.
+func (t *ConcurrentMerkleTree) SetLeaf(currentRoot BytesLike, previousLeaf BytesLike, newLeaf BytesLike, proof []HexString, index uint32) (HexString, error) {
+	root, previous, leaf, fullProof, err := t.parseOperation(currentRoot, previousLeaf, newLeaf, proof, index)
+	if err != nil {
+		return "", err
+	}
+	if index > t.rightmostIndex {
+		return "", ErrConcurrentIndexOutOfBounds
+	}
+	newRoot, err := t.tryApplyProof(root, previous, leaf, fullProof, index, true)
+	if err != nil {
+		return "", err
+	}
+	return newRoot.hex(), nil
+}
+
+// FillEmptyOrAppend scrive la foglia in posizione `index` se è ancora vuota, altrimenti la
+// aggiunge in coda con Append
+func (t *ConcurrentMerkleTree) FillEmptyOrAppend(currentRoot BytesLike, leaf BytesLike, proof []HexString, index uint32) (HexString, error) {
+	root, _, node, fullProof, err := t.parseOperation(currentRoot, cmtNode{}.hex(), leaf, proof, index)
+	if err != nil {
+		return "", err
+	}
+	newRoot, err := t.tryApplyProof(root, cmtNode{}, node, fullProof, index, false)
+	if errors.Is(err, ErrConcurrentLeafModified) {
+		return t.Append(leaf)
+	}
+	if err != nil {
+		return "", err
+	}
+	return newRoot.hex(), nil
+}
+
+// ProveLeaf verifica che `leaf` sia in posizione `index`, come l'istruzione verify_leaf
+func (t *ConcurrentMerkleTree) ProveLeaf(currentRoot BytesLike, leaf BytesLike, proof []HexString, index uint32) error {
+	root, node, _, fullProof, err := t.parseOperation(currentRoot, leaf, cmtNode{}.hex(), proof, index)
+	if err != nil {
+		return err
+	}
+	if index > t.rightmostIndex {
+		return ErrConcurrentIndexOutOfBounds
+	}
+	valid, err := t.checkValidLeaf(root, node, fullProof, index, true)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrConcurrentInvalidProof
+	}
+	return nil
+}
+
+// FastForwardProof aggiorna una proof calcolata su `currentRoot` con le modifiche successive
+// registrate nel changelog, restituendo la foglia e la proof valide per la root corrente
+func (t *ConcurrentMerkleTree) FastForwardProof(currentRoot BytesLike, leaf BytesLike, proof []HexString, index uint32) (HexString, []HexString, error) {
+	root, node, _, fullProof, err := t.parseOperation(currentRoot, leaf, cmtNode{}.hex(), proof, index)
+	if err != nil {
+		return "", nil, err
+	}
+	changeLogIndex, useFullBuffer := t.findRootInChangeLog(root)
+	t.fastForwardProof(&node, fullProof, index, changeLogIndex, useFullBuffer)
+	return node.hex(), cmtHexNodes(fullProof), nil
+}
+
+// parseOperation converte gli argomenti di un'operazione e completa la proof con i nodi vuoti
+func (t *ConcurrentMerkleTree) parseOperation(currentRoot BytesLike, leaf BytesLike, newLeaf BytesLike, proof []HexString, index uint32) (cmtNode, cmtNode, cmtNode, []cmtNode, error) {
+	if uint64(index) >= 1<<t.maxDepth {
+		return cmtNode{}, cmtNode{}, cmtNode{}, nil, ErrConcurrentIndexOutOfBounds
+	}
+	if len(proof) > t.maxDepth {
+		return cmtNode{}, cmtNode{}, cmtNode{}, nil, fmt.Errorf("proof di %d elementi per un albero di profondità %d", len(proof), t.maxDepth)
+	}
+	nodes := make([]cmtNode, 3)
+	for i, value := range []BytesLike{currentRoot, leaf, newLeaf} {
+		node, err := toCMTNode(value)
+		if err != nil {
+			return cmtNode{}, cmtNode{}, cmtNode{}, nil, err
+		}
+		nodes[i] = node
+	}
+	fullProof := make([]cmtNode, t.maxDepth)
+	for i := range fullProof {
+		if i >= len(proof) {
+			fullProof[i] = t.emptyNodes[i]
+			continue
+		}
+		node, err := toCMTNode(proof[i])
+		if err != nil {
+			return cmtNode{}, cmtNode{}, cmtNode{}, nil, fmt.Errorf("elemento %d della proof non valido: %w", i, err)
+		}
+		fullProof[i] = node
+	}
+	return nodes[0], nodes[1], nodes[2], fullProof, nil
+}
+
+// tryApplyProof verifica la proof (dopo il fast-forward) e scrive la nuova foglia
+func (t *ConcurrentMerkleTree) tryApplyProof(currentRoot cmtNode, leaf cmtNode, newLeaf cmtNode, proof []cmtNode, index uint32, allowInferredProof bool) (cmtNode, error) {
+	valid, err := t.checkValidLeaf(currentRoot, leaf, proof, index, allowInferredProof)
+	if err != nil {
+		return cmtNode{}, err
+	}
+	if !valid {
+		return cmtNode{}, ErrConcurrentInvalidProof
+	}
+	t.incrementActiveIndex()
+	t.sequenceNumber++
+	return t.updateBuffersFromProof(newLeaf, proof, index), nil
+}
+
+// checkValidLeaf porta la proof alla root corrente e controlla che la foglia non sia cambiata
+func (t *ConcurrentMerkleTree) checkValidLeaf(currentRoot cmtNode, leaf cmtNode, proof []cmtNode, index uint32, allowInferredProof bool) (bool, error) {
+	changeLogIndex, useFullBuffer := t.findRootInChangeLog(currentRoot)
+	if useFullBuffer && !allowInferredProof {
+		return false, ErrConcurrentRootNotFound
+	}
+	updatedLeaf := leaf
+	if !t.fastForwardProof(&updatedLeaf, proof, index, changeLogIndex, useFullBuffer) {
+		return false, ErrConcurrentLeafModified
+	}
+	if index > t.rightmostIndex {
+		return false, nil
+	}
+	return cmtRecompute(updatedLeaf, proof, index) == t.changeLogs[t.activeIndex].root, nil
+}
+
+// findRootInChangeLog cerca la root tra le voci valide, dalla più recente. Se non la trova,
+// restituisce la posizione da cui ripercorrere l'intero buffer.
+func (t *ConcurrentMerkleTree) findRootInChangeLog(root cmtNode) (uint64, bool) {
+	mask := uint64(t.maxBufferSize - 1)
+	for i := uint64(0); i < t.bufferSize; i++ {
+		j := (t.activeIndex - i) & mask
+		if t.changeLogs[j].root == root {
+			return j, false
+		}
+	}
+	return (t.activeIndex - t.bufferSize) & mask, true
+}
+
+// fastForwardProof applica alla proof le voci del changelog successive a `changeLogIndex`.
+// Restituisce false se una di esse ha modificato la foglia stessa.
+func (t *ConcurrentMerkleTree) fastForwardProof(leaf *cmtNode, proof []cmtNode, index uint32, changeLogIndex uint64, useFullBuffer bool) bool {
+	mask := uint64(t.maxBufferSize - 1)
+	updatedLeaf := *leaf
+	for {
+		if !useFullBuffer && changeLogIndex == t.activeIndex {
+			break
+		}
+		changeLogIndex = (changeLogIndex + 1) & mask
+		t.changeLogs[changeLogIndex].updateProofOrLeaf(index, proof, &updatedLeaf)
+		if useFullBuffer && changeLogIndex == t.activeIndex {
+			break
+		}
+	}
+	unchanged := updatedLeaf == *leaf
+	*leaf = updatedLeaf
+	return unchanged
+}
+
+// updateBuffersFromProof scrive il nuovo percorso nel changelog attivo e aggiorna la proof
+// della foglia più a destra
+func (t *ConcurrentMerkleTree) updateBuffersFromProof(start cmtNode, proof []cmtNode, index uint32) cmtNode {
+	changeLog := &t.changeLogs[t.activeIndex]
+	root := changeLog.replaceAndRecomputePath(index, start, proof)
+	if uint64(t.rightmostIndex) < 1<<t.maxDepth {
+		if index < t.rightmostIndex {
+			changeLog.updateProofOrLeaf(t.rightmostIndex-1, t.rightmostProof, &t.rightmostLeaf)
+		} else {
+			// Scrittura della prima posizione libera: diventa la foglia più a destra
+			copy(t.rightmostProof, proof)
+			t.rightmostIndex = index + 1
+			t.rightmostLeaf = changeLog.path[0]
+		}
+	}
+	return root
+}
+
+// incrementActiveIndex avanza nel buffer circolare del changelog
+func (t *ConcurrentMerkleTree) incrementActiveIndex() {
+	t.activeIndex = (t.activeIndex + 1) & uint64(t.maxBufferSize-1)
+	if t.bufferSize < uint64(t.maxBufferSize) {
+		t.bufferSize++
+	}
+}
+
+// replaceAndRecomputePath ricalcola il percorso dalla foglia alla root
+func (c *cmtChangeLog) replaceAndRecomputePath(index uint32, node cmtNode, proof []cmtNode) cmtNode {
+	c.index = index
+	for i, sibling := range proof {
+		c.path[i] = node
+		node = cmtHashToParent(node, sibling, (index>>i)&1 == 0)
+	}
+	c.root = node
+	return node
+}
+
+// updateProofOrLeaf aggiorna la proof di `index` con il nodo del changelog nel punto in cui
+// i due percorsi si separano, oppure la foglia se il changelog riguarda la stessa posizione
+func (c *cmtChangeLog) updateProofOrLeaf(index uint32, proof []cmtNode, leaf *cmtNode) {
+	if index != c.index {
+		critbit := bits.Len32(index^c.index) - 1
+		proof[critbit] = c.path[critbit]
+	} else {
+		*leaf = c.path[0]
+	}
+}
+
+// cmtRecompute calcola la root a partire da foglia, proof e indice
+func cmtRecompute(leaf cmtNode, proof []cmtNode, index uint32) cmtNode {
+	for i, sibling := range proof {
+		leaf = cmtHashToParent(leaf, sibling, (index>>i)&1 == 0)
+	}
+	return leaf
+}
+
+// cmtHashToParent calcola il padre; `isLeft` indica se `node` è il figlio sinistro
+func cmtHashToParent(node cmtNode, sibling cmtNode, isLeft bool) cmtNode {
+	if isLeft {
+		return cmtHash(node, sibling)
+	}
+	return cmtHash(sibling, node)
+}
+
+// cmtHash calcola keccak256(left || right)
+func cmtHash(left cmtNode, right cmtNode) cmtNode {
+	var node cmtNode
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(left[:])
+	hash.Write(right[:])
+	hash.Sum(node[:0])
+	return node
+}
+
+func (n cmtNode) hex() HexString {
+	hex, _ := ToHex(n[:])
+	return hex
+}
+
+// toCMTNode converte un valore in un nodo di 32 byte
+func toCMTNode(value BytesLike) (cmtNode, error) {
+	var node cmtNode
+	valueBytes, err := ToBytes(value)
+	if err != nil {
+		return node, err
+	}
+	if len(valueBytes) != len(node) {
+		return node, fmt.Errorf("nodo di %d byte, attesi %d", len(valueBytes), len(node))
+	}
+	copy(node[:], valueBytes)
+	return node, nil
+}
+
+func cmtHexNodes(nodes []cmtNode) []HexString {
+	hexNodes := make([]HexString, len(nodes))
+	for i, node := range nodes {
+		hexNodes[i] = node.hex()
+	}
+	return hexNodes
+}
+
+// ConcurrentOperation è un'operazione registrata sul programma on-chain
+type ConcurrentOperation struct {
+	Type         string      `json:"type"`                   // "append", "replace" (o "set_leaf"), "fill_empty_or_append", "verify_leaf"
+	Root         HexString   `json:"root,omitempty"`         // Root su cui è stata calcolata la proof
+	Leaf         HexString   `json:"leaf,omitempty"`         // Foglia da aggiungere, verificare o sostituire
+	NewLeaf      HexString   `json:"newLeaf,omitempty"`      // Nuova foglia di replace
+	Proof        []HexString `json:"proof,omitempty"`        // Proof senza canopy
+	Index        uint32      `json:"index,omitempty"`        // Indice della foglia
+	ExpectedRoot HexString   `json:"expectedRoot,omitempty"` // Root on-chain dopo l'operazione
+	Seq          uint64      `json:"seq,omitempty"`          // Numero di sequenza del ChangeLogEvent
+	Failed       bool        `json:"failed,omitempty"`       // La transazione è fallita on-chain
+	Error        string      `json:"error,omitempty"`        // Variante di ConcurrentMerkleTreeError attesa (implica Failed)
+}
+
+// ConcurrentFixture è una sequenza di operazioni registrate, con la configurazione dell'albero
+type ConcurrentFixture struct {
+	MaxDepth      int                   `json:"maxDepth"`
+	MaxBufferSize int                   `json:"maxBufferSize"`
+	Operations    []ConcurrentOperation `json:"operations"`
+}
+
+// Apply esegue un'operazione registrata e restituisce la nuova root
+func (t *ConcurrentMerkleTree) Apply(operation ConcurrentOperation) (HexString, error) {
+	switch operation.Type {
+	case "append":
+		return t.Append(operation.Leaf)
+	case "replace", "set_leaf":
+		return t.SetLeaf(operation.Root, operation.Leaf, operation.NewLeaf, operation.Proof, operation.Index)
+	case "fill_empty_or_append":
+		return t.FillEmptyOrAppend(operation.Root, operation.Leaf, operation.Proof, operation.Index)
+	case "verify_leaf":
+		if err := t.ProveLeaf(operation.Root, operation.Leaf, operation.Proof, operation.Index); err != nil {
+			return "", err
+		}
+		return t.Root(), nil
+	default:
+		return "", fmt.Errorf("operazione %q non supportata", operation.Type)
+	}
+}
+
+// ReplayConcurrentFixture riesegue le operazioni della fixture e controlla, dopo ognuna,
+// la root e il numero di sequenza registrati (se presenti) e l'esito della transazione.
+// Per le operazioni fallite con un campo `error` controlla anche che l'errore sia quello atteso.
+func ReplayConcurrentFixture(fixture ConcurrentFixture) (*ConcurrentMerkleTree, error) {
+	tree, err := NewConcurrentMerkleTree(fixture.MaxDepth, fixture.MaxBufferSize)
+	if err != nil {
+		return nil, err
+	}
+	for i, operation := range fixture.Operations {
+		var expectedErr error
+		if operation.Error != "" {
+			var found bool
+			if expectedErr, found = concurrentErrorCodes[operation.Error]; !found {
+				return tree, fmt.Errorf("operazione %d (%s): errore %q sconosciuto", i, operation.Type, operation.Error)
+			}
+		}
+		root, err := tree.Apply(operation)
+		if operation.Failed || expectedErr != nil {
+			if err == nil {
+				return tree, fmt.Errorf("operazione %d (%s): fallita on-chain ma applicata", i, operation.Type)
+			}
+			if expectedErr != nil && !errors.Is(err, expectedErr) {
+				return tree, fmt.Errorf("operazione %d (%s): errore %v, atteso %s", i, operation.Type, err, operation.Error)
+			}
+			continue
+		}
+		if err != nil {
+			return tree, fmt.Errorf("operazione %d (%s): %w", i, operation.Type, err)
+		}
+		if expected, _ := ToHex(operation.ExpectedRoot); operation.ExpectedRoot != "" && root != expected {
+			return tree, fmt.Errorf("operazione %d (%s): root %s, attesa %s", i, operation.Type, root, operation.ExpectedRoot)
+		}
+		if operation.Seq != 0 && tree.SequenceNumber() != operation.Seq {
+			return tree, fmt.Errorf("operazione %d (%s): sequenza %d, attesa %d", i, operation.Type, tree.SequenceNumber(), operation.Seq)
+		}
+	}
+	return tree, nil
+}
+
+// LoadConcurrentFixture legge una fixture JSON
+func LoadConcurrentFixture(path string) (ConcurrentFixture, error) {
+	var fixture ConcurrentFixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, err
+	}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return fixture, fmt.Errorf("fixture non valida %s: %w", path, err)
+	}
+	return fixture, nil
+}
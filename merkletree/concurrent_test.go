@@ -0,0 +1,173 @@
+package merkletree
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// concurrentFixtureFiles restituisce le fixture generate da testdata/concurrent/generate.py
+func concurrentFixtureFiles(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("testdata/concurrent/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("nessuna fixture in testdata/concurrent (errore %v)", err)
+	}
+	return files
+}
+
+func TestReplayConcurrentFixtures(t *testing.T) {
+	for _, file := range concurrentFixtureFiles(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			fixture, err := LoadConcurrentFixture(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree, err := ReplayConcurrentFixture(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var last ConcurrentOperation
+			for _, operation := range fixture.Operations {
+				if !operation.Failed && operation.ExpectedRoot != "" {
+					last = operation
+				}
+			}
+			if tree.Root() != last.ExpectedRoot || tree.SequenceNumber() != last.Seq {
+				t.Fatalf("stato finale %s (sequenza %d), atteso %s (sequenza %d)", tree.Root(), tree.SequenceNumber(), last.ExpectedRoot, last.Seq)
+			}
+			changeLogs := tree.ChangeLogs()
+			if len(changeLogs) != fixture.MaxBufferSize || changeLogs[len(changeLogs)-1].Root != tree.Root() {
+				t.Fatalf("%d voci nel changelog, attese %d con l'ultima sulla root corrente", len(changeLogs), fixture.MaxBufferSize)
+			}
+		})
+	}
+}
+
+func TestConcurrentFixtureFailures(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, file := range concurrentFixtureFiles(t) {
+		fixture, err := LoadConcurrentFixture(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := NewConcurrentMerkleTree(fixture.MaxDepth, fixture.MaxBufferSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, operation := range fixture.Operations {
+			before := tree.Root()
+			_, err := tree.Apply(operation)
+			if !operation.Failed {
+				if err != nil {
+					t.Fatalf("%s, operazione %d (%s): %v", file, i, operation.Type, err)
+				}
+				continue
+			}
+			expected, found := concurrentErrorCodes[operation.Error]
+			if !found {
+				t.Fatalf("%s, operazione %d: errore atteso %q sconosciuto", file, i, operation.Error)
+			}
+			if !errors.Is(err, expected) {
+				t.Fatalf("%s, operazione %d (%s): errore %v, atteso %s", file, i, operation.Type, err, operation.Error)
+			}
+			if tree.Root() != before {
+				t.Fatalf("%s, operazione %d (%s): l'operazione fallita ha modificato l'albero", file, i, operation.Type)
+			}
+			seen[operation.Error] = true
+		}
+	}
+	for code := range concurrentErrorCodes {
+		if !seen[code] {
+			t.Errorf("nessuna operazione fallita con errore %s", code)
+		}
+	}
+}
+
+func TestReplayConcurrentFixtureDetectsMismatch(t *testing.T) {
+	fixture, err := LoadConcurrentFixture("testdata/concurrent/replace.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La replace con buffer pieno è la prima operazione riuscita su una root uscita dal buffer
+	index := -1
+	for i, operation := range fixture.Operations {
+		if operation.Type == "replace" && !operation.Failed && operation.Index == 0 {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		t.Fatal("replace della foglia 0 non trovata nella fixture")
+	}
+
+	wrongRoot := fixture
+	wrongRoot.Operations = append([]ConcurrentOperation{}, fixture.Operations...)
+	wrongRoot.Operations[index].ExpectedRoot = fixture.Operations[index-1].ExpectedRoot
+	if _, err := ReplayConcurrentFixture(wrongRoot); err == nil {
+		t.Fatal("root diversa da quella registrata accettata")
+	}
+
+	// Un'operazione fallita con un errore diverso da quello registrato
+	failed := -1
+	for i, operation := range fixture.Operations {
+		if operation.Error == "LeafContentsModified" {
+			failed = i
+		}
+	}
+	if failed < 0 {
+		t.Fatal("operazione fallita con LeafContentsModified non trovata nella fixture")
+	}
+	for _, code := range []string{"InvalidProof", "ErroreInesistente"} {
+		wrongError := fixture
+		wrongError.Operations = append([]ConcurrentOperation{}, fixture.Operations...)
+		wrongError.Operations[failed].Error = code
+		if _, err := ReplayConcurrentFixture(wrongError); err == nil {
+			t.Fatalf("errore %s al posto di LeafContentsModified accettato", code)
+		}
+	}
+
+	wrongOutcome := fixture
+	wrongOutcome.Operations = append([]ConcurrentOperation{}, fixture.Operations...)
+	wrongOutcome.Operations[index].Failed = true
+	if _, err := ReplayConcurrentFixture(wrongOutcome); err == nil {
+		t.Fatal("operazione riuscita registrata come fallita accettata")
+	}
+}
+
+func TestConcurrentFastForwardProof(t *testing.T) {
+	tree, err := NewConcurrentMerkleTree(3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := make([]HexString, 4)
+	for i := range leaves {
+		leaves[i] = keccak256Hex([]byte{byte(i)})
+		if _, err := tree.Append(leaves[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// La proof della foglia 0 calcolata ora diventa obsoleta dopo la modifica della foglia 1
+	staleRoot := tree.Root()
+	// Il livello più alto manca: il sottoalbero delle foglie 4-7 è vuoto
+	staleProof := []HexString{leaves[1], OrderedNodeHash(leaves[2], leaves[3])}
+	if err := tree.ProveLeaf(staleRoot, leaves[0], staleProof, 0); err != nil {
+		t.Fatal(err)
+	}
+	updated := keccak256Hex([]byte("nuova"))
+	if _, err := tree.SetLeaf(staleRoot, leaves[1], updated, []HexString{leaves[0], staleProof[1]}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, proof, err := tree.FastForwardProof(staleRoot, leaves[0], staleProof, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf != leaves[0] || proof[0] != updated || proof[1] != staleProof[1] {
+		t.Fatalf("proof aggiornata %v (foglia %s)", proof, leaf)
+	}
+	if err := tree.ProveLeaf(tree.Root(), leaf, proof, 0); err != nil {
+		t.Fatal(err)
+	}
+}
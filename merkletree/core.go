@@ -1,9 +1,10 @@
 package merkletree
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
-	"sort"
 )
 
 type MultiProof struct {
@@ -37,7 +38,7 @@ func CheckLeafNode(tree []BytesLike, i int) {
 func IsValidMerkleNode(node BytesLike) bool {
 	bytes, err := ToBytes(node)
 	if err != nil {
-		fmt.Errorf("Not valide Merkle Node: ", err)
+		Logger().Warn("nodo di Merkle non valido", "err", err)
 	}
 	return len(bytes) == 32
 }
@@ -50,15 +51,26 @@ func CheckValidMerkleNode(node BytesLike) {
 
 // MakeMerkleTree costruisce un albero di Merkle a partire da una lista di hash delle foglie
 func MakeMerkleTree(hashes []BytesLike, nodeHash NodeHash) []HexString {
+	tree, err := MakeMerkleTreeContext(context.Background(), hashes, nodeHash, nil)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	return tree
+}
+
+// MakeMerkleTreeContext è come MakeMerkleTree, ma restituisce un errore se la lista è vuota o se
+// una foglia non è esadecimale, si interrompe quando il contesto viene annullato e segnala
+// l'avanzamento del calcolo dei nodi interni a `progress` (se non nil)
+func MakeMerkleTreeContext(ctx context.Context, hashes []BytesLike, nodeHash NodeHash, progress ProgressFunc) ([]HexString, error) {
 	if len(hashes) == 0 {
-		panic("Errore: impossibile costruire un albero di Merkle con 0 elementi")
+		return nil, errors.New("impossibile costruire un albero di Merkle con 0 elementi")
 	}
 	// Converte tutti gli hash in BytesLike
 	leaves := make([]HexString, len(hashes))
 	for i, h := range hashes {
 		leaf, err := ToHex(h)
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			return nil, fmt.Errorf("foglia %d non valida: %w", i, err)
 		}
 		leaves[i] = leaf
 	}
@@ -67,13 +79,19 @@ func MakeMerkleTree(hashes []BytesLike, nodeHash NodeHash) []HexString {
 	copy(tree[len(tree)-len(leaves):], leaves)
 
 	// Generazione dei nodi interni
-	for i := len(tree) - len(leaves) - 1; i >= 0; i-- {
+	internalNodes := len(tree) - len(leaves)
+	reporter := newProgressReporter(ctx, progress, PhaseNodeHashing, internalNodes)
+	for i := internalNodes - 1; i >= 0; i-- {
+		if err := reporter.step(internalNodes - 1 - i); err != nil {
+			return nil, err
+		}
 		leftChild := tree[LeftChildIndex(i)]
 		rightChild := tree[RightChildIndex(i)]
 		tree[i] = nodeHash(leftChild, rightChild)
 	}
+	reporter.done()
 
-	return tree
+	return tree, nil
 }
 
 // GetProof restituisce la proof di Merkle per un nodo specifico
@@ -84,7 +102,7 @@ func GetProof(tree []BytesLike, index int) []HexString {
 		siblingIdx := SiblingIndex(index)
 		value, err := ToHex(tree[siblingIdx])
 		if err != nil {
-			fmt.Errorf("Errore in GetProof: ", err)
+			Logger().Warn("conversione del nodo fratello non riuscita in GetProof", "err", err)
 		}
 		proof = append(proof, value)
 		index = ParentIndex(index)
@@ -105,22 +123,48 @@ func ProcessProof(leaf BytesLike, proof []BytesLike, nodeHash NodeHash) HexStrin
 	// Applica la funzione di hash riducendo la proof a un singolo valore
 	result, err := ToHex(leaf)
 	if err != nil {
-		fmt.Errorf("Error in ProcessProof: ", err)
+		Logger().Warn("conversione del nodo non riuscita in ProcessProof", "err", err)
 	}
 	for _, sibling := range proof {
 		sibling, err := ToHex(sibling)
 		if err != nil {
-			fmt.Errorf("Error in ProcessProof: ", err)
+			Logger().Warn("conversione del nodo non riuscita in ProcessProof", "err", err)
 		}
 		result = nodeHash(result, sibling)
 	}
 	resultHex, err := ToHex(result)
 	if err != nil {
-		fmt.Printf("Error in ProcessProof: ", err)
+		Logger().Warn("conversione del nodo non riuscita in ProcessProof", "err", err)
 	}
 	return resultHex
 }
 
+// ProcessProofAt calcola la root a partire dalla foglia con indice `index` nell'albero,
+// rispettando l'ordine sinistro/destro dei nodi: necessaria con funzioni di hash non simmetriche
+func ProcessProofAt(leaf BytesLike, index int, proof []BytesLike, nodeHash NodeHash) HexString {
+	CheckValidMerkleNode(leaf)
+	for _, node := range proof {
+		CheckValidMerkleNode(node)
+	}
+
+	result, err := ToHex(leaf)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE in ProcessProofAt: %v", err))
+	}
+	for _, sibling := range proof {
+		if index <= 0 {
+			panic("❌ ERRORE: proof più lunga del percorso verso la root")
+		}
+		if index%2 == 1 {
+			result = nodeHash(result, sibling)
+		} else {
+			result = nodeHash(sibling, result)
+		}
+		index = ParentIndex(index)
+	}
+	return result
+}
+
 // GetMultiProof genera una proof multipla per un insieme di foglie
 func GetMultiProof(tree []BytesLike, indices []int) MultiProof {
 	if len(indices) == 0 {
@@ -145,7 +189,7 @@ func GetMultiProof(tree []BytesLike, indices []int) MultiProof {
 			proofFlags = append(proofFlags, false)
 			proofVal, err := ToHex(tree[s])
 			if err != nil {
-				fmt.Errorf("Error: ", err)
+				Logger().Warn("conversione del nodo non riuscita", "err", err)
 			}
 			proof = append(proof, proofVal)
 		}
@@ -157,7 +201,7 @@ func GetMultiProof(tree []BytesLike, indices []int) MultiProof {
 	for i, idx := range indices {
 		index, err := ToHex(idx)
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			Logger().Warn("conversione dell'indice della foglia non riuscita", "err", err)
 		}
 		leavesHex[i] = index // Converte l'indice in formato esadecimale
 	}
@@ -190,11 +234,11 @@ func ProcessMultiProof(multiproof MultiProof, nodeHash NodeHash) HexString {
 		}
 		leafA, err := ToHex(a)
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			Logger().Warn("conversione del nodo non riuscita", "err", err)
 		}
 		leafB, err := ToHex(b)
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			Logger().Warn("conversione del nodo non riuscita", "err", err)
 		}
 		stack = append(stack, nodeHash(leafA, leafB))
 	}
@@ -206,6 +250,66 @@ func ProcessMultiProof(multiproof MultiProof, nodeHash NodeHash) HexString {
 	return stack[0]
 }
 
+// ProcessMultiProofAt è come ProcessMultiProof, ma conosce la posizione delle foglie: `indices`
+// sono i TreeIndex di multiproof.Leaves, in ordine decrescente come in GetMultiProof. Ogni coppia
+// viene combinata rispettando sinistra e destra, quindi è corretta anche con funzioni di hash
+// non simmetriche.
+func ProcessMultiProofAt(multiproof MultiProof, indices []int, nodeHash NodeHash) (HexString, error) {
+	if len(indices) == 0 || len(indices) != len(multiproof.Leaves) {
+		return "", fmt.Errorf("%d foglie per %d indici", len(multiproof.Leaves), len(indices))
+	}
+	type indexedNode struct {
+		index int
+		hash  HexString
+	}
+	stack := make([]indexedNode, len(indices))
+	for i, index := range indices {
+		if i > 0 && index >= indices[i-1] {
+			return "", errors.New("gli indici delle foglie devono essere in ordine decrescente")
+		}
+		leaf, err := ToHex(multiproof.Leaves[i])
+		if err != nil || !IsValidMerkleNode(leaf) {
+			return "", fmt.Errorf("foglia %d non valida", i)
+		}
+		stack[i] = indexedNode{index: index, hash: leaf}
+	}
+	proof := multiproof.Proof
+
+	for _, flag := range multiproof.ProofFlags {
+		if len(stack) < 1 || stack[0].index <= 0 || (!flag && len(proof) < 1) {
+			return "", errors.New("multiproof non valida")
+		}
+		node := stack[0]
+		stack = stack[1:]
+		var sibling HexString
+		if flag {
+			if len(stack) < 1 || stack[0].index != SiblingIndex(node.index) {
+				return "", errors.New("multiproof non valida: il nodo da combinare non è il fratello")
+			}
+			sibling = stack[0].hash
+			stack = stack[1:]
+		} else {
+			if !IsValidMerkleNode(proof[0]) {
+				return "", errors.New("nodo della proof non valido")
+			}
+			sibling = proof[0]
+			proof = proof[1:]
+		}
+		if node.index%2 == 1 {
+			node.hash = nodeHash(node.hash, sibling)
+		} else {
+			node.hash = nodeHash(sibling, node.hash)
+		}
+		node.index = ParentIndex(node.index)
+		stack = append(stack, node)
+	}
+
+	if len(stack) != 1 || len(proof) != 0 || stack[0].index != 0 {
+		return "", errors.New("multiproof non valida")
+	}
+	return stack[0].hash, nil
+}
+
 // Funzioni di supporto per gli indici degli alberi di Merkle
 // ParentIndex restituisce l'indice del nodo genitore per un nodo dato
 func ParentIndex(i int) int {
@@ -238,14 +342,34 @@ func PrepareMerkleTree[T any](values []T, options MerkleTreeOptions, leafHash fu
 	Value     T
 	TreeIndex int
 }) {
+	tree, indexedValues, err := PrepareMerkleTreeContext(context.Background(), values, options, leafHash, nodeHash, nil)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	return tree, indexedValues
+}
+
+// PrepareMerkleTreeContext è come PrepareMerkleTree, ma si interrompe restituendo ctx.Err()
+// quando il contesto viene annullato e segnala l'avanzamento di ogni fase a `progress` (se non nil)
+func PrepareMerkleTreeContext[T any](ctx context.Context, values []T, options MerkleTreeOptions, leafHash func(T) HexString, nodeHash NodeHash, progress ProgressFunc) ([]HexString, []struct {
+	Value     T
+	TreeIndex int
+}, error) {
 
 	// Se `nodeHash` è nil, assegniamo la funzione standard
 	if nodeHash == nil {
 		nodeHash = StandardNodeHash
 	}
 
-	// Assicuriamoci che `leafHash` sia sempre `StandardLeafHash`
-	leafHash = StandardLeafHash[T]
+	// Se `leafHash` è nil, assegniamo la funzione standard
+	if leafHash == nil {
+		leafHash = StandardLeafHash[T]
+	}
+
+	// Un albero a profondità fissa può partire vuoto e ricevere i valori con AppendLeaves
+	if len(values) == 0 && options.FixedDepth <= 0 {
+		return nil, nil, errors.New("impossibile costruire un albero di Merkle con 0 elementi")
+	}
 
 	// Creiamo una struttura per memorizzare i valori hashati
 	hashedValues := make([]struct {
@@ -255,7 +379,11 @@ func PrepareMerkleTree[T any](values []T, options MerkleTreeOptions, leafHash fu
 	}, len(values))
 
 	// Applica la funzione di hash alle foglie
+	reporter := newProgressReporter(ctx, progress, PhaseLeafHashing, len(values))
 	for i, value := range values {
+		if err := reporter.step(i); err != nil {
+			return nil, nil, err
+		}
 		hashedValues[i] = struct {
 			Value      T
 			ValueIndex int
@@ -265,39 +393,52 @@ func PrepareMerkleTree[T any](values []T, options MerkleTreeOptions, leafHash fu
 			ValueIndex: i,
 			Hash:       leafHash(value),
 		}
-
 	}
+	reporter.done()
 
 	// Se l'opzione `sortLeaves` è attiva, ordiniamo le foglie
 	if options.SortLeaves {
-		sort.Slice(hashedValues, func(i, j int) bool {
-			result, err := Compare(hashedValues[i].Hash, hashedValues[j].Hash)
-			if err != nil {
-				fmt.Errorf("Error: ", err)
-			}
-			return result < 0
+		err := sortContext(ctx, progress, hashedValues, func(a, b struct {
+			Value      T
+			ValueIndex int
+			Hash       HexString
+		}) int {
+			return compareHex(a.Hash, b.Hash)
 		})
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Costruiamo l'albero di Merkle
-	tree := MakeMerkleTree(
-		func() []BytesLike {
-			hashes := make([]BytesLike, len(hashedValues))
-			for i, v := range hashedValues {
-				hashes[i] = v.Hash
-			}
-			return hashes
-		}(),
-		nodeHash,
-	)
+	hashes := make([]BytesLike, len(hashedValues))
+	for i, v := range hashedValues {
+		hashes[i] = v.Hash
+	}
+	var tree []HexString
+	var err error
+	if options.FixedDepth > 0 {
+		tree, err = makeFixedDepthMerkleTreeContext(ctx, hashes, options.FixedDepth, options.zeroLeaf(), nodeHash, progress)
+	} else {
+		tree, err = MakeMerkleTreeContext(ctx, hashes, nodeHash, progress)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Assegniamo gli indici corretti alle foglie
 	indexedValues := make([]struct {
 		Value     T
 		TreeIndex int
 	}, len(values))
 
+	reporter = newProgressReporter(ctx, progress, PhaseIndexing, len(hashedValues))
 	for leafIndex, hv := range hashedValues {
-		correctedIndex := len(tree) - len(hashedValues) + leafIndex
+		if err := reporter.step(leafIndex); err != nil {
+			return nil, nil, err
+		}
+		// Le foglie iniziano a metà dell'array, anche con le posizioni libere di FixedDepth
+		correctedIndex := len(tree)/2 + leafIndex
 		indexedValues[hv.ValueIndex] = struct {
 			Value     T
 			TreeIndex int
@@ -306,14 +447,15 @@ func PrepareMerkleTree[T any](values []T, options MerkleTreeOptions, leafHash fu
 			TreeIndex: correctedIndex,
 		}
 	}
+	reporter.done()
 
 	// Verifica che gli indici siano validi
 	for _, v := range indexedValues {
 		if v.TreeIndex < 0 || v.TreeIndex >= len(tree) {
-			fmt.Printf("❌ ERRORE: TreeIndex %d è fuori dai limiti! (Max: %d)\n", v.TreeIndex, len(tree)-1)
+			options.logger().Error("TreeIndex fuori dai limiti", "treeIndex", v.TreeIndex, "max", len(tree)-1)
 			panic("TreeIndex fuori dai limiti!")
 		}
 	}
 
-	return tree, indexedValues
+	return tree, indexedValues, nil
 }
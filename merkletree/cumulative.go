@@ -0,0 +1,206 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// cumulativeLeafSize è la dimensione di una foglia cumulativa: address (20 byte) + uint256 (32 byte)
+const cumulativeLeafSize = common.AddressLength + 32
+
+// ErrCumulativeDecrease indica che l'importo cumulativo di almeno un account diminuirebbe
+var ErrCumulativeDecrease = errors.New("l'importo cumulativo di uno o più account diminuisce")
+
+// RewardDelta è la variazione dell'importo cumulativo di un account in un'epoca
+type RewardDelta struct {
+	Account string
+	Amount  *big.Int // Negativo solo se si vuole ridurre l'importo (richiede AllowDecrease)
+}
+
+// CumulativeOptions definisce le opzioni di BuildCumulativeEpoch
+type CumulativeOptions struct {
+	AllowDecrease bool // Consente di ridurre l'importo cumulativo di un account
+}
+
+// AccountChange descrive la variazione di un account tra due epoche
+type AccountChange struct {
+	Account  common.Address `json:"account"`
+	Previous *big.Int       `json:"previous"`
+	Current  *big.Int       `json:"current"`
+	Delta    *big.Int       `json:"delta"`
+	New      bool           `json:"new"` // L'account non era presente nell'epoca precedente
+}
+
+// CumulativeReport riassume il passaggio da un'epoca alla successiva
+type CumulativeReport struct {
+	PreviousRoot  HexString       `json:"previousRoot,omitempty"`
+	Root          HexString       `json:"root,omitempty"`
+	Accounts      int             `json:"accounts"`
+	PreviousTotal *big.Int        `json:"previousTotal"`
+	Total         *big.Int        `json:"total"`
+	DeltaTotal    *big.Int        `json:"deltaTotal"` // Importo da pagare in questa epoca
+	Changes       []AccountChange `json:"changes"`    // Solo gli account con delta diverso da zero
+	Decreases     []AccountChange `json:"decreases,omitempty"`
+}
+
+// CumulativeLeaf codifica una foglia come abi.encodePacked(address account, uint256 cumulativeAmount)
+func CumulativeLeaf(account common.Address, cumulative *big.Int) []byte {
+	leaf := make([]byte, 0, cumulativeLeafSize)
+	leaf = append(leaf, account.Bytes()...)
+	return append(leaf, math.U256Bytes(new(big.Int).Set(cumulative))...)
+}
+
+// DecodeCumulativeLeaf decodifica una foglia scritta da CumulativeLeaf
+func DecodeCumulativeLeaf(leaf []byte) (common.Address, *big.Int, error) {
+	if len(leaf) != cumulativeLeafSize {
+		return common.Address{}, nil, fmt.Errorf("foglia cumulativa di %d byte, attesi %d", len(leaf), cumulativeLeafSize)
+	}
+	return common.BytesToAddress(leaf[:common.AddressLength]), new(big.Int).SetBytes(leaf[common.AddressLength:]), nil
+}
+
+// BuildCumulativeEpoch costruisce l'albero della nuova epoca a partire da quello precedente
+// (nil per la prima epoca) e dalle variazioni. Gli account non presenti nelle variazioni
+// mantengono il loro importo. Se un importo diminuisce e AllowDecrease non è attivo,
+// restituisce ErrCumulativeDecrease insieme al report con le diminuzioni, senza albero.
+func BuildCumulativeEpoch(previous *StandardMerkleTree[[]byte], deltas []RewardDelta, options CumulativeOptions) (*StandardMerkleTree[[]byte], CumulativeReport, error) {
+	report := CumulativeReport{
+		PreviousTotal: new(big.Int),
+		Total:         new(big.Int),
+		DeltaTotal:    new(big.Int),
+	}
+
+	balances := make(map[common.Address]*big.Int)
+	previousBalances := make(map[common.Address]*big.Int)
+	if previous != nil {
+		if !IsValidMerkleTree(previous.Tree, StandardNodeHash) {
+			return nil, report, errors.New("l'albero dell'epoca precedente non è valido")
+		}
+		report.PreviousRoot = previous.Root()
+		for i, v := range previous.Values {
+			if StandardLeafHash(v.Value) != previous.Tree[v.TreeIndex] {
+				return nil, report, fmt.Errorf("il valore %d dell'epoca precedente non corrisponde alla sua foglia", i)
+			}
+			account, amount, err := DecodeCumulativeLeaf(v.Value)
+			if err != nil {
+				return nil, report, fmt.Errorf("valore %d dell'epoca precedente: %w", i, err)
+			}
+			if _, found := previousBalances[account]; found {
+				return nil, report, fmt.Errorf("account %s duplicato nell'epoca precedente", account.Hex())
+			}
+			previousBalances[account] = amount
+			balances[account] = new(big.Int).Set(amount)
+			report.PreviousTotal.Add(report.PreviousTotal, amount)
+		}
+	}
+
+	seenDeltas := make(map[common.Address]bool, len(deltas))
+	for i, delta := range deltas {
+		if !common.IsHexAddress(delta.Account) {
+			return nil, report, fmt.Errorf("variazione %d: indirizzo non valido %q", i, delta.Account)
+		}
+		if delta.Amount == nil {
+			return nil, report, fmt.Errorf("variazione %d: importo mancante", i)
+		}
+		account := common.HexToAddress(delta.Account)
+		if seenDeltas[account] {
+			return nil, report, fmt.Errorf("variazione %d: account %s duplicato", i, account.Hex())
+		}
+		seenDeltas[account] = true
+
+		balance, found := balances[account]
+		if !found {
+			balance = new(big.Int)
+			balances[account] = balance
+		}
+		balance.Add(balance, delta.Amount)
+		if balance.Sign() < 0 {
+			return nil, report, fmt.Errorf("l'importo cumulativo di %s diventerebbe negativo (%s)", account.Hex(), balance)
+		}
+		if balance.BitLen() > 256 {
+			return nil, report, fmt.Errorf("l'importo cumulativo di %s supera uint256", account.Hex())
+		}
+	}
+
+	accounts := make([]common.Address, 0, len(balances))
+	for account, balance := range balances {
+		// Un nuovo account con importo zero non entra nell'albero
+		if _, found := previousBalances[account]; !found && balance.Sign() == 0 {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i].Bytes(), accounts[j].Bytes()) < 0 })
+	if len(accounts) == 0 {
+		return nil, report, errors.New("nessun account nella nuova epoca")
+	}
+
+	leaves := make([][]byte, len(accounts))
+	for i, account := range accounts {
+		current := balances[account]
+		previousAmount, found := previousBalances[account]
+		if !found {
+			previousAmount = new(big.Int)
+		}
+		leaves[i] = CumulativeLeaf(account, current)
+		report.Total.Add(report.Total, current)
+
+		delta := new(big.Int).Sub(current, previousAmount)
+		if delta.Sign() == 0 {
+			continue
+		}
+		change := AccountChange{Account: account, Previous: previousAmount, Current: current, Delta: delta, New: !found}
+		report.Changes = append(report.Changes, change)
+		if delta.Sign() < 0 {
+			report.Decreases = append(report.Decreases, change)
+		}
+	}
+	report.Accounts = len(accounts)
+	report.DeltaTotal.Sub(report.Total, report.PreviousTotal)
+
+	if len(report.Decreases) > 0 && !options.AllowDecrease {
+		return nil, report, fmt.Errorf("%w: %d account", ErrCumulativeDecrease, len(report.Decreases))
+	}
+
+	tree := NewStandardMerkleTree(leaves, MerkleTreeOptions{})
+	report.Root = tree.Root()
+	return tree, report, nil
+}
+
+// ParseRewardDeltasCSV legge righe "account,delta" (base 10, anche negativo); una prima riga
+// di intestazione che inizia con "account" viene ignorata
+func ParseRewardDeltasCSV(r io.Reader) ([]RewardDelta, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var deltas []RewardDelta
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return deltas, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "account") {
+			continue
+		}
+		if len(record) != 2 {
+			return nil, fmt.Errorf("riga %d: attese 2 colonne, trovate %d", line, len(record))
+		}
+		amount, ok := new(big.Int).SetString(strings.TrimSpace(record[1]), 10)
+		if !ok {
+			return nil, fmt.Errorf("riga %d: importo non valido %q", line, record[1])
+		}
+		deltas = append(deltas, RewardDelta{Account: strings.TrimSpace(record[0]), Amount: amount})
+	}
+}
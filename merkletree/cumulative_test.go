@@ -0,0 +1,143 @@
+package merkletree
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	testAccountA = "0x00000000000000000000000000000000000000a1"
+	testAccountB = "0x00000000000000000000000000000000000000b2"
+	testAccountC = "0x00000000000000000000000000000000000000c3"
+)
+
+// cumulativeBalances legge gli importi cumulativi dalle foglie dell'albero
+func cumulativeBalances(t *testing.T, tree *StandardMerkleTree[[]byte]) map[common.Address]int64 {
+	t.Helper()
+	balances := make(map[common.Address]int64)
+	for _, v := range tree.Values {
+		account, amount, err := DecodeCumulativeLeaf(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		balances[account] = amount.Int64()
+	}
+	return balances
+}
+
+func TestCumulativeLeafRoundTrip(t *testing.T) {
+	account := common.HexToAddress(testAccountA)
+	amount, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	leaf := CumulativeLeaf(account, amount)
+	if len(leaf) != common.AddressLength+32 {
+		t.Fatalf("foglia di %d byte", len(leaf))
+	}
+	decodedAccount, decodedAmount, err := DecodeCumulativeLeaf(leaf)
+	if err != nil || decodedAccount != account || decodedAmount.Cmp(amount) != 0 {
+		t.Fatalf("foglia decodificata %s %s, errore %v", decodedAccount.Hex(), decodedAmount, err)
+	}
+	if _, _, err := DecodeCumulativeLeaf(leaf[1:]); err == nil {
+		t.Fatal("foglia troncata accettata")
+	}
+}
+
+func TestBuildCumulativeEpochs(t *testing.T) {
+	first, err := ParseRewardDeltasCSV(strings.NewReader("account,delta\n" + testAccountA + ",100\n" + testAccountB + ",50\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	epoch1, report1, err := BuildCumulativeEpoch(nil, first, CumulativeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report1.Total.Int64() != 150 || report1.DeltaTotal.Int64() != 150 || len(report1.Changes) != 2 || !report1.Changes[0].New {
+		t.Fatalf("report della prima epoca %+v", report1)
+	}
+
+	// B non cambia, A aumenta, C è nuovo
+	epoch2, report2, err := BuildCumulativeEpoch(epoch1, []RewardDelta{
+		{Account: testAccountA, Amount: big.NewInt(25)},
+		{Account: testAccountC, Amount: big.NewInt(10)},
+	}, CumulativeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[common.Address]int64{
+		common.HexToAddress(testAccountA): 125,
+		common.HexToAddress(testAccountB): 50,
+		common.HexToAddress(testAccountC): 10,
+	}
+	balances := cumulativeBalances(t, epoch2)
+	for account, amount := range expected {
+		if balances[account] != amount {
+			t.Fatalf("importo di %s = %d, atteso %d", account.Hex(), balances[account], amount)
+		}
+	}
+	if report2.PreviousRoot != epoch1.Root() || report2.Root != epoch2.Root() {
+		t.Fatal("root del report non corrispondenti agli alberi")
+	}
+	if report2.PreviousTotal.Int64() != 150 || report2.Total.Int64() != 185 || report2.DeltaTotal.Int64() != 35 {
+		t.Fatalf("totali %s -> %s (delta %s)", report2.PreviousTotal, report2.Total, report2.DeltaTotal)
+	}
+	if len(report2.Changes) != 2 || report2.Accounts != 3 {
+		t.Fatalf("%d variazioni su %d account, attese 2 su 3", len(report2.Changes), report2.Accounts)
+	}
+}
+
+func TestBuildCumulativeEpochDecrease(t *testing.T) {
+	epoch1, _, err := BuildCumulativeEpoch(nil, []RewardDelta{
+		{Account: testAccountA, Amount: big.NewInt(100)},
+		{Account: testAccountB, Amount: big.NewInt(50)},
+	}, CumulativeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrease := []RewardDelta{{Account: testAccountA, Amount: big.NewInt(-40)}}
+
+	tree, report, err := BuildCumulativeEpoch(epoch1, decrease, CumulativeOptions{})
+	if !errors.Is(err, ErrCumulativeDecrease) || tree != nil {
+		t.Fatalf("errore %v, atteso ErrCumulativeDecrease senza albero", err)
+	}
+	if len(report.Decreases) != 1 || report.Decreases[0].Delta.Int64() != -40 {
+		t.Fatalf("diminuzioni %+v", report.Decreases)
+	}
+
+	tree, _, err = BuildCumulativeEpoch(epoch1, decrease, CumulativeOptions{AllowDecrease: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances := cumulativeBalances(t, tree); balances[common.HexToAddress(testAccountA)] != 60 {
+		t.Fatalf("importo di A = %d, atteso 60", balances[common.HexToAddress(testAccountA)])
+	}
+
+	if _, _, err := BuildCumulativeEpoch(epoch1, []RewardDelta{{Account: testAccountB, Amount: big.NewInt(-51)}}, CumulativeOptions{AllowDecrease: true}); err == nil {
+		t.Fatal("importo cumulativo negativo accettato")
+	}
+}
+
+func TestBuildCumulativeEpochRejectsInvalidInput(t *testing.T) {
+	cases := map[string][]RewardDelta{
+		"indirizzo non valido": {{Account: "0x12", Amount: big.NewInt(1)}},
+		"importo mancante":     {{Account: testAccountA}},
+		"account duplicato":    {{Account: testAccountA, Amount: big.NewInt(1)}, {Account: testAccountA, Amount: big.NewInt(2)}},
+		"nessun account":       {{Account: testAccountA, Amount: big.NewInt(0)}},
+	}
+	for name, deltas := range cases {
+		if _, _, err := BuildCumulativeEpoch(nil, deltas, CumulativeOptions{}); err == nil {
+			t.Errorf("%s: epoca accettata", name)
+		}
+	}
+
+	epoch1, _, err := BuildCumulativeEpoch(nil, []RewardDelta{{Account: testAccountA, Amount: big.NewInt(1)}, {Account: testAccountB, Amount: big.NewInt(2)}}, CumulativeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	epoch1.Values[0].Value = CumulativeLeaf(common.HexToAddress(testAccountA), big.NewInt(1000))
+	if _, _, err := BuildCumulativeEpoch(epoch1, nil, CumulativeOptions{}); err == nil {
+		t.Fatal("epoca precedente con un valore che non corrisponde alla foglia accettata")
+	}
+}
@@ -0,0 +1,104 @@
+package merkletree
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ValueChange descrive un valore presente in entrambi gli alberi ma modificato
+type ValueChange[T any] struct {
+	Key string `json:"key"`
+	Old T      `json:"old"`
+	New T      `json:"new"`
+}
+
+// TreeDiff rappresenta le differenze strutturali tra due Merkle Tree
+type TreeDiff[T any] struct {
+	OldRoot      HexString        `json:"oldRoot"`
+	NewRoot      HexString        `json:"newRoot"`
+	Added        []T              `json:"added"`
+	Removed      []T              `json:"removed"`
+	Modified     []ValueChange[T] `json:"modified"`
+	ChangedNodes *int             `json:"changedNodes,omitempty"` // Nodi che differiscono, solo tra alberi della stessa dimensione
+}
+
+// IsEmpty indica se i due alberi contengono gli stessi valori
+func (d TreeDiff[T]) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Diff confronta due alberi (anche caricati da un dump) abbinando i valori tramite `key`,
+// ad esempio la colonna dell'indirizzo. Se `key` è nil, la chiave è il valore stesso.
+func Diff[T any](a *MerkleTreeImpl[T], b *MerkleTreeImpl[T], key func(T) string) (TreeDiff[T], error) {
+	if key == nil {
+		key = func(value T) string { return fmt.Sprint(value) }
+	}
+
+	oldByKey, err := indexValuesByKey(a, key)
+	if err != nil {
+		return TreeDiff[T]{}, fmt.Errorf("albero precedente: %w", err)
+	}
+	newByKey, err := indexValuesByKey(b, key)
+	if err != nil {
+		return TreeDiff[T]{}, fmt.Errorf("albero nuovo: %w", err)
+	}
+
+	diff := TreeDiff[T]{
+		OldRoot: a.Root(),
+		NewRoot: b.Root(),
+	}
+	if changed, comparable := countChangedNodes(a.nodes(), b.nodes()); comparable {
+		diff.ChangedNodes = &changed
+	}
+
+	for _, v := range a.Values {
+		k := key(v.Value)
+		newIndex, found := newByKey[k]
+		if !found {
+			diff.Removed = append(diff.Removed, v.Value)
+			continue
+		}
+		newValue := b.Values[newIndex]
+		if a.node(v.TreeIndex) != b.node(newValue.TreeIndex) || !reflect.DeepEqual(v.Value, newValue.Value) {
+			diff.Modified = append(diff.Modified, ValueChange[T]{Key: k, Old: v.Value, New: newValue.Value})
+		}
+	}
+	for _, v := range b.Values {
+		if _, found := oldByKey[key(v.Value)]; !found {
+			diff.Added = append(diff.Added, v.Value)
+		}
+	}
+
+	return diff, nil
+}
+
+// indexValuesByKey costruisce la mappa chiave -> indice del valore, rifiutando chiavi duplicate
+func indexValuesByKey[T any](m *MerkleTreeImpl[T], key func(T) string) (map[string]int, error) {
+	byKey := make(map[string]int, len(m.Values))
+	for i, v := range m.Values {
+		k := key(v.Value)
+		if previous, found := byKey[k]; found {
+			return nil, fmt.Errorf("chiave duplicata %q (valori %d e %d)", k, previous, i)
+		}
+		byKey[k] = i
+	}
+	return byKey, nil
+}
+
+// countChangedNodes conta le posizioni in cui i due alberi differiscono. Il confronto ha senso
+// solo se gli alberi hanno lo stesso numero di nodi: altrimenti il layout a heap sposta tutte
+// le foglie e restituisce false.
+func countChangedNodes(a NodeStore, b NodeStore) (int, bool) {
+	if a.Len() != b.Len() {
+		return 0, false
+	}
+	changed := 0
+	for i := 0; i < a.Len(); i++ {
+		nodeA, errA := a.Get(i)
+		nodeB, errB := b.Get(i)
+		if errA != nil || errB != nil || nodeA != nodeB {
+			changed++
+		}
+	}
+	return changed, true
+}
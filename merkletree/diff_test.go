@@ -0,0 +1,63 @@
+package merkletree
+
+import (
+	"strings"
+	"testing"
+)
+
+func diffKey(value string) string {
+	return strings.SplitN(value, ":", 2)[0]
+}
+
+func TestDiffSameSize(t *testing.T) {
+	a := NewStandardMerkleTree([]string{"alice:10", "bob:20", "carol:30", "dave:40"}, MerkleTreeOptions{})
+	b := NewStandardMerkleTree([]string{"alice:10", "bob:25", "carol:30", "erin:40"}, MerkleTreeOptions{})
+
+	diff, err := Diff(&a.MerkleTreeImpl, &b.MerkleTreeImpl, diffKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "erin:40" {
+		t.Fatalf("aggiunti %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "dave:40" {
+		t.Fatalf("rimossi %v", diff.Removed)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0].Key != "bob" || diff.Modified[0].New != "bob:25" {
+		t.Fatalf("modificati %v", diff.Modified)
+	}
+	if diff.ChangedNodes == nil || *diff.ChangedNodes == 0 || *diff.ChangedNodes > len(a.Tree) {
+		t.Fatalf("ChangedNodes %v non valido per alberi della stessa dimensione", diff.ChangedNodes)
+	}
+
+	same, err := Diff(&a.MerkleTreeImpl, &a.MerkleTreeImpl, diffKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !same.IsEmpty() || same.ChangedNodes == nil || *same.ChangedNodes != 0 {
+		t.Fatalf("diff di un albero con se stesso non vuoto: %+v", same)
+	}
+}
+
+func TestDiffDifferentSizeOmitsChangedNodes(t *testing.T) {
+	a := NewStandardMerkleTree([]string{"alice:10", "bob:20", "carol:30"}, MerkleTreeOptions{})
+	b := NewStandardMerkleTree([]string{"alice:10", "bob:20", "carol:30", "dave:40"}, MerkleTreeOptions{})
+
+	diff, err := Diff(&a.MerkleTreeImpl, &b.MerkleTreeImpl, diffKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.ChangedNodes != nil {
+		t.Fatalf("ChangedNodes = %d per alberi di dimensione diversa", *diff.ChangedNodes)
+	}
+	if len(diff.Added) != 1 || len(diff.Removed) != 0 || len(diff.Modified) != 0 {
+		t.Fatalf("diff %+v", diff)
+	}
+}
+
+func TestDiffRejectsDuplicateKeys(t *testing.T) {
+	a := NewStandardMerkleTree([]string{"alice:10", "alice:20"}, MerkleTreeOptions{})
+	if _, err := Diff(&a.MerkleTreeImpl, &a.MerkleTreeImpl, diffKey); err == nil {
+		t.Fatal("chiavi duplicate accettate")
+	}
+}
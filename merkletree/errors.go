@@ -1,10 +1,18 @@
 package merkletree
 
 import (
+	"errors"
 	"fmt"
-	"log"
 )
 
+// ErrSortedLeavesRelayout indica che una modifica romperebbe l'ordinamento delle foglie
+// e richiederebbe di ricostruire l'albero da zero
+var ErrSortedLeavesRelayout = errors.New("la modifica rompe l'ordinamento delle foglie (SortLeaves): è necessario ricostruire l'albero")
+
+// ErrSymmetricNodeHash indica una NodeHash simmetrica (come StandardNodeHash) dove la proof
+// deve vincolare la posizione delle foglie
+var ErrSymmetricNodeHash = errors.New("la NodeHash è simmetrica e non vincola la posizione delle foglie: usare OrderedNodeHash")
+
 // Invariant verifica una condizione e causa un panic se la condizione è falsa
 func Invariant(condition bool, message string) {
 	if !condition {
@@ -15,14 +23,14 @@ func Invariant(condition bool, message string) {
 // InvariantWithDebug permette di fornire messaggi più dettagliati per il debug
 func InvariantWithDebug(condition bool, message string, debugInfo interface{}) {
 	if !condition {
-		log.Fatalf("InvariantError: %s | Debug Info: %v", message, debugInfo)
-		panic(fmt.Sprintf("InvariantError: %s", message))
+		Logger().Error("InvariantError", "messaggio", message, "debug", debugInfo)
+		panic(fmt.Sprintf("InvariantError: %s | Debug Info: %v", message, debugInfo))
 	}
 }
 
 // SafePanic permette di catturare gli errori e fornire messaggi dettagliati senza crash immediato
 func SafePanic(message string) {
-	log.Println("❌ ERRORE CRITICO:", message)
+	Logger().Error("errore critico", "messaggio", message)
 	panic(fmt.Sprintf("FatalError: %s", message))
 }
 
@@ -36,6 +44,6 @@ func ValidateArgument(condition bool, message string) {
 // Assert verifica una condizione e fornisce un log di errore senza crash immediato
 func Assert(condition bool, message string) {
 	if !condition {
-		log.Println("⚠️ AssertError:", message)
+		Logger().Warn("AssertError", "messaggio", message)
 	}
 }
@@ -0,0 +1,111 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxFixedDepth è la profondità massima di un albero a profondità fissa (2^MaxFixedDepth foglie):
+// l'albero è tenuto in memoria come HexString, e 2^25-1 nodi occupano già alcuni GiB
+const MaxFixedDepth = 24
+
+// ZeroLeaf è la foglia predefinita delle posizioni libere di un albero a profondità fissa
+const ZeroLeaf HexString = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+// MakeFixedDepthMerkleTree costruisce un albero perfetto con 2^depth foglie, completando
+// gli hash dati con `zeroLeaf`. Il layout è quello di MakeMerkleTree.
+func MakeFixedDepthMerkleTree(hashes []BytesLike, depth int, zeroLeaf BytesLike, nodeHash NodeHash) ([]HexString, error) {
+	return makeFixedDepthMerkleTreeContext(context.Background(), hashes, depth, zeroLeaf, nodeHash, nil)
+}
+
+// makeFixedDepthMerkleTreeContext costruisce l'albero livello per livello: i sottoalberi
+// che contengono solo foglie zero non vengono ricalcolati ma presi da ZeroHashes
+func makeFixedDepthMerkleTreeContext(ctx context.Context, hashes []BytesLike, depth int, zeroLeaf BytesLike, nodeHash NodeHash, progress ProgressFunc) ([]HexString, error) {
+	if depth < 0 || depth > MaxFixedDepth {
+		return nil, fmt.Errorf("profondità %d non valida (max %d)", depth, MaxFixedDepth)
+	}
+	if len(hashes) > 1<<depth {
+		return nil, fmt.Errorf("%d foglie non entrano in un albero di profondità %d", len(hashes), depth)
+	}
+	if !IsValidMerkleNode(zeroLeaf) {
+		return nil, errors.New("la foglia zero deve essere un nodo di 32 byte")
+	}
+	zeros := ZeroHashes(depth, zeroLeaf, nodeHash)
+
+	tree := make([]HexString, 2<<depth-1)
+	firstLeaf := 1<<depth - 1
+	for i := range tree[firstLeaf:] {
+		if i >= len(hashes) {
+			tree[firstLeaf+i] = zeros[0]
+			continue
+		}
+		leaf, err := ToHex(hashes[i])
+		if err != nil {
+			return nil, fmt.Errorf("foglia %d non valida: %w", i, err)
+		}
+		tree[firstLeaf+i] = leaf
+	}
+
+	// Generazione dei nodi interni, dal livello sopra le foglie fino alla root
+	reporter := newProgressReporter(ctx, progress, PhaseNodeHashing, firstLeaf)
+	done := 0
+	for level := 1; level <= depth; level++ {
+		start := 1<<(depth-level) - 1
+		width := 1 << (depth - level)
+		used := (len(hashes) + 1<<level - 1) >> level // Nodi con almeno una foglia non zero
+		for j := 0; j < width; j++ {
+			if err := reporter.step(done); err != nil {
+				return nil, err
+			}
+			done++
+			i := start + j
+			if j >= used {
+				tree[i] = zeros[level]
+				continue
+			}
+			tree[i] = nodeHash(tree[LeftChildIndex(i)], tree[RightChildIndex(i)])
+		}
+	}
+	reporter.done()
+
+	return tree, nil
+}
+
+// ZeroHashes restituisce le root dei sottoalberi vuoti: l'elemento i è la root di un
+// sottoalbero di altezza i con tutte le foglie uguali a `zeroLeaf`
+func ZeroHashes(depth int, zeroLeaf BytesLike, nodeHash NodeHash) []HexString {
+	zero, err := ToHex(zeroLeaf)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: foglia zero non valida: %v", err))
+	}
+	zeros := make([]HexString, depth+1)
+	zeros[0] = zero
+	for i := 1; i <= depth; i++ {
+		zeros[i] = nodeHash(zeros[i-1], zeros[i-1])
+	}
+	return zeros
+}
+
+// FixedDepthZeroHashes restituisce le root dei sottoalberi vuoti per ogni livello dell'albero
+// (nil se l'albero non ha profondità fissa), utili ai verificatori on-chain e ai circuiti
+func (m *MerkleTreeImpl[T]) FixedDepthZeroHashes() []HexString {
+	if m.Options.FixedDepth <= 0 {
+		return nil
+	}
+	return ZeroHashes(m.Options.FixedDepth, m.Options.zeroLeaf(), m.nodeHash())
+}
+
+// checkFixedDepthTree verifica che un albero esportato abbia la dimensione prevista dalla profondità
+func checkFixedDepthTree(tree []HexString, depth int) error {
+	if depth == 0 {
+		return nil
+	}
+	if depth < 0 || depth > MaxFixedDepth {
+		return fmt.Errorf("profondità %d non valida (max %d)", depth, MaxFixedDepth)
+	}
+	if len(tree) != 2<<depth-1 {
+		return fmt.Errorf("albero di %d nodi, attesi %d per la profondità %d", len(tree), 2<<depth-1, depth)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package merkletree
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMaxFixedDepth(t *testing.T) {
+	if _, err := MakeFixedDepthMerkleTree(nil, MaxFixedDepth+1, ZeroLeaf, StandardNodeHash); err == nil {
+		t.Fatalf("profondità %d accettata", MaxFixedDepth+1)
+	}
+	if _, err := NewStandardMerkleTreeContext(context.Background(), testValues(1), MerkleTreeOptions{FixedDepth: MaxFixedDepth + 1}, nil); err == nil {
+		t.Fatalf("albero standard di profondità %d accettato", MaxFixedDepth+1)
+	}
+	if _, err := LoadStandardMerkleTree(StandardMerkleTreeData[string]{Format: "standard-v1", Tree: []HexString{ZeroLeaf}, FixedDepth: MaxFixedDepth + 1}); err == nil {
+		t.Fatalf("dump di profondità %d accettato", MaxFixedDepth+1)
+	}
+
+	// Gli hash dei sottoalberi vuoti non allocano l'albero: la profondità massima è calcolabile
+	zeros := ZeroHashes(MaxFixedDepth, ZeroLeaf, StandardNodeHash)
+	if len(zeros) != MaxFixedDepth+1 || zeros[MaxFixedDepth] != StandardNodeHash(zeros[MaxFixedDepth-1], zeros[MaxFixedDepth-1]) {
+		t.Fatalf("hash dei sottoalberi vuoti fino a %d non validi", MaxFixedDepth)
+	}
+}
@@ -0,0 +1,245 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// TreeHandle è una vista in sola lettura di un albero, sicura da condividere tra goroutine.
+// Contiene una copia dei nodi e dei valori, con un proprio indice dei valori: le modifiche all'albero
+// di origine non la influenzano. I valori sono copiati per assegnazione, quindi valori che
+// contengono slice o puntatori non devono essere modificati dopo la creazione del handle.
+type TreeHandle[T any] struct {
+	tree MerkleTreeImpl[T]
+	root HexString
+}
+
+// NewTreeHandle crea un handle immutabile a partire da un albero in memoria
+func NewTreeHandle[T any](m *MerkleTreeImpl[T]) (*TreeHandle[T], error) {
+	if m.Store != nil {
+		return nil, errors.New("NewTreeHandle richiede un albero in memoria")
+	}
+	if len(m.Tree) == 0 {
+		return nil, errors.New("impossibile creare un handle per un albero vuoto")
+	}
+
+	tree := MerkleTreeImpl[T]{
+		Tree: append([]HexString(nil), m.Tree...),
+		Values: append([]struct {
+			Value     T
+			TreeIndex int
+		}(nil), m.Values...),
+		LeafHash: m.LeafHash,
+		NodeHash: m.NodeHash,
+		Options:  m.Options,
+	}
+	if tree.LeafHash == nil {
+		tree.LeafHash = StandardLeafHash[T]
+	}
+	// Il handle indicizza sempre i valori, anche se l'albero di origine non mantiene HashLookup
+	tree.rebuildHashLookup()
+
+	return &TreeHandle[T]{tree: tree, root: tree.Tree[0]}, nil
+}
+
+// Root restituisce la root dell'albero
+func (h *TreeHandle[T]) Root() HexString {
+	return h.root
+}
+
+// Len restituisce il numero di valori dell'albero
+func (h *TreeHandle[T]) Len() int {
+	return len(h.tree.Values)
+}
+
+// Value restituisce il valore con indice `index`
+func (h *TreeHandle[T]) Value(index int) (T, error) {
+	if index < 0 || index >= len(h.tree.Values) {
+		var zero T
+		return zero, fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(h.tree.Values)-1)
+	}
+	return h.tree.Values[index].Value, nil
+}
+
+// IndexOf restituisce l'indice di un valore, se presente
+func (h *TreeHandle[T]) IndexOf(value T) (int, bool) {
+	index, found := h.tree.HashLookup[h.tree.LeafHash(value)]
+	return index, found
+}
+
+// GetProofByIndex genera la proof per il valore con indice `index`.
+// A differenza di MerkleTreeImpl.GetProof restituisce un errore invece di un panic.
+func (h *TreeHandle[T]) GetProofByIndex(index int) ([]HexString, error) {
+	if err := h.checkIndex(index); err != nil {
+		return nil, err
+	}
+	return h.tree.GetProofAt(h.tree.Values[index].TreeIndex)
+}
+
+// GetProofByValue genera la proof per un valore. È separata da GetProofByIndex perché un
+// valore di tipo int non deve essere confuso con un indice.
+func (h *TreeHandle[T]) GetProofByValue(value T) ([]HexString, error) {
+	index, err := h.valueIndex(value)
+	if err != nil {
+		return nil, err
+	}
+	return h.GetProofByIndex(index)
+}
+
+// GetProofBundleByIndex genera un proof bundle per il valore con indice `index`
+func (h *TreeHandle[T]) GetProofBundleByIndex(index int) (ProofBundle, error) {
+	if err := h.checkIndex(index); err != nil {
+		return ProofBundle{}, err
+	}
+	return h.tree.GetProofBundle(index)
+}
+
+// GetProofBundleByValue genera un proof bundle per un valore
+func (h *TreeHandle[T]) GetProofBundleByValue(value T) (ProofBundle, error) {
+	index, err := h.valueIndex(value)
+	if err != nil {
+		return ProofBundle{}, err
+	}
+	return h.GetProofBundleByIndex(index)
+}
+
+// Verify verifica una proof rispetto alla root del handle. La proof viene ricalcolata a partire
+// dalla posizione del valore nell'albero, quindi è corretta anche con funzioni di hash non simmetriche.
+func (h *TreeHandle[T]) Verify(value T, proof []HexString) bool {
+	index, found := h.IndexOf(value)
+	if !found {
+		return false
+	}
+	treeIndex := h.tree.Values[index].TreeIndex
+	if len(proof) != treeDepth(treeIndex) {
+		return false
+	}
+	proofNodes := make([]BytesLike, len(proof))
+	for i, node := range proof {
+		if !IsValidMerkleNode(node) {
+			return false
+		}
+		proofNodes[i] = node
+	}
+	return ProcessProofAt(h.tree.LeafHash(value), treeIndex, proofNodes, h.tree.nodeHash()) == h.root
+}
+
+// Dump restituisce una copia dei dati dell'albero nel formato standard-v1
+func (h *TreeHandle[T]) Dump() StandardMerkleTreeData[T] {
+	return StandardMerkleTreeData[T]{
+		Format: "standard-v1",
+		Tree:   append([]HexString(nil), h.tree.Tree...),
+		Values: append([]struct {
+			Value     T
+			TreeIndex int
+		}(nil), h.tree.Values...),
+		FixedDepth: h.tree.Options.FixedDepth,
+		ZeroLeaf:   h.tree.Options.ZeroLeaf,
+	}
+}
+
+// checkIndex verifica che `index` sia l'indice di un valore
+func (h *TreeHandle[T]) checkIndex(index int) error {
+	if index < 0 || index >= len(h.tree.Values) {
+		return fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(h.tree.Values)-1)
+	}
+	return nil
+}
+
+// valueIndex restituisce l'indice di un valore presente nell'albero
+func (h *TreeHandle[T]) valueIndex(value T) (int, error) {
+	index, found := h.IndexOf(value)
+	if !found {
+		return 0, errors.New("il valore richiesto non esiste nell'albero")
+	}
+	return index, nil
+}
+
+// TreeRegistry contiene più alberi indicizzati per root, più un albero corrente.
+// Tutti i metodi sono sicuri per l'uso concorrente; le chiamate in corso su un handle
+// continuano a usarlo anche dopo uno Swap o una Remove.
+type TreeRegistry[T any] struct {
+	mu      sync.RWMutex
+	trees   map[HexString]*TreeHandle[T]
+	current atomic.Pointer[TreeHandle[T]]
+}
+
+// NewTreeRegistry crea un registro vuoto
+func NewTreeRegistry[T any]() *TreeRegistry[T] {
+	return &TreeRegistry[T]{trees: make(map[HexString]*TreeHandle[T])}
+}
+
+// Add registra un albero senza cambiare l'albero corrente
+func (r *TreeRegistry[T]) Add(handle *TreeHandle[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trees[handle.Root()] = handle
+}
+
+// Swap registra un albero e lo rende corrente in modo atomico, restituendo il precedente (o nil)
+func (r *TreeRegistry[T]) Swap(handle *TreeHandle[T]) *TreeHandle[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trees[handle.Root()] = handle
+	return r.current.Swap(handle)
+}
+
+// Current restituisce l'albero corrente, o nil se non è stato impostato
+func (r *TreeRegistry[T]) Current() *TreeHandle[T] {
+	return r.current.Load()
+}
+
+// Get restituisce l'albero con la root data
+func (r *TreeRegistry[T]) Get(root HexString) (*TreeHandle[T], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handle, found := r.trees[root]
+	return handle, found
+}
+
+// Remove rimuove l'albero con la root data; l'albero corrente non può essere rimosso
+func (r *TreeRegistry[T]) Remove(root HexString) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current := r.current.Load(); current != nil && current.Root() == root {
+		return fmt.Errorf("impossibile rimuovere l'albero corrente %s", root)
+	}
+	if _, found := r.trees[root]; !found {
+		return fmt.Errorf("nessun albero con root %s", root)
+	}
+	delete(r.trees, root)
+	return nil
+}
+
+// Roots restituisce le root registrate, in ordine lessicografico
+func (r *TreeRegistry[T]) Roots() []HexString {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	roots := make([]HexString, 0, len(r.trees))
+	for root := range r.trees {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+	return roots
+}
+
+// GetProofByValue genera la proof per un valore nell'albero con la root data
+func (r *TreeRegistry[T]) GetProofByValue(root HexString, value T) ([]HexString, error) {
+	handle, found := r.Get(root)
+	if !found {
+		return nil, fmt.Errorf("nessun albero con root %s", root)
+	}
+	return handle.GetProofByValue(value)
+}
+
+// GetProofByIndex genera la proof per il valore con indice `index` nell'albero con la root data
+func (r *TreeRegistry[T]) GetProofByIndex(root HexString, index int) ([]HexString, error) {
+	handle, found := r.Get(root)
+	if !found {
+		return nil, fmt.Errorf("nessun albero con root %s", root)
+	}
+	return handle.GetProofByIndex(index)
+}
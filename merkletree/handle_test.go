@@ -0,0 +1,186 @@
+package merkletree
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTreeHandleIntValues(t *testing.T) {
+	// Con T = int un valore non deve essere interpretato come indice
+	values := []uint64{}
+	for i := uint64(100); i < 108; i++ {
+		values = append(values, i)
+	}
+	tree := NewStandardMerkleTree(values, MerkleTreeOptions{})
+	handle, err := NewTreeHandle(&tree.MerkleTreeImpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intHandle := testIntHandle(t, []int{5, 1, 3})
+
+	for index, v := range handle.tree.Values {
+		byIndex, err := handle.GetProofByIndex(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byValue, err := handle.GetProofByValue(v.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalProofs(byIndex, byValue) || !handle.Verify(v.Value, byValue) {
+			t.Fatalf("proof del valore %d diverse o non valide", v.Value)
+		}
+	}
+	if _, err := handle.GetProofByIndex(len(values)); err == nil {
+		t.Fatal("indice fuori dai limiti accettato")
+	}
+	if _, err := handle.GetProofByValue(1); err == nil {
+		t.Fatal("valore assente accettato")
+	}
+
+	// Con T = int la ricerca per valore deve usare l'hash, non interpretare il valore come indice
+	proof, err := intHandle.GetProofByValue(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !intHandle.Verify(5, proof) {
+		t.Fatal("proof per valore non valida")
+	}
+	if _, err := intHandle.GetProofByValue(2); err == nil {
+		t.Fatal("il valore 2 è stato trattato come indice")
+	}
+
+	index, _ := intHandle.IndexOf(3)
+	byIndex, _ := intHandle.GetProofByIndex(index)
+	byValue, err := intHandle.GetProofByValue(3)
+	if err != nil || !equalProofs(byIndex, byValue) {
+		t.Fatalf("proof per indice e per valore diverse: %v", err)
+	}
+}
+
+// testIntHandle crea un handle per un albero di int, con l'hash della foglia su uint64
+func testIntHandle(t *testing.T, values []int) *TreeHandle[int] {
+	t.Helper()
+	leafHash := func(v int) HexString { return StandardLeafHash(uint64(v)) }
+	tree, indexed := PrepareMerkleTree(values, DefaultOptions, leafHash, nil)
+	handle, err := NewTreeHandle(&MerkleTreeImpl[int]{Tree: tree, Values: indexed, LeafHash: leafHash, Options: DefaultOptions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handle
+}
+
+func TestTreeHandleVerifyOrderedHash(t *testing.T) {
+	// Con OrderedNodeHash la posizione dei fratelli conta: Verify deve usare il TreeIndex del valore
+	values := testValues(7)
+	tree, indexed := PrepareMerkleTree(values, DefaultOptions, StandardLeafHash[string], OrderedNodeHash)
+	handle, err := NewTreeHandle(&MerkleTreeImpl[string]{Tree: tree, Values: indexed, LeafHash: StandardLeafHash[string], NodeHash: OrderedNodeHash, Options: DefaultOptions})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index, v := range indexed {
+		proof, err := handle.GetProofByIndex(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !handle.Verify(v.Value, proof) {
+			t.Fatalf("proof del valore %q (TreeIndex %d) non valida", v.Value, v.TreeIndex)
+		}
+		if other := values[(index+1)%len(values)]; handle.Verify(other, proof) {
+			t.Fatalf("proof del valore %q accettata per %q", v.Value, other)
+		}
+		if len(proof) > 0 && handle.Verify(v.Value, proof[:len(proof)-1]) {
+			t.Fatalf("proof troncata accettata per %q", v.Value)
+		}
+	}
+	if handle.Verify("assente", nil) {
+		t.Fatal("valore assente accettato")
+	}
+}
+
+func TestTreeHandleDumpFixedDepth(t *testing.T) {
+	options := MerkleTreeOptions{FixedDepth: 3, ZeroLeaf: StandardLeafHash("vuota")}
+	tree := NewStandardMerkleTree(testValues(3), options)
+	handle, err := NewTreeHandle(&tree.MerkleTreeImpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadStandardMerkleTree(handle.Dump())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Options != tree.Options || loaded.Root() != handle.Root() {
+		t.Fatalf("opzioni ricaricate %+v, attese %+v", loaded.Options, tree.Options)
+	}
+	if err := loaded.AppendLeaves("valore-z"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := NewStandardMerkleTree(append(testValues(3), "valore-z"), options).Root(); loaded.Root() != expected {
+		t.Fatalf("dopo AppendLeaves root %s, attesa %s", loaded.Root(), expected)
+	}
+}
+
+func TestTreeRegistryConcurrentSwap(t *testing.T) {
+	const versions = 8
+	handles := make([]*TreeHandle[string], versions)
+	for i := range handles {
+		tree := NewStandardMerkleTree(testValues(16+i), MerkleTreeOptions{})
+		handle, err := NewTreeHandle(&tree.MerkleTreeImpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles[i] = handle
+	}
+
+	registry := NewTreeRegistry[string]()
+	registry.Swap(handles[0])
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	errs := make(chan string, 64)
+	for reader := 0; reader < 8; reader++ {
+		wg.Add(1)
+		go func(reader int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// La proof in corso usa il handle letto, anche se nel frattempo viene sostituito
+				handle := registry.Current()
+				value := testValues(16)[(reader+i)%16]
+				proof, err := handle.GetProofByValue(value)
+				if err != nil {
+					errs <- err.Error()
+					return
+				}
+				if !handle.Verify(value, proof) {
+					errs <- "proof non valida per la root del handle"
+					return
+				}
+				if _, err := registry.GetProofByValue(handle.Root(), value); err != nil {
+					errs <- err.Error()
+					return
+				}
+			}
+		}(reader)
+	}
+
+	for round := 0; round < 200; round++ {
+		previous := registry.Swap(handles[(round+1)%versions])
+		if previous == nil {
+			t.Fatal("Swap non ha restituito l'albero precedente")
+		}
+		_ = registry.Roots()
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
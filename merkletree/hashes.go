@@ -19,7 +19,7 @@ func StandardLeafHash[T any](value T) HexString {
 
 	encodedPacked, err := keccak256HashedData(value)
 	if err != nil {
-		fmt.Errorf("Error: %s", err)
+		Logger().Warn("hash della foglia non riuscito", "err", err)
 	}
 	encodedPackedHex, err := ToHex(encodedPacked)
 	return encodedPackedHex
@@ -32,13 +32,13 @@ func StandardNodeHash(a BytesLike, b BytesLike) HexString {
 	sort.Slice(nodes, func(i, j int) bool {
 		result, err := Compare(nodes[i], nodes[j])
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			Logger().Warn("confronto dei nodi non riuscito", "err", err)
 		}
 		return result < 0
 	})
 	concatenated, err := Concat(nodes[0], nodes[1])
 	if err != nil {
-		fmt.Errorf("Error: ", err)
+		Logger().Warn("concatenazione dei nodi non riuscita", "err", err)
 	}
 	hashed, _ := keccak256HashedData(concatenated)
 	hashedHex, _ := ToHex(hashed)
@@ -0,0 +1,213 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// HashOnlyMerkleTree è un albero costruito o caricato dai soli hash delle foglie, senza i
+// valori: permette di pubblicare l'albero senza rivelare destinatari e importi. Chi conosce
+// il proprio valore ricalcola l'hash della foglia (es. con StandardLeafHash) e ne chiede la proof.
+type HashOnlyMerkleTree struct {
+	Tree       []HexString
+	NodeHash   NodeHash
+	HashLookup map[HexString]int // Hash della foglia -> indice nell'albero
+	Options    MerkleTreeOptions
+}
+
+// RedactedMerkleTreeData rappresenta un albero esportato senza i valori
+type RedactedMerkleTreeData struct {
+	Format     string
+	Tree       []HexString
+	FixedDepth int       `json:",omitempty"`
+	ZeroLeaf   HexString `json:",omitempty"`
+}
+
+// NewHashOnlyMerkleTree costruisce un albero a partire dagli hash delle foglie. Con le opzioni
+// predefinite le foglie vengono ordinate come in NewStandardMerkleTree, quindi a parità di
+// hash si ottengono lo stesso albero e la stessa root.
+func NewHashOnlyMerkleTree(leafHashes []BytesLike, options *MerkleTreeOptions, nodeHash NodeHash) (*HashOnlyMerkleTree, error) {
+	if nodeHash == nil {
+		nodeHash = StandardNodeHash
+	}
+	opts := NewMerkleTreeOptions(options)
+	hashes := make([]HexString, len(leafHashes))
+	for i, leaf := range leafHashes {
+		if !IsValidMerkleNode(leaf) {
+			return nil, fmt.Errorf("l'hash della foglia %d non è un nodo di 32 byte", i)
+		}
+		hashes[i], _ = ToHex(leaf)
+	}
+	identity := func(hash HexString) HexString { return hash }
+
+	tree, _, err := PrepareMerkleTreeContext(context.Background(), hashes, opts, identity, nodeHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	hashOnlyTree := &HashOnlyMerkleTree{Tree: tree, NodeHash: nodeHash, Options: opts}
+	hashOnlyTree.rebuildHashLookup()
+	return hashOnlyTree, nil
+}
+
+// LoadHashOnlyMerkleTree carica un albero esportato con Redact
+func LoadHashOnlyMerkleTree(data RedactedMerkleTreeData, nodeHash NodeHash) (*HashOnlyMerkleTree, error) {
+	if data.Format != "redacted-v1" {
+		return nil, fmt.Errorf("formato non supportato: %q", data.Format)
+	}
+	if len(data.Tree) == 0 {
+		return nil, errors.New("albero vuoto")
+	}
+	if err := checkFixedDepthTree(data.Tree, data.FixedDepth); err != nil {
+		return nil, err
+	}
+	if nodeHash == nil {
+		nodeHash = StandardNodeHash
+	}
+	options := NewMerkleTreeOptions(&MerkleTreeOptions{FixedDepth: data.FixedDepth, ZeroLeaf: data.ZeroLeaf})
+
+	hashOnlyTree := &HashOnlyMerkleTree{Tree: data.Tree, NodeHash: nodeHash, Options: options}
+	if !IsValidMerkleTree(hashOnlyTree.Tree, nodeHash) {
+		return nil, errors.New("l'albero esportato non è valido")
+	}
+	hashOnlyTree.rebuildHashLookup()
+	return hashOnlyTree, nil
+}
+
+// rebuildHashLookup indicizza le foglie per hash; negli alberi a profondità fissa le
+// posizioni libere (foglia zero) non vengono indicizzate
+func (m *HashOnlyMerkleTree) rebuildHashLookup() {
+	firstLeaf := len(m.Tree) / 2
+	m.HashLookup = make(map[HexString]int, len(m.Tree)-firstLeaf)
+	for i := firstLeaf; i < len(m.Tree); i++ {
+		if m.Options.FixedDepth > 0 && m.Tree[i] == m.Options.zeroLeaf() {
+			continue
+		}
+		m.HashLookup[m.Tree[i]] = i
+	}
+}
+
+// Root restituisce la root dell'albero
+func (m *HashOnlyMerkleTree) Root() HexString {
+	return m.Tree[0]
+}
+
+// LeafIndex restituisce l'indice nell'albero della foglia con l'hash dato
+func (m *HashOnlyMerkleTree) LeafIndex(leafHash BytesLike) (int, error) {
+	hash, err := ToHex(leafHash)
+	if err != nil {
+		return 0, err
+	}
+	index, found := m.HashLookup[hash]
+	if !found {
+		return 0, fmt.Errorf("la foglia %s non esiste nell'albero", hash)
+	}
+	return index, nil
+}
+
+// GetProof restituisce la proof della foglia con l'hash dato
+func (m *HashOnlyMerkleTree) GetProof(leafHash BytesLike) ([]HexString, error) {
+	index, err := m.LeafIndex(leafHash)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetProofAt(index)
+}
+
+// GetProofAt restituisce la proof della foglia con indice `treeIndex` nell'albero
+func (m *HashOnlyMerkleTree) GetProofAt(treeIndex int) ([]HexString, error) {
+	return GetProofFromStore(NewMemoryNodeStore(m.Tree), treeIndex)
+}
+
+// Verify verifica la proof di una foglia, dato il suo hash. La proof viene ricalcolata dalla
+// posizione della foglia nell'albero, quindi è corretta con qualsiasi NodeHash.
+func (m *HashOnlyMerkleTree) Verify(leafHash BytesLike, proof []HexString) bool {
+	treeIndex, err := m.LeafIndex(leafHash)
+	if err != nil {
+		return false
+	}
+	bytesProof := make([]BytesLike, len(proof))
+	for i, node := range proof {
+		bytesProof[i] = node
+	}
+	return VerifyHashOnlyMerkleTreeAt(m.Root(), leafHash, treeIndex, bytesProof, m.NodeHash)
+}
+
+// Redact esporta l'albero senza i valori; si ricarica con LoadHashOnlyMerkleTree
+func (m *HashOnlyMerkleTree) Redact() RedactedMerkleTreeData {
+	return RedactedMerkleTreeData{
+		Format:     "redacted-v1",
+		Tree:       append([]HexString{}, m.Tree...),
+		FixedDepth: m.Options.FixedDepth,
+		ZeroLeaf:   m.Options.ZeroLeaf,
+	}
+}
+
+// Redact esporta l'array completo dei nodi senza i valori: chi conosce il proprio valore
+// ricalcola l'hash della foglia e ottiene la proof da LoadHashOnlyMerkleTree
+func (m *MerkleTreeImpl[T]) Redact() (RedactedMerkleTreeData, error) {
+	store := m.nodes()
+	tree := make([]HexString, store.Len())
+	for i := range tree {
+		node, err := store.Get(i)
+		if err != nil {
+			return RedactedMerkleTreeData{}, err
+		}
+		tree[i] = node
+	}
+	return RedactedMerkleTreeData{
+		Format:     "redacted-v1",
+		Tree:       tree,
+		FixedDepth: m.Options.FixedDepth,
+		ZeroLeaf:   m.Options.ZeroLeaf,
+	}, nil
+}
+
+// VerifyHashOnlyMerkleTree verifica la proof di una foglia dato solo il suo hash. Senza la
+// posizione della foglia la verifica è corretta solo con funzioni di hash simmetriche (come
+// StandardNodeHash): con le altre restituisce false, e va usata VerifyHashOnlyMerkleTreeAt.
+func VerifyHashOnlyMerkleTree(root BytesLike, leafHash BytesLike, proof []BytesLike, nodeHash NodeHash) bool {
+	if nodeHash == nil {
+		nodeHash = StandardNodeHash
+	}
+	if !isSymmetricNodeHash(nodeHash) {
+		return false
+	}
+	if !validHashOnlyProof(leafHash, proof) {
+		return false
+	}
+	rootHex, err := ToHex(root)
+	if err != nil {
+		return false
+	}
+	return ProcessProof(leafHash, proof, nodeHash) == rootHex
+}
+
+// VerifyHashOnlyMerkleTreeAt verifica la proof della foglia con indice `treeIndex` nell'albero,
+// combinando i fratelli a sinistra o a destra secondo la posizione
+func VerifyHashOnlyMerkleTreeAt(root BytesLike, leafHash BytesLike, treeIndex int, proof []BytesLike, nodeHash NodeHash) bool {
+	if nodeHash == nil {
+		nodeHash = StandardNodeHash
+	}
+	if treeIndex < 0 || len(proof) != treeDepth(treeIndex) || !validHashOnlyProof(leafHash, proof) {
+		return false
+	}
+	rootHex, err := ToHex(root)
+	if err != nil {
+		return false
+	}
+	return ProcessProofAt(leafHash, treeIndex, proof, nodeHash) == rootHex
+}
+
+// validHashOnlyProof verifica che foglia e nodi della proof siano nodi di 32 byte
+func validHashOnlyProof(leafHash BytesLike, proof []BytesLike) bool {
+	if !IsValidMerkleNode(leafHash) {
+		return false
+	}
+	for _, node := range proof {
+		if !IsValidMerkleNode(node) {
+			return false
+		}
+	}
+	return true
+}
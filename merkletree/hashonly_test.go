@@ -0,0 +1,197 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testLeafHashes(values []string) []BytesLike {
+	hashes := make([]BytesLike, len(values))
+	for i, value := range values {
+		hashes[i] = StandardLeafHash(value)
+	}
+	return hashes
+}
+
+func TestHashOnlyTreeMatchesStandardTree(t *testing.T) {
+	values := testValues(7)
+	standard := NewStandardMerkleTree(values, MerkleTreeOptions{})
+	hashOnly, err := NewHashOnlyMerkleTree(testLeafHashes(values), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashOnly.Root() != standard.Root() || !equalProofs(hashOnly.Tree, standard.Tree) {
+		t.Fatalf("root %s, attesa %s", hashOnly.Root(), standard.Root())
+	}
+
+	for i, value := range values {
+		proof, err := hashOnly.GetProof(StandardLeafHash(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := standard.GetProof(i); !equalProofs(proof, expected) {
+			t.Fatalf("proof di %s diversa da quella dell'albero standard", value)
+		}
+		if !hashOnly.Verify(StandardLeafHash(value), proof) {
+			t.Fatalf("proof di %s non valida", value)
+		}
+		if hashOnly.Verify(StandardLeafHash(values[(i+1)%len(values)]), proof) {
+			t.Fatalf("proof di %s accettata per un'altra foglia", value)
+		}
+	}
+
+	if _, err := hashOnly.GetProof(StandardLeafHash("assente")); err == nil {
+		t.Fatal("proof generata per una foglia assente")
+	}
+	if _, err := NewHashOnlyMerkleTree([]BytesLike{"0x1234"}, nil, nil); err == nil {
+		t.Fatal("hash di foglia non valido accettato")
+	}
+}
+
+func TestHashOnlyTreeOrderedNodeHash(t *testing.T) {
+	values := testValues(6)
+	hashOnly, err := NewHashOnlyMerkleTree(testLeafHashes(values), nil, OrderedNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHashOnlyMerkleTree(hashOnly.Redact(), OrderedNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, leafHash := range testLeafHashes(values) {
+		proof, err := loaded.GetProof(leafHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Verify(leafHash, proof) {
+			t.Fatalf("proof della foglia %s non valida con OrderedNodeHash", leafHash)
+		}
+		treeIndex, _ := loaded.LeafIndex(leafHash)
+		proofNodes := make([]BytesLike, len(proof))
+		for i, node := range proof {
+			proofNodes[i] = node
+		}
+		if !VerifyHashOnlyMerkleTreeAt(loaded.Root(), leafHash, treeIndex, proofNodes, OrderedNodeHash) {
+			t.Fatalf("proof della foglia %s non valida alla posizione %d", leafHash, treeIndex)
+		}
+		if VerifyHashOnlyMerkleTreeAt(loaded.Root(), leafHash, SiblingIndex(treeIndex), proofNodes, OrderedNodeHash) {
+			t.Fatalf("proof della foglia %s accettata nella posizione del fratello", leafHash)
+		}
+		// Senza posizione la verifica con una funzione non simmetrica viene rifiutata
+		if VerifyHashOnlyMerkleTree(loaded.Root(), leafHash, proofNodes, OrderedNodeHash) {
+			t.Fatal("verifica senza posizione accettata con OrderedNodeHash")
+		}
+	}
+}
+
+func TestRedactAndLoadRoundTrip(t *testing.T) {
+	values := testValues(6)
+	standard := NewStandardMerkleTree(values, MerkleTreeOptions{})
+	redacted, err := standard.Redact()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// L'esportazione contiene tutto l'array dei nodi ma nessun valore
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "valore-") {
+		t.Fatalf("l'esportazione contiene i valori: %s", data)
+	}
+	var decoded RedactedMerkleTreeData
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHashOnlyMerkleTree(decoded, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Root() != standard.Root() || len(loaded.Tree) != len(standard.Tree) {
+		t.Fatalf("root %s, attesa %s", loaded.Root(), standard.Root())
+	}
+
+	// Chi conosce il proprio valore ricalcola la foglia e ottiene una proof verificabile
+	for _, value := range values {
+		proof, err := loaded.GetProof(StandardLeafHash(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bytesProof := make([]BytesLike, len(proof))
+		for i, node := range proof {
+			bytesProof[i] = node
+		}
+		if !VerifyStandardMerkleTree(standard.Root(), value, bytesProof) {
+			t.Fatalf("proof di %s non valida per l'albero standard", value)
+		}
+	}
+
+	// Il nuovo export dell'albero caricato è identico
+	again, err := json.Marshal(loaded.Redact())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Fatalf("export diverso dopo il caricamento:\n%s\n%s", again, data)
+	}
+}
+
+func TestRedactFixedDepthRoundTrip(t *testing.T) {
+	values := testValues(5)
+	standard := NewStandardMerkleTree(values, MerkleTreeOptions{FixedDepth: 3})
+	redacted, err := standard.Redact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redacted.FixedDepth != 3 {
+		t.Fatalf("profondità esportata %d, attesa 3", redacted.FixedDepth)
+	}
+	loaded, err := LoadHashOnlyMerkleTree(redacted, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Root() != standard.Root() || loaded.Options.FixedDepth != 3 || loaded.Options.SortLeaves {
+		t.Fatalf("albero caricato %+v", loaded.Options)
+	}
+	// Le posizioni libere non sono indicizzate: non si ottiene la proof della foglia zero
+	if len(loaded.HashLookup) != len(values) {
+		t.Fatalf("%d foglie indicizzate, attese %d", len(loaded.HashLookup), len(values))
+	}
+	if _, err := loaded.GetProof(ZeroLeaf); err == nil {
+		t.Fatal("proof generata per una posizione libera")
+	}
+	for _, value := range values {
+		proof, err := loaded.GetProof(StandardLeafHash(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(proof) != 3 || !loaded.Verify(StandardLeafHash(value), proof) {
+			t.Fatalf("proof di %s non valida: %v", value, proof)
+		}
+	}
+}
+
+func TestLoadHashOnlyMerkleTreeRejectsInvalidData(t *testing.T) {
+	redacted, err := NewStandardMerkleTree(testValues(4), MerkleTreeOptions{}).Redact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]func(*RedactedMerkleTreeData){
+		"formato sconosciuto": func(d *RedactedMerkleTreeData) { d.Format = "redacted-v2" },
+		"albero vuoto":        func(d *RedactedMerkleTreeData) { d.Tree = nil },
+		"nodo modificato":     func(d *RedactedMerkleTreeData) { d.Tree[len(d.Tree)-1] = StandardLeafHash("altro") },
+		"root modificata":     func(d *RedactedMerkleTreeData) { d.Tree[0] = StandardLeafHash("altro") },
+		"profondità errata":   func(d *RedactedMerkleTreeData) { d.FixedDepth = 5 },
+	}
+	for name, tamper := range cases {
+		data := redacted
+		data.Tree = append([]HexString{}, redacted.Tree...)
+		tamper(&data)
+		if _, err := LoadHashOnlyMerkleTree(data, nil); err == nil {
+			t.Errorf("%s: albero caricato", name)
+		}
+	}
+}
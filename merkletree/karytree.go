@@ -0,0 +1,411 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxKaryArity è l'arietà massima di un albero k-ario (il limite di input di Poseidon)
+const MaxKaryArity = 16
+
+// NaryNodeHash calcola l'hash di un nodo a partire da tutti i suoi figli, in ordine
+type NaryNodeHash func(children []BytesLike) HexString
+
+// KeccakNaryNodeHash calcola keccak256 della concatenazione dei figli, nell'ordine dato
+func KeccakNaryNodeHash(children []BytesLike) HexString {
+	data := make([]byte, 0, 32*len(children))
+	for i, child := range children {
+		bytes, err := ToBytes(child)
+		if err != nil {
+			panic(fmt.Sprintf("❌ ERRORE: figlio %d non valido: %v", i, err))
+		}
+		data = append(data, bytes...)
+	}
+	return keccak256Hex(data)
+}
+
+// PoseidonNaryNodeHash calcola Poseidon(children...), come i circuiti Merkle k-ari di circomlib
+func PoseidonNaryNodeHash(children []BytesLike) HexString {
+	return PoseidonHash(children...)
+}
+
+// KaryParentIndex restituisce l'indice del genitore nel layout a heap di arietà `arity`
+func KaryParentIndex(i int, arity int) int {
+	if i > 0 {
+		return (i - 1) / arity
+	}
+	panic("❌ ERRORE: La radice non ha un nodo genitore!")
+}
+
+// KaryChildIndex restituisce l'indice del figlio `j` (da 0 a arity-1) del nodo `i`
+func KaryChildIndex(i int, arity int, j int) int {
+	return arity*i + 1 + j
+}
+
+// KarySiblingIndices restituisce gli indici dei fratelli del nodo `i`, in ordine e senza `i`
+func KarySiblingIndices(i int, arity int) []int {
+	parent := KaryParentIndex(i, arity)
+	siblings := make([]int, 0, arity-1)
+	for j := 0; j < arity; j++ {
+		if child := KaryChildIndex(parent, arity, j); child != i {
+			siblings = append(siblings, child)
+		}
+	}
+	return siblings
+}
+
+// KaryProof dimostra l'inclusione di una foglia: per ogni livello, dal basso, i fratelli
+// del nodo nel percorso (arity-1 nodi, nell'ordine dei figli)
+type KaryProof struct {
+	Index    int           `json:"index"` // Posizione della foglia: le cifre in base arity indicano la posizione a ogni livello
+	Leaf     HexString     `json:"leaf"`
+	Siblings [][]HexString `json:"siblings"`
+}
+
+// KaryMultiProof dimostra l'inclusione di più foglie. Il verificatore ricostruisce i nodi
+// livello per livello: per ogni genitore, in ordine crescente, i figli non ricavabili dalle
+// foglie o dai livelli inferiori vengono presi da Proof nell'ordine.
+type KaryMultiProof struct {
+	Depth   int         `json:"depth"`
+	Indices []int       `json:"indices"` // Posizioni delle foglie, in ordine crescente
+	Leaves  []HexString `json:"leaves"`
+	Proof   []HexString `json:"proof"`
+}
+
+// KaryMerkleTree è un albero di Merkle perfetto di arietà configurabile: con hash come
+// Poseidon, che accettano più input, alberi più larghi hanno proof meno profonde.
+// Usa il layout a heap (i figli del nodo i sono arity*i+1 ... arity*i+arity) e completa
+// le foglie con una foglia zero fino a arity^Depth.
+type KaryMerkleTree struct {
+	Arity    int
+	Depth    int
+	Tree     []HexString
+	Leaves   int // Numero di foglie reali
+	NodeHash NaryNodeHash
+}
+
+// NewKaryMerkleTree costruisce un albero di arietà `arity` a partire dagli hash delle foglie.
+// Con depth 0 usa la profondità minima che contiene tutte le foglie; zeroLeaf vuota vale ZeroLeaf.
+func NewKaryMerkleTree(hashes []BytesLike, arity int, depth int, zeroLeaf HexString, nodeHash NaryNodeHash) (*KaryMerkleTree, error) {
+	if arity < 2 || arity > MaxKaryArity {
+		return nil, fmt.Errorf("arietà %d non valida (da 2 a %d)", arity, MaxKaryArity)
+	}
+	if nodeHash == nil {
+		return nil, errors.New("funzione di hash dei nodi mancante")
+	}
+	if zeroLeaf == "" {
+		zeroLeaf = ZeroLeaf
+	}
+	if !IsValidMerkleNode(zeroLeaf) {
+		return nil, errors.New("la foglia zero deve essere un nodo di 32 byte")
+	}
+	if depth < 0 {
+		return nil, fmt.Errorf("profondità %d non valida", depth)
+	}
+	if depth == 0 {
+		for capacity := 1; capacity < len(hashes); capacity *= arity {
+			depth++
+		}
+	}
+	capacity, err := karyCapacity(arity, depth)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) > capacity {
+		return nil, fmt.Errorf("%d foglie non entrano in un albero %d-ario di profondità %d", len(hashes), arity, depth)
+	}
+
+	zeros := KaryZeroHashes(arity, depth, zeroLeaf, nodeHash)
+	firstLeaf := karyLevelStart(arity, depth)
+	tree := make([]HexString, firstLeaf+capacity)
+	for i := 0; i < capacity; i++ {
+		if i >= len(hashes) {
+			tree[firstLeaf+i] = zeros[0]
+			continue
+		}
+		leaf, err := ToHex(hashes[i])
+		if err != nil || !IsValidMerkleNode(leaf) {
+			return nil, fmt.Errorf("foglia %d non valida", i)
+		}
+		tree[firstLeaf+i] = leaf
+	}
+
+	// Generazione dei nodi interni livello per livello: i sottoalberi vuoti sono presi da zeros
+	used, width := len(hashes), capacity
+	for level := 1; level <= depth; level++ {
+		used = (used + arity - 1) / arity
+		width /= arity
+		start := karyLevelStart(arity, depth-level)
+		for j := 0; j < width; j++ {
+			i := start + j
+			if j >= used {
+				tree[i] = zeros[level]
+				continue
+			}
+			children := make([]BytesLike, arity)
+			for c := range children {
+				children[c] = tree[KaryChildIndex(i, arity, c)]
+			}
+			tree[i] = nodeHash(children)
+		}
+	}
+
+	return &KaryMerkleTree{Arity: arity, Depth: depth, Tree: tree, Leaves: len(hashes), NodeHash: nodeHash}, nil
+}
+
+// karyCapacity restituisce arity^depth, limitato a 2^MaxFixedDepth foglie
+func karyCapacity(arity int, depth int) (int, error) {
+	capacity := 1
+	for i := 0; i < depth; i++ {
+		capacity *= arity
+		if capacity > 1<<MaxFixedDepth {
+			return 0, fmt.Errorf("un albero %d-ario di profondità %d supera 2^%d foglie", arity, depth, MaxFixedDepth)
+		}
+	}
+	return capacity, nil
+}
+
+// karyLevelStart restituisce l'indice del primo nodo del livello `level` (0 = root)
+func karyLevelStart(arity int, level int) int {
+	start, width := 0, 1
+	for i := 0; i < level; i++ {
+		start += width
+		width *= arity
+	}
+	return start
+}
+
+// KaryZeroHashes restituisce le root dei sottoalberi vuoti: l'elemento i è la root di un
+// sottoalbero di altezza i con tutte le foglie uguali a `zeroLeaf`
+func KaryZeroHashes(arity int, depth int, zeroLeaf BytesLike, nodeHash NaryNodeHash) []HexString {
+	zero, err := ToHex(zeroLeaf)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: foglia zero non valida: %v", err))
+	}
+	zeros := make([]HexString, depth+1)
+	zeros[0] = zero
+	for i := 1; i <= depth; i++ {
+		children := make([]BytesLike, arity)
+		for c := range children {
+			children[c] = zeros[i-1]
+		}
+		zeros[i] = nodeHash(children)
+	}
+	return zeros
+}
+
+// Root restituisce la root dell'albero
+func (t *KaryMerkleTree) Root() HexString {
+	return t.Tree[0]
+}
+
+// leafTreeIndex restituisce l'indice nel layout a heap della foglia in posizione `index`
+func (t *KaryMerkleTree) leafTreeIndex(index int) (int, error) {
+	firstLeaf := karyLevelStart(t.Arity, t.Depth)
+	if index < 0 || firstLeaf+index >= len(t.Tree) {
+		return 0, fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(t.Tree)-firstLeaf-1)
+	}
+	return firstLeaf + index, nil
+}
+
+// GetProof restituisce la proof della foglia in posizione `index` (anche una foglia zero)
+func (t *KaryMerkleTree) GetProof(index int) (KaryProof, error) {
+	treeIndex, err := t.leafTreeIndex(index)
+	if err != nil {
+		return KaryProof{}, err
+	}
+	proof := KaryProof{Index: index, Leaf: t.Tree[treeIndex]}
+	for i := treeIndex; i > 0; i = KaryParentIndex(i, t.Arity) {
+		group := make([]HexString, 0, t.Arity-1)
+		for _, sibling := range KarySiblingIndices(i, t.Arity) {
+			group = append(group, t.Tree[sibling])
+		}
+		proof.Siblings = append(proof.Siblings, group)
+	}
+	return proof, nil
+}
+
+// ProcessKaryProof ricalcola la root a partire da una proof per un albero di profondità
+// `depth`. La profondità è un parametro del verificatore e non viene presa dalla proof:
+// altrimenti una proof più corta farebbe passare un nodo interno per una foglia.
+func ProcessKaryProof(proof KaryProof, arity int, depth int, nodeHash NaryNodeHash) (HexString, error) {
+	if arity < 2 || arity > MaxKaryArity {
+		return "", fmt.Errorf("arietà %d non valida (da 2 a %d)", arity, MaxKaryArity)
+	}
+	if len(proof.Siblings) != depth {
+		return "", fmt.Errorf("proof di %d livelli, attesi %d", len(proof.Siblings), depth)
+	}
+	capacity, err := karyCapacity(arity, depth)
+	if err != nil {
+		return "", err
+	}
+	if proof.Index < 0 || proof.Index >= capacity {
+		return "", fmt.Errorf("indice %d non valido per una proof di %d livelli", proof.Index, len(proof.Siblings))
+	}
+	if !IsValidMerkleNode(proof.Leaf) {
+		return "", errors.New("foglia non valida")
+	}
+
+	node := proof.Leaf
+	index := proof.Index
+	for level, group := range proof.Siblings {
+		if len(group) != arity-1 {
+			return "", fmt.Errorf("livello %d: %d fratelli, attesi %d", level, len(group), arity-1)
+		}
+		position := index % arity
+		children := make([]BytesLike, 0, arity)
+		for j, sibling := range group {
+			if !IsValidMerkleNode(sibling) {
+				return "", fmt.Errorf("livello %d: fratello %d non valido", level, j)
+			}
+			if j == position {
+				children = append(children, node)
+			}
+			children = append(children, sibling)
+		}
+		if position == arity-1 {
+			children = append(children, node)
+		}
+		node = nodeHash(children)
+		index /= arity
+	}
+	return ToHex(node)
+}
+
+// VerifyKaryProof verifica una proof rispetto alla root attesa di un albero di profondità `depth`
+func VerifyKaryProof(root BytesLike, proof KaryProof, arity int, depth int, nodeHash NaryNodeHash) bool {
+	computed, err := ProcessKaryProof(proof, arity, depth, nodeHash)
+	if err != nil {
+		return false
+	}
+	expected, err := ToHex(root)
+	return err == nil && computed == expected
+}
+
+// GetMultiProof restituisce la multiproof delle foglie nelle posizioni date
+func (t *KaryMerkleTree) GetMultiProof(indices []int) (KaryMultiProof, error) {
+	if len(indices) == 0 {
+		return KaryMultiProof{}, errors.New("impossibile generare una proof multipla per 0 elementi")
+	}
+	sorted := append([]int{}, indices...)
+	sort.Ints(sorted)
+	multiproof := KaryMultiProof{Depth: t.Depth}
+	for i, index := range sorted {
+		if i > 0 && index == sorted[i-1] {
+			return KaryMultiProof{}, fmt.Errorf("indice %d duplicato", index)
+		}
+		treeIndex, err := t.leafTreeIndex(index)
+		if err != nil {
+			return KaryMultiProof{}, err
+		}
+		multiproof.Indices = append(multiproof.Indices, index)
+		multiproof.Leaves = append(multiproof.Leaves, t.Tree[treeIndex])
+	}
+
+	known := multiproof.Indices
+	for level := t.Depth; level > 0; level-- {
+		start := karyLevelStart(t.Arity, level)
+		known = karyWalkLevel(known, t.Arity, func(position int) {
+			multiproof.Proof = append(multiproof.Proof, t.Tree[start+position])
+		}, nil)
+	}
+	return multiproof, nil
+}
+
+// karyWalkLevel visita i genitori dei nodi noti di un livello, in ordine crescente: chiama
+// `missing` per ogni figlio non noto e `parent` per ogni genitore. Restituisce le posizioni
+// dei genitori, che diventano i nodi noti del livello superiore.
+func karyWalkLevel(known []int, arity int, missing func(position int), parent func(position int)) []int {
+	var parents []int
+	for k := 0; k < len(known); {
+		p := known[k] / arity
+		for j := 0; j < arity; j++ {
+			position := p*arity + j
+			if k < len(known) && known[k] == position {
+				k++
+				continue
+			}
+			missing(position)
+		}
+		if parent != nil {
+			parent(p)
+		}
+		parents = append(parents, p)
+	}
+	return parents
+}
+
+// ProcessKaryMultiProof ricalcola la root a partire da una multiproof per un albero di
+// profondità `depth`; multiproof.Depth deve coincidere, come per ProcessKaryProof
+func ProcessKaryMultiProof(multiproof KaryMultiProof, arity int, depth int, nodeHash NaryNodeHash) (HexString, error) {
+	if arity < 2 || arity > MaxKaryArity {
+		return "", fmt.Errorf("arietà %d non valida (da 2 a %d)", arity, MaxKaryArity)
+	}
+	if depth < 0 || multiproof.Depth != depth {
+		return "", fmt.Errorf("multiproof di profondità %d, attesa %d", multiproof.Depth, depth)
+	}
+	capacity, err := karyCapacity(arity, depth)
+	if err != nil {
+		return "", err
+	}
+	if len(multiproof.Indices) == 0 || len(multiproof.Indices) != len(multiproof.Leaves) {
+		return "", fmt.Errorf("%d foglie per %d indici", len(multiproof.Leaves), len(multiproof.Indices))
+	}
+
+	nodes := make(map[int]HexString, len(multiproof.Indices))
+	for i, index := range multiproof.Indices {
+		if index < 0 || index >= capacity || (i > 0 && index <= multiproof.Indices[i-1]) {
+			return "", fmt.Errorf("indice %d non valido: gli indici devono essere crescenti e minori di %d", index, capacity)
+		}
+		if !IsValidMerkleNode(multiproof.Leaves[i]) {
+			return "", fmt.Errorf("foglia %d non valida", i)
+		}
+		nodes[index] = multiproof.Leaves[i]
+	}
+
+	proof := multiproof.Proof
+	known := multiproof.Indices
+	for level := depth; level > 0; level-- {
+		var invalid error
+		parents := make(map[int]HexString)
+		known = karyWalkLevel(known, arity, func(position int) {
+			if len(proof) == 0 {
+				invalid = errors.New("multiproof troppo corta")
+				return
+			}
+			if !IsValidMerkleNode(proof[0]) {
+				invalid = errors.New("nodo della multiproof non valido")
+			}
+			nodes[position] = proof[0]
+			proof = proof[1:]
+		}, func(p int) {
+			if invalid != nil {
+				return
+			}
+			children := make([]BytesLike, arity)
+			for j := range children {
+				children[j] = nodes[p*arity+j]
+			}
+			parents[p] = nodeHash(children)
+		})
+		if invalid != nil {
+			return "", invalid
+		}
+		nodes = parents
+	}
+	if len(proof) != 0 {
+		return "", fmt.Errorf("multiproof con %d nodi in eccesso", len(proof))
+	}
+	return ToHex(nodes[0])
+}
+
+// VerifyKaryMultiProof verifica una multiproof rispetto alla root attesa di un albero di profondità `depth`
+func VerifyKaryMultiProof(root BytesLike, multiproof KaryMultiProof, arity int, depth int, nodeHash NaryNodeHash) bool {
+	computed, err := ProcessKaryMultiProof(multiproof, arity, depth, nodeHash)
+	if err != nil {
+		return false
+	}
+	expected, err := ToHex(root)
+	return err == nil && computed == expected
+}
@@ -0,0 +1,249 @@
+package merkletree
+
+import (
+	"math/big"
+	"testing"
+)
+
+// karyTestLeaves restituisce `n` hash di foglie distinti
+func karyTestLeaves(n int) []BytesLike {
+	leaves := make([]BytesLike, n)
+	for i, value := range testValues(n) {
+		leaves[i] = StandardLeafHash(value)
+	}
+	return leaves
+}
+
+func TestKaryIndices(t *testing.T) {
+	if parent := KaryParentIndex(7, 4); parent != 1 {
+		t.Fatalf("genitore di 7 = %d, atteso 1", parent)
+	}
+	siblings := KarySiblingIndices(6, 4)
+	if len(siblings) != 3 || siblings[0] != 5 || siblings[1] != 7 || siblings[2] != 8 {
+		t.Fatalf("fratelli di 6 = %v, attesi [5 7 8]", siblings)
+	}
+	// Con arietà 2 il layout coincide con quello degli alberi binari
+	for i := 1; i < 32; i++ {
+		if KaryParentIndex(i, 2) != ParentIndex(i) || KarySiblingIndices(i, 2)[0] != SiblingIndex(i) {
+			t.Fatalf("nodo %d: layout binario diverso", i)
+		}
+		if KaryChildIndex(i, 2, 0) != LeftChildIndex(i) {
+			t.Fatalf("nodo %d: figlio sinistro diverso", i)
+		}
+	}
+}
+
+func TestKaryRootMatchesManualHash(t *testing.T) {
+	leaves := karyTestLeaves(5)
+	tree, err := NewKaryMerkleTree(leaves, 4, 0, "", KeccakNaryNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Depth != 2 || len(tree.Tree) != 1+4+16 {
+		t.Fatalf("profondità %d con %d nodi, attesi 2 e 21", tree.Depth, len(tree.Tree))
+	}
+
+	// Livello 1: il primo gruppo è pieno, il secondo ha una foglia, gli altri due sono vuoti
+	z := ZeroLeaf
+	empty := KeccakNaryNodeHash([]BytesLike{z, z, z, z})
+	first := KeccakNaryNodeHash(leaves[:4])
+	second := KeccakNaryNodeHash([]BytesLike{leaves[4], z, z, z})
+	if root := KeccakNaryNodeHash([]BytesLike{first, second, empty, empty}); tree.Root() != root {
+		t.Fatalf("root %s, attesa %s", tree.Root(), root)
+	}
+	if zeros := KaryZeroHashes(4, 2, z, KeccakNaryNodeHash); zeros[1] != empty {
+		t.Fatalf("hash del sottoalbero vuoto %s, atteso %s", zeros[1], empty)
+	}
+}
+
+func TestKaryBinaryMatchesFixedDepthTree(t *testing.T) {
+	leaves := karyTestLeaves(11)
+	kary, err := NewKaryMerkleTree(leaves, 2, 4, "", KeccakNaryNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary, err := MakeFixedDepthMerkleTree(leaves, 4, ZeroLeaf, OrderedNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kary.Root() != binary[0] {
+		t.Fatalf("root %s, attesa quella dell'albero binario %s", kary.Root(), binary[0])
+	}
+}
+
+func TestKaryProofs(t *testing.T) {
+	for _, arity := range []int{2, 4, 8, 16} {
+		leaves := karyTestLeaves(3*arity + 1)
+		tree, err := NewKaryMerkleTree(leaves, arity, 0, "", KeccakNaryNodeHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		capacity := len(tree.Tree) - karyLevelStart(arity, tree.Depth)
+		for index := 0; index < capacity; index++ {
+			proof, err := tree.GetProof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(proof.Siblings) != tree.Depth {
+				t.Fatalf("arietà %d, foglia %d: %d livelli, attesi %d", arity, index, len(proof.Siblings), tree.Depth)
+			}
+			if !VerifyKaryProof(tree.Root(), proof, arity, tree.Depth, KeccakNaryNodeHash) {
+				t.Fatalf("arietà %d: proof della foglia %d non valida", arity, index)
+			}
+
+			// La stessa proof in un'altra posizione del gruppo non è valida (se le foglie differiscono)
+			moved := proof
+			moved.Index = index ^ 1
+			if index^1 < len(leaves) && VerifyKaryProof(tree.Root(), moved, arity, tree.Depth, KeccakNaryNodeHash) {
+				t.Fatalf("arietà %d: proof della foglia %d valida in posizione %d", arity, index, moved.Index)
+			}
+		}
+
+		proof, _ := tree.GetProof(0)
+		proof.Siblings = append([][]HexString{proof.Siblings[0][1:]}, proof.Siblings[1:]...)
+		if _, err := ProcessKaryProof(proof, arity, tree.Depth, KeccakNaryNodeHash); err == nil {
+			t.Fatalf("arietà %d: gruppo di fratelli incompleto accettato", arity)
+		}
+		if _, err := tree.GetProof(capacity); err == nil {
+			t.Fatalf("arietà %d: indice fuori dai limiti accettato", arity)
+		}
+
+		// Il genitore della foglia 0, con la proof senza il livello delle foglie, dà la root
+		// giusta: va rifiutato perché la proof è più corta della profondità dell'albero
+		internal, _ := tree.GetProof(0)
+		internal.Leaf = tree.Tree[karyLevelStart(arity, tree.Depth-1)]
+		internal.Siblings = internal.Siblings[1:]
+		if VerifyKaryProof(tree.Root(), internal, arity, tree.Depth, KeccakNaryNodeHash) {
+			t.Fatalf("arietà %d: nodo interno accettato come foglia", arity)
+		}
+		if !VerifyKaryProof(tree.Root(), internal, arity, tree.Depth-1, KeccakNaryNodeHash) {
+			t.Fatalf("arietà %d: la proof del nodo interno non ricalcola la root", arity)
+		}
+	}
+}
+
+func TestKaryMultiProofs(t *testing.T) {
+	leaves := karyTestLeaves(37)
+	tree, err := NewKaryMerkleTree(leaves, 4, 0, "", KeccakNaryNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := make([]int, 64)
+	for i := range all {
+		all[i] = i
+	}
+	cases := map[string][]int{
+		"una foglia":            {5},
+		"stesso gruppo":         {4, 5, 7},
+		"gruppi diversi":        {0, 17, 36},
+		"non ordinati":          {36, 2, 19},
+		"foglie zero":           {40, 63},
+		"gruppo completo":       {8, 9, 10, 11},
+		"tutte le foglie":       all,
+		"primo e ultimo gruppo": {0, 1, 62, 63},
+	}
+	for name, indices := range cases {
+		multiproof, err := tree.GetMultiProof(indices)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !VerifyKaryMultiProof(tree.Root(), multiproof, 4, tree.Depth, KeccakNaryNodeHash) {
+			t.Fatalf("%s: multiproof non valida", name)
+		}
+
+		// Una multiproof non usa più nodi delle proof singole delle stesse foglie
+		if len(multiproof.Proof) > len(indices)*3*tree.Depth {
+			t.Fatalf("%s: multiproof di %d nodi", name, len(multiproof.Proof))
+		}
+		if len(multiproof.Proof) > 0 {
+			short := multiproof
+			short.Proof = multiproof.Proof[1:]
+			if _, err := ProcessKaryMultiProof(short, 4, tree.Depth, KeccakNaryNodeHash); err == nil {
+				t.Fatalf("%s: multiproof troppo corta accettata", name)
+			}
+		}
+		long := multiproof
+		long.Proof = append(append([]HexString{}, multiproof.Proof...), ZeroLeaf)
+		if _, err := ProcessKaryMultiProof(long, 4, tree.Depth, KeccakNaryNodeHash); err == nil {
+			t.Fatalf("%s: multiproof con nodi in eccesso accettata", name)
+		}
+		tampered := multiproof
+		tampered.Leaves = append([]HexString{}, multiproof.Leaves...)
+		tampered.Leaves[0] = StandardLeafHash("altro")
+		if VerifyKaryMultiProof(tree.Root(), tampered, 4, tree.Depth, KeccakNaryNodeHash) {
+			t.Fatalf("%s: multiproof con una foglia modificata accettata", name)
+		}
+	}
+
+	if multiproof, _ := tree.GetMultiProof(all); len(multiproof.Proof) != 0 {
+		t.Fatalf("la multiproof di tutte le foglie ha %d nodi, attesi 0", len(multiproof.Proof))
+	}
+	if multiproof, _ := tree.GetMultiProof([]int{4, 5, 7}); len(multiproof.Proof) != 1+3+3 {
+		t.Fatalf("multiproof di 3 foglie dello stesso gruppo con %d nodi, attesi 7", len(multiproof.Proof))
+	}
+	if _, err := tree.GetMultiProof([]int{3, 3}); err == nil {
+		t.Fatal("indice duplicato accettato")
+	}
+	if _, err := tree.GetMultiProof(nil); err == nil {
+		t.Fatal("multiproof vuota accettata")
+	}
+
+	// Un livello in meno: i nodi interni del penultimo livello passano per foglie
+	internal := KaryMultiProof{Depth: tree.Depth - 1, Indices: []int{0}, Leaves: []HexString{tree.Tree[karyLevelStart(4, tree.Depth-1)]}}
+	for position := 1; position < 16; position++ {
+		internal.Proof = append(internal.Proof, tree.Tree[karyLevelStart(4, tree.Depth-1)+position])
+	}
+	if VerifyKaryMultiProof(tree.Root(), internal, 4, tree.Depth, KeccakNaryNodeHash) {
+		t.Fatal("multiproof di un nodo interno accettata come foglia")
+	}
+
+	unsorted, _ := tree.GetMultiProof([]int{1, 30})
+	unsorted.Indices = []int{30, 1}
+	unsorted.Leaves = []HexString{unsorted.Leaves[1], unsorted.Leaves[0]}
+	if _, err := ProcessKaryMultiProof(unsorted, 4, tree.Depth, KeccakNaryNodeHash); err == nil {
+		t.Fatal("indici non ordinati accettati")
+	}
+}
+
+func TestKaryPoseidonTree(t *testing.T) {
+	values := make([]BytesLike, 6)
+	for i := range values {
+		values[i] = PoseidonLeafHash(big.NewInt(int64(i + 1)))
+	}
+	tree, err := NewKaryMerkleTree(values, 4, 2, fieldHex(big.NewInt(0)), PoseidonNaryNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero := big.NewInt(0)
+	first := PoseidonHash(values[:4]...)
+	second := PoseidonHash(values[4], values[5], zero, zero)
+	empty := PoseidonHash(zero, zero, zero, zero)
+	if root := PoseidonHash(first, second, empty, empty); tree.Root() != root {
+		t.Fatalf("root %s, attesa %s", tree.Root(), root)
+	}
+	multiproof, err := tree.GetMultiProof([]int{1, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyKaryMultiProof(tree.Root(), multiproof, 4, tree.Depth, PoseidonNaryNodeHash) {
+		t.Fatal("multiproof Poseidon non valida")
+	}
+}
+
+func TestKaryTreeLimits(t *testing.T) {
+	if _, err := NewKaryMerkleTree(karyTestLeaves(2), MaxKaryArity+1, 0, "", KeccakNaryNodeHash); err == nil {
+		t.Error("arietà oltre il massimo accettata")
+	}
+	if _, err := NewKaryMerkleTree(karyTestLeaves(17), 4, 2, "", KeccakNaryNodeHash); err == nil {
+		t.Error("più foglie della capacità accettate")
+	}
+	if _, err := NewKaryMerkleTree(nil, 16, 7, "", KeccakNaryNodeHash); err == nil {
+		t.Error("albero oltre 2^MaxFixedDepth foglie accettato")
+	}
+	if _, err := NewKaryMerkleTree(nil, 2, MaxFixedDepth+1, "", KeccakNaryNodeHash); err == nil {
+		t.Errorf("albero binario di profondità %d accettato", MaxFixedDepth+1)
+	}
+	if _, err := NewKaryMerkleTree(karyTestLeaves(2), 4, 0, "", nil); err == nil {
+		t.Error("albero senza funzione di hash accettato")
+	}
+}
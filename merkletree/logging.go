@@ -0,0 +1,35 @@
+package merkletree
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// discardHandler è un slog.Handler che scarta tutti i messaggi
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var packageLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	packageLogger.Store(slog.New(discardHandler{}))
+}
+
+// SetLogger imposta il logger usato dalla libreria per i messaggi diagnostici.
+// Di default la libreria è silenziosa; passando nil si torna al comportamento predefinito.
+func SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(discardHandler{})
+	}
+	packageLogger.Store(logger)
+}
+
+// Logger restituisce il logger corrente della libreria
+func Logger() *slog.Logger {
+	return packageLogger.Load()
+}
@@ -0,0 +1,39 @@
+package merkletree
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTreeLoggerOverridesPackageLogger(t *testing.T) {
+	var packageOutput, treeOutput bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&packageOutput, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer SetLogger(nil)
+
+	logger := slog.New(slog.NewTextHandler(&treeOutput, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	tree := NewStandardMerkleTree(testValues(4), MerkleTreeOptions{Logger: logger})
+	if tree.Options.Logger != logger {
+		t.Fatal("il logger delle opzioni non è stato mantenuto")
+	}
+	tree.Validate()
+
+	if !strings.Contains(treeOutput.String(), "validato") {
+		t.Fatalf("messaggio non scritto sul logger dell'albero: %q", treeOutput.String())
+	}
+	if strings.Contains(packageOutput.String(), "validato") {
+		t.Fatal("messaggio dell'albero scritto sul logger della libreria")
+	}
+}
+
+func TestTreeLoggerFallsBackToPackageLogger(t *testing.T) {
+	var packageOutput bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&packageOutput, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer SetLogger(nil)
+
+	NewStandardMerkleTree(testValues(4), MerkleTreeOptions{}).Validate()
+	if !strings.Contains(packageOutput.String(), "validato") {
+		t.Fatalf("messaggio non scritto sul logger della libreria: %q", packageOutput.String())
+	}
+}
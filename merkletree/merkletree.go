@@ -14,11 +14,79 @@ type MerkleTreeImpl[T any] struct {
 	LeafHash   func(T) HexString
 	NodeHash   NodeHash
 	HashLookup map[HexString]int
+	Options    MerkleTreeOptions
+	Store      NodeStore // Se impostato, i nodi vengono letti dallo store invece che da Tree
+}
+
+// NewMerkleTreeFromStore apre un albero i cui nodi sono già presenti in uno store,
+// ad esempio un file scritto da BuildMerkleTreeStream, senza caricarli in memoria
+func NewMerkleTreeFromStore[T any](store NodeStore, leafHash func(T) HexString, nodeHash NodeHash) *MerkleTreeImpl[T] {
+	if leafHash == nil {
+		leafHash = StandardLeafHash[T]
+	}
+	return &MerkleTreeImpl[T]{
+		LeafHash:   leafHash,
+		NodeHash:   nodeHash,
+		HashLookup: make(map[HexString]int),
+		Store:      store,
+	}
 }
 
 // Root restituisce la root dell'albero di Merkle
 func (m *MerkleTreeImpl[T]) Root() HexString {
-	return m.Tree[0]
+	return m.node(0)
+}
+
+// nodes restituisce lo store dei nodi: Store se impostato, altrimenti una vista su Tree
+// che non alloca e segue le riassegnazioni di Tree
+func (m *MerkleTreeImpl[T]) nodes() NodeStore {
+	if m.Store != nil {
+		return m.Store
+	}
+	return (*treeNodeStore[T])(m)
+}
+
+// treeNodeStore espone Tree come NodeStore; la conversione da *MerkleTreeImpl non alloca
+type treeNodeStore[T any] MerkleTreeImpl[T]
+
+// Get restituisce il nodo con indice `index`
+func (s *treeNodeStore[T]) Get(index int) (HexString, error) {
+	if index < 0 || index >= len(s.Tree) {
+		return "", nodeIndexError(index, len(s.Tree))
+	}
+	return s.Tree[index], nil
+}
+
+// Put scrive il nodo con indice `index`; l'indice Len() aggiunge un nodo in coda
+func (s *treeNodeStore[T]) Put(index int, node HexString) error {
+	switch {
+	case index >= 0 && index < len(s.Tree):
+		s.Tree[index] = node
+	case index == len(s.Tree):
+		s.Tree = append(s.Tree, node)
+	default:
+		return nodeIndexError(index, len(s.Tree))
+	}
+	return nil
+}
+
+// Len restituisce il numero di nodi
+func (s *treeNodeStore[T]) Len() int {
+	return len(s.Tree)
+}
+
+// Close non fa nulla: i nodi appartengono all'albero
+func (s *treeNodeStore[T]) Close() error {
+	return nil
+}
+
+// node restituisce il nodo con indice `index`
+func (m *MerkleTreeImpl[T]) node(index int) HexString {
+	node, err := m.nodes().Get(index)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	return node
 }
 
 // getLeafIndex restituisce l'indice di un valore nel Merkle Tree
@@ -45,7 +113,7 @@ func (m *MerkleTreeImpl[T]) validateValueAt(index int) {
 	}
 
 	expectedHash := m.LeafHash(m.Values[index].Value)
-	actualHash := m.Tree[m.Values[index].TreeIndex]
+	actualHash := m.node(m.Values[index].TreeIndex)
 
 	if expectedHash != actualHash {
 		panic(fmt.Sprintf("❌ ERRORE: Valore atteso %s, ma trovato %s", expectedHash, actualHash))
@@ -94,18 +162,11 @@ func (m *MerkleTreeImpl[T]) GetProof(leaf interface{}) []HexString {
 	valueIndex := m.getLeafIndex(leaf)
 	m.validateValueAt(valueIndex)
 
-	treeIndex := m.Values[valueIndex].TreeIndex
-	bytesTree := make([]BytesLike, len(m.Tree))
-	for i, hexStr := range m.Tree {
-		hexStrVal, err := ToBytes(hexStr)
-		if err != nil {
-			fmt.Errorf("Error: ", err)
-		}
-		bytesTree[i] = hexStrVal
+	proof, err := m.GetProofAt(m.Values[valueIndex].TreeIndex)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
 	}
 
-	proof := GetProof(bytesTree, treeIndex)
-
 	if len(proof) == 0 {
 		panic("❌ ERRORE: Proof generata è vuota!")
 	}
@@ -113,13 +174,19 @@ func (m *MerkleTreeImpl[T]) GetProof(leaf interface{}) []HexString {
 	return proof
 }
 
+// GetProofAt genera la proof per la foglia con indice `treeIndex` nell'albero,
+// utile quando l'albero è aperto da uno store senza i valori
+func (m *MerkleTreeImpl[T]) GetProofAt(treeIndex int) ([]HexString, error) {
+	return GetProofFromStore(m.nodes(), treeIndex)
+}
+
 // Verify verifica se una proof è valida
 func (m *MerkleTreeImpl[T]) Verify(leaf interface{}, proof []HexString) bool {
 	bytesProof := make([]BytesLike, len(proof))
 	for i, hexStr := range proof {
 		proofVal, err := ToBytes(hexStr)
 		if err != nil {
-			fmt.Errorf("Error: ", err)
+			m.Options.logger().Warn("conversione della proof non riuscita", "err", err)
 		}
 		bytesProof[i] = proofVal
 	}
@@ -144,9 +211,13 @@ func (m *MerkleTreeImpl[T]) Validate() {
 		m.validateValueAt(i)
 	}
 
-	if !IsValidMerkleTree(m.Tree, m.NodeHash) {
+	valid, err := IsValidNodeStore(m.nodes(), m.NodeHash)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	if !valid {
 		panic("❌ ERRORE: L'albero di Merkle non è valido!")
 	}
 
-	fmt.Println("✅ Albero di Merkle validato con successo!")
+	m.Options.logger().Debug("albero di Merkle validato con successo")
 }
@@ -0,0 +1,354 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Posizioni dei sibling nelle proof di merkletreejs
+const (
+	MerkleTreeJSLeft  = "left"
+	MerkleTreeJSRight = "right"
+)
+
+// MerkleTreeJSHashFunc è la funzione di hash usata da merkletreejs per foglie e nodi
+type MerkleTreeJSHashFunc func(data []byte) []byte
+
+// MerkleTreeJSOptions corrisponde alle opzioni del costruttore di merkletreejs
+type MerkleTreeJSOptions struct {
+	HashFn          MerkleTreeJSHashFunc   `json:"-"`             // Default: SHA-256, come merkletreejs
+	SortPairs       bool                   `json:"sortPairs"`     // Ordina i due figli prima di calcolare l'hash del nodo
+	SortLeaves      bool                   `json:"sortLeaves"`    // Ordina le foglie (dopo l'eventuale hash)
+	HashLeaves      bool                   `json:"hashLeaves"`    // Applica HashFn alle foglie
+	DuplicateOdd    bool                   `json:"duplicateOdd"`  // Il nodo dispari viene accoppiato con se stesso invece di essere promosso
+	IsBitcoinTree   bool                   `json:"isBitcoinTree"` // Hash doppio con byte invertiti, come le merkle root di Bitcoin
+	Complete        bool                   `json:"complete"`      // Albero binario completo: le foglie in eccesso sono accoppiate sul livello più basso
+	FillDefaultHash func(index int) []byte `json:"-"`             // Se impostata, completa le foglie fino alla potenza di due successiva
+}
+
+// MerkleTreeJSProofNode è un elemento di proof nel formato di merkletreejs `{position, data}`
+type MerkleTreeJSProofNode struct {
+	Position string    `json:"position"` // MerkleTreeJSLeft se il sibling sta a sinistra
+	Data     HexString `json:"data"`
+}
+
+// MerkleTreeJS riproduce il layout a livelli di merkletreejs: ogni livello contiene gli hash
+// delle coppie del livello precedente e, senza DuplicateOdd, il nodo dispari viene promosso
+// così com'è. Root e proof coincidono con quelle della libreria JavaScript.
+type MerkleTreeJS struct {
+	Options   MerkleTreeJSOptions
+	layers    [][][]byte
+	leafLimit int // Foglie accoppiate al primo livello (le altre salgono di livello con Complete)
+}
+
+// NewMerkleTreeJS costruisce l'albero come `new MerkleTree(leaves, hashFn, options)`
+func NewMerkleTreeJS(leaves []BytesLike, options MerkleTreeJSOptions) (*MerkleTreeJS, error) {
+	if options.Complete && (options.IsBitcoinTree || options.DuplicateOdd) {
+		return nil, errors.New("l'opzione Complete non è compatibile con IsBitcoinTree e DuplicateOdd")
+	}
+	if options.HashFn == nil {
+		options.HashFn = MerkleTreeJSSha256
+	}
+
+	nodes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		leafBytes, err := ToBytes(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("foglia %d non valida: %w", i, err)
+		}
+		if options.HashLeaves {
+			leafBytes = options.HashFn(leafBytes)
+		}
+		nodes[i] = leafBytes
+	}
+	if options.SortLeaves {
+		sort.SliceStable(nodes, func(i, j int) bool { return bytes.Compare(nodes[i], nodes[j]) < 0 })
+	}
+	if options.FillDefaultHash != nil && len(nodes) > 1 {
+		for i := len(nodes); i < 1<<bits.Len(uint(len(nodes)-1)); i++ {
+			nodes = append(nodes, options.FillDefaultHash(i))
+		}
+	}
+
+	tree := &MerkleTreeJS{Options: options, layers: [][][]byte{nodes}, leafLimit: len(nodes)}
+	if options.Complete && len(nodes) > 1 && len(nodes)&(len(nodes)-1) != 0 {
+		// Come merkletreejs: sul livello delle foglie si accoppiano solo quelle che non entrano
+		// nella potenza di due precedente, le altre salgono di livello
+		tree.leafLimit = 2*len(nodes) - 1<<bits.Len(uint(len(nodes)-1))
+	}
+	tree.createHashes(nodes)
+	return tree, nil
+}
+
+// createHashes costruisce i livelli sopra le foglie come MerkleTree.createHashes
+func (t *MerkleTreeJS) createHashes(nodes [][]byte) {
+	for len(nodes) > 1 {
+		layer := make([][]byte, 0, (len(nodes)+1)/2)
+		limit := len(nodes)
+		if len(t.layers) == 1 {
+			limit = t.leafLimit
+		}
+		for i := 0; i < len(nodes); i += 2 {
+			if i >= limit {
+				layer = append(layer, nodes[limit:]...)
+				break
+			}
+			if i+1 == len(nodes) && !t.Options.IsBitcoinTree && !t.Options.DuplicateOdd {
+				// Il nodo dispari viene promosso al livello successivo senza hash
+				layer = append(layer, nodes[i])
+				continue
+			}
+			left := nodes[i]
+			right := left
+			if i+1 < len(nodes) {
+				right = nodes[i+1]
+			}
+			layer = append(layer, t.hashPair(left, right, t.Options.SortPairs))
+		}
+		t.layers = append(t.layers, layer)
+		nodes = layer
+	}
+}
+
+// hashPair calcola l'hash di un nodo a partire dai due figli, nell'ordine dato
+// (o in ordine crescente se `sortPairs`)
+func (t *MerkleTreeJS) hashPair(left []byte, right []byte, sortPairs bool) []byte {
+	if t.Options.IsBitcoinTree {
+		// Bitcoin lavora sugli hash in ordine di byte interno: invertiamo, doppio hash e invertiamo di nuovo
+		left, right = reversedBytes(left), reversedBytes(right)
+	}
+	if sortPairs && bytes.Compare(left, right) > 0 {
+		left, right = right, left
+	}
+	hash := t.Options.HashFn(append(append([]byte{}, left...), right...))
+	if t.Options.IsBitcoinTree {
+		hash = reversedBytes(t.Options.HashFn(hash))
+	}
+	return hash
+}
+
+// Root restituisce la root dell'albero ("0x" per un albero vuoto, come getHexRoot)
+func (t *MerkleTreeJS) Root() HexString {
+	top := t.layers[len(t.layers)-1]
+	if len(top) == 0 {
+		return "0x"
+	}
+	return bytesHex(top[0])
+}
+
+// Leaves restituisce le foglie dopo hash, ordinamento e riempimento
+func (t *MerkleTreeJS) Leaves() []HexString {
+	return layerHex(t.layers[0])
+}
+
+// Layers restituisce tutti i livelli, dalle foglie alla root, come getHexLayers
+func (t *MerkleTreeJS) Layers() [][]HexString {
+	layers := make([][]HexString, len(t.layers))
+	for i, layer := range t.layers {
+		layers[i] = layerHex(layer)
+	}
+	return layers
+}
+
+// LeafIndex restituisce l'indice di una foglia (già hashata), o -1 se non esiste.
+// Come merkletreejs, con foglie duplicate restituisce l'ultima occorrenza.
+func (t *MerkleTreeJS) LeafIndex(leaf BytesLike) int {
+	leafBytes, err := ToBytes(leaf)
+	if err != nil {
+		return -1
+	}
+	index := -1
+	for i, node := range t.layers[0] {
+		if bytes.Equal(node, leafBytes) {
+			index = i
+		}
+	}
+	return index
+}
+
+// GetProof restituisce la proof di una foglia (già hashata) come getProof(leaf); come
+// merkletreejs, una proof vuota (non nil) se la foglia non esiste
+func (t *MerkleTreeJS) GetProof(leaf BytesLike) []MerkleTreeJSProofNode {
+	index := t.LeafIndex(leaf)
+	if index < 0 {
+		return []MerkleTreeJSProofNode{}
+	}
+	proof, _ := t.GetProofAt(index)
+	return proof
+}
+
+// GetProofAt restituisce la proof della foglia in posizione `index`, come getProof(leaf, index).
+// Come merkletreejs, con DuplicateOdd il sibling di un nodo accoppiato con se stesso non
+// compare nella proof (tranne che per gli alberi Bitcoin).
+func (t *MerkleTreeJS) GetProofAt(index int) ([]MerkleTreeJSProofNode, error) {
+	if index < 0 || index >= len(t.layers[0]) {
+		return nil, fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(t.layers[0])-1)
+	}
+
+	proof := []MerkleTreeJSProofNode{}
+	for i, layer := range t.layers {
+		if i == 0 && index >= t.leafLimit {
+			// Foglia salita di livello senza sibling (albero Complete)
+			index = t.leafLimit/2 + index - t.leafLimit
+			continue
+		}
+		isRightNode := index%2 == 1
+		pairIndex := index + 1
+		if isRightNode {
+			pairIndex = index - 1
+		} else if t.Options.IsBitcoinTree && index == len(layer)-1 && i < len(t.layers)-1 {
+			pairIndex = index
+		}
+		if pairIndex < len(layer) {
+			position := MerkleTreeJSRight
+			if isRightNode {
+				position = MerkleTreeJSLeft
+			}
+			proof = append(proof, MerkleTreeJSProofNode{Position: position, Data: bytesHex(layer[pairIndex])})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// GetHexProof restituisce la proof di una foglia senza posizioni, come getHexProof
+func (t *MerkleTreeJS) GetHexProof(leaf BytesLike) []HexString {
+	return MerkleTreeJSProofToHex(t.GetProof(leaf))
+}
+
+// Verify verifica una proof come MerkleTree.verify: con SortPairs le posizioni vengono ignorate
+// (tranne che per gli alberi Bitcoin) e ogni posizione diversa da "left" vale come "right".
+// Come in merkletreejs, gli alberi Bitcoin con SortPairs producono proof non verificabili.
+func (t *MerkleTreeJS) Verify(proof []MerkleTreeJSProofNode, leaf BytesLike, root BytesLike) bool {
+	hash, err := ToBytes(leaf)
+	if err != nil || len(hash) == 0 {
+		return false
+	}
+	rootBytes, err := ToBytes(root)
+	if err != nil || len(rootBytes) == 0 {
+		return false
+	}
+
+	for _, node := range proof {
+		data, err := ToBytes(node.Data)
+		if err != nil {
+			return false
+		}
+		sortPairs := t.Options.SortPairs && !t.Options.IsBitcoinTree
+		if node.Position == MerkleTreeJSLeft {
+			hash = t.hashPair(data, hash, sortPairs)
+		} else {
+			hash = t.hashPair(hash, data, sortPairs)
+		}
+	}
+	return bytes.Equal(hash, rootBytes)
+}
+
+// ProofFromHex converte una proof senza posizioni (come quelle di getHexProof o di
+// GetProof per alberi con hash ordinato) nel formato {position, data}, ricavando le
+// posizioni dai livelli dell'albero
+func (t *MerkleTreeJS) ProofFromHex(index int, proof []HexString) ([]MerkleTreeJSProofNode, error) {
+	expected, err := t.GetProofAt(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(proof) != len(expected) {
+		return nil, fmt.Errorf("proof di %d elementi, attesi %d per la foglia %d", len(proof), len(expected), index)
+	}
+	for i, node := range proof {
+		data, err := ToBytes(node)
+		if err != nil {
+			return nil, fmt.Errorf("elemento %d della proof non valido: %w", i, err)
+		}
+		expectedData, _ := ToBytes(expected[i].Data)
+		if !bytes.Equal(data, expectedData) {
+			return nil, fmt.Errorf("l'elemento %d della proof non corrisponde all'albero", i)
+		}
+	}
+	return expected, nil
+}
+
+// MerkleTreeJSProofToHex converte una proof {position, data} in una proof []HexString.
+// Le posizioni vanno perse: il risultato è verificabile solo con hash dei nodi ordinato
+// (SortPairs), ad esempio con ProcessProof e StandardNodeHash quando HashFn è Keccak-256.
+func MerkleTreeJSProofToHex(proof []MerkleTreeJSProofNode) []HexString {
+	hexProof := make([]HexString, len(proof))
+	for i, node := range proof {
+		hexProof[i] = node.Data
+	}
+	return hexProof
+}
+
+// MerkleTreeJSProofFromSorted converte una proof []HexString per alberi con SortPairs nel
+// formato {position, data}, calcolando le posizioni dall'ordine dei nodi lungo il percorso
+func MerkleTreeJSProofFromSorted(leaf BytesLike, proof []HexString, hashFn MerkleTreeJSHashFunc) ([]MerkleTreeJSProofNode, error) {
+	if hashFn == nil {
+		hashFn = MerkleTreeJSSha256
+	}
+	hash, err := ToBytes(leaf)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) == 0 {
+		return nil, errors.New("foglia vuota")
+	}
+
+	nodes := make([]MerkleTreeJSProofNode, len(proof))
+	for i, node := range proof {
+		data, err := ToBytes(node)
+		if err != nil {
+			return nil, fmt.Errorf("elemento %d della proof non valido: %w", i, err)
+		}
+		nodes[i] = MerkleTreeJSProofNode{Position: MerkleTreeJSRight, Data: bytesHex(data)}
+		if bytes.Compare(data, hash) < 0 {
+			nodes[i].Position = MerkleTreeJSLeft
+			hash = hashFn(append(append([]byte{}, data...), hash...))
+		} else {
+			hash = hashFn(append(append([]byte{}, hash...), data...))
+		}
+	}
+	return nodes, nil
+}
+
+// MerkleTreeJSSha256 è la funzione di hash predefinita di merkletreejs
+func MerkleTreeJSSha256(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// MerkleTreeJSKeccak256 è la funzione di hash usata con merkletreejs sugli alberi Ethereum
+func MerkleTreeJSKeccak256(data []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+// reversedBytes restituisce una copia dei byte in ordine inverso
+func reversedBytes(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for i, b := range data {
+		reversed[len(data)-1-i] = b
+	}
+	return reversed
+}
+
+// bytesHex converte byte arbitrari in HexString
+func bytesHex(data []byte) HexString {
+	hex, _ := ToHex(data)
+	return hex
+}
+
+// layerHex converte un livello dell'albero in HexString
+func layerHex(layer [][]byte) []HexString {
+	hexLayer := make([]HexString, len(layer))
+	for i, node := range layer {
+		hexLayer[i] = bytesHex(node)
+	}
+	return hexLayer
+}
@@ -0,0 +1,147 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// merkleTreeJSFixture è un caso di testdata/merkletreejs.json, generato da testdata/merkletreejs.js
+type merkleTreeJSFixture struct {
+	Name    string `json:"name"`
+	Options struct {
+		MerkleTreeJSOptions
+		FillDefaultHash HexString `json:"fillDefaultHash"`
+	} `json:"options"`
+	Leaves           []HexString               `json:"leaves"`
+	Root             HexString                 `json:"root"`
+	Proofs           [][]MerkleTreeJSProofNode `json:"proofs"`
+	Verified         []bool                    `json:"verified"`
+	VerifiedNextLeaf []bool                    `json:"verifiedNextLeaf"`
+}
+
+func loadMerkleTreeJSFixtures(t *testing.T) []merkleTreeJSFixture {
+	t.Helper()
+	data, err := os.ReadFile("testdata/merkletreejs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []merkleTreeJSFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+func TestMerkleTreeJSFixtures(t *testing.T) {
+	for _, fixture := range loadMerkleTreeJSFixtures(t) {
+		t.Run(fixture.Name, func(t *testing.T) {
+			options := fixture.Options.MerkleTreeJSOptions
+			if fixture.Options.FillDefaultHash != "" {
+				fill := mustBytes(t, fixture.Options.FillDefaultHash)
+				options.FillDefaultHash = func(int) []byte { return fill }
+			}
+			leaves := make([]BytesLike, len(fixture.Leaves))
+			for i, leaf := range fixture.Leaves {
+				leaves[i] = leaf
+			}
+			tree, err := NewMerkleTreeJS(leaves, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tree.Root() != fixture.Root {
+				t.Fatalf("root %s, attesa %s", tree.Root(), fixture.Root)
+			}
+			if len(tree.Leaves()) != len(fixture.Proofs) {
+				t.Fatalf("%d foglie, attese %d", len(tree.Leaves()), len(fixture.Proofs))
+			}
+
+			for i, expected := range fixture.Proofs {
+				proof, err := tree.GetProofAt(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(proof) != len(expected) {
+					t.Fatalf("foglia %d: proof di %d elementi, attesi %d", i, len(proof), len(expected))
+				}
+				for j := range proof {
+					if proof[j] != expected[j] {
+						t.Fatalf("foglia %d, elemento %d: %+v, atteso %+v", i, j, proof[j], expected[j])
+					}
+				}
+				// Con DuplicateOdd verify() rifiuta la proof dell'ultima foglia (accoppiata con se stessa, senza sibling)
+				if verified := tree.Verify(proof, tree.Leaves()[i], tree.Root()); verified != fixture.Verified[i] {
+					t.Fatalf("verify della foglia %d: %v, atteso %v", i, verified, fixture.Verified[i])
+				}
+				next := tree.Leaves()[(i+1)%len(fixture.Proofs)]
+				if verified := tree.Verify(proof, next, tree.Root()); verified != fixture.VerifiedNextLeaf[i] {
+					t.Fatalf("verify della foglia %d con la foglia successiva: %v, atteso %v", i, verified, fixture.VerifiedNextLeaf[i])
+				}
+
+				if options.SortPairs && fixture.Verified[i] {
+					converted, err := MerkleTreeJSProofFromSorted(tree.Leaves()[i], MerkleTreeJSProofToHex(proof), nil)
+					if err != nil || !tree.Verify(converted, tree.Leaves()[i], tree.Root()) {
+						t.Fatalf("proof convertita della foglia %d non valida (errore %v)", i, err)
+					}
+				}
+				if fromHex, err := tree.ProofFromHex(i, MerkleTreeJSProofToHex(proof)); err != nil || len(fromHex) != len(proof) {
+					t.Fatalf("ProofFromHex della foglia %d: %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMerkleTreeJSMissingLeaf(t *testing.T) {
+	tree, err := NewMerkleTreeJS([]BytesLike{StandardLeafHash("a"), StandardLeafHash("b")}, MerkleTreeJSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// getProof di merkletreejs restituisce [] per una foglia assente: in JSON deve restare []
+	proof := tree.GetProof(StandardLeafHash("c"))
+	if proof == nil || len(proof) != 0 {
+		t.Fatalf("proof %v, attesa una proof vuota", proof)
+	}
+	if data, _ := json.Marshal(proof); string(data) != "[]" {
+		t.Fatalf("proof serializzata come %s", data)
+	}
+	if hexProof := tree.GetHexProof(StandardLeafHash("c")); hexProof == nil || len(hexProof) != 0 {
+		t.Fatalf("proof esadecimale %v, attesa vuota", hexProof)
+	}
+	if _, err := tree.GetProofAt(2); err == nil {
+		t.Fatal("indice fuori dai limiti accettato")
+	}
+}
+
+func TestMerkleTreeJSKeccakSortPairsMatchesStandardNodeHash(t *testing.T) {
+	values := testValues(6)
+	leaves := make([]BytesLike, len(values))
+	for i, value := range values {
+		leaves[i] = StandardLeafHash(value)
+	}
+	tree, err := NewMerkleTreeJS(leaves, MerkleTreeJSOptions{HashFn: MerkleTreeJSKeccak256, SortPairs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Con Keccak-256 e SortPairs le proof senza posizioni si verificano con StandardNodeHash
+	for i, leaf := range tree.Leaves() {
+		proof, _ := tree.GetProofAt(i)
+		hexProof := MerkleTreeJSProofToHex(proof)
+		bytesProof := make([]BytesLike, len(hexProof))
+		for j, node := range hexProof {
+			bytesProof[j] = node
+		}
+		if root := ProcessProof(leaf, bytesProof, StandardNodeHash); root != tree.Root() {
+			t.Fatalf("foglia %d: root %s, attesa %s", i, root, tree.Root())
+		}
+	}
+}
+
+func TestMerkleTreeJSCompleteRejectsIncompatibleOptions(t *testing.T) {
+	leaves := []BytesLike{StandardLeafHash("a"), StandardLeafHash("b"), StandardLeafHash("c")}
+	for _, options := range []MerkleTreeJSOptions{{Complete: true, DuplicateOdd: true}, {Complete: true, IsBitcoinTree: true}} {
+		if _, err := NewMerkleTreeJS(leaves, options); err == nil {
+			t.Fatalf("opzioni %+v accettate", options)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package merkletree
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile non è supportato su questa piattaforma: FileNodeStore usa ReadAt
+func mmapFile(file *os.File, size int) ([]byte, error) {
+	return nil, errors.New("mmap non supportato")
+}
+
+// munmapFile non fa nulla su questa piattaforma
+func munmapFile(data []byte) error {
+	return nil
+}
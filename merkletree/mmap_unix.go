@@ -0,0 +1,19 @@
+//go:build unix
+
+package merkletree
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapFile mappa in memoria, in sola lettura, i primi `size` byte del file
+func mmapFile(file *os.File, size int) ([]byte, error) {
+	return unix.Mmap(int(file.Fd()), 0, size, unix.PROT_READ, unix.MAP_SHARED)
+}
+
+// munmapFile rilascia una mappatura creata da mmapFile
+func munmapFile(data []byte) error {
+	return unix.Munmap(data)
+}
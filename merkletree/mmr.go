@@ -0,0 +1,458 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// MerkleMountainRange è un accumulatore append-only basato su Merkle Mountain Range.
+// Le posizioni dei nodi seguono la numerazione post-order (0-based) usata da Grin e
+// da Polkadot (ckb-merkle-mountain-range), così le root sono interoperabili.
+type MerkleMountainRange struct {
+	Nodes    []HexString // Tutti i nodi, indicizzati per posizione MMR
+	NodeHash NodeHash
+	leaves   int
+}
+
+// MMRProof rappresenta una proof di inclusione di una foglia in un MMR di dimensione MMRSize
+type MMRProof struct {
+	MMRSize  int         `json:"mmrSize"`
+	LeafPos  int         `json:"leafPos"`
+	Siblings []HexString `json:"siblings"` // Fratelli dalla foglia fino al picco che la contiene
+	Peaks    []HexString `json:"peaks"`    // Gli altri picchi, da sinistra a destra
+}
+
+// MMRConsistencyProof dimostra che un MMR di dimensione NewSize estende quello di dimensione OldSize
+type MMRConsistencyProof struct {
+	OldSize    int           `json:"oldSize"`
+	NewSize    int           `json:"newSize"`
+	OldPeaks   []HexString   `json:"oldPeaks"`
+	Paths      [][]HexString `json:"paths"`      // Per ogni vecchio picco, i fratelli fino al nuovo picco
+	ExtraPeaks []HexString   `json:"extraPeaks"` // Nuovi picchi che non contengono vecchi picchi
+}
+
+// NewMerkleMountainRange crea un MMR vuoto con la funzione di hash indicata. Con nodeHash nil
+// usa OrderedNodeHash (keccak256(left || right)), come Grin e ckb: una funzione che ordina i
+// figli, come StandardNodeHash, non vincolerebbe le posizioni alla root.
+func NewMerkleMountainRange(nodeHash NodeHash) *MerkleMountainRange {
+	if nodeHash == nil {
+		nodeHash = OrderedNodeHash
+	}
+	return &MerkleMountainRange{NodeHash: nodeHash}
+}
+
+// Size restituisce il numero di nodi dell'MMR
+func (m *MerkleMountainRange) Size() int {
+	return len(m.Nodes)
+}
+
+// LeafCount restituisce il numero di foglie inserite
+func (m *MerkleMountainRange) LeafCount() int {
+	return m.leaves
+}
+
+// Append aggiunge l'hash di una foglia e restituisce la sua posizione. Costo O(log n).
+func (m *MerkleMountainRange) Append(leaf BytesLike) int {
+	CheckValidMerkleNode(leaf)
+	leafHex, err := ToHex(leaf)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: foglia non valida: %v", err))
+	}
+
+	leafPos := len(m.Nodes)
+	m.Nodes = append(m.Nodes, leafHex)
+
+	// Finché la prossima posizione è più alta, è un genitore da calcolare
+	height := 0
+	pos := leafPos
+	for MMRPosHeight(pos+1) > height {
+		pos++
+		left := pos - mmrParentOffset(height)
+		right := left + mmrSiblingOffset(height)
+		m.Nodes = append(m.Nodes, m.NodeHash(m.Nodes[left], m.Nodes[right]))
+		height++
+	}
+	m.leaves++
+
+	return leafPos
+}
+
+// Peaks restituisce gli hash dei picchi dell'MMR corrente
+func (m *MerkleMountainRange) Peaks() []HexString {
+	peaks, err := m.peaksAt(len(m.Nodes))
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	return peaks
+}
+
+// Root restituisce la root dell'MMR corrente
+func (m *MerkleMountainRange) Root() HexString {
+	root, err := m.RootAt(len(m.Nodes))
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	return root
+}
+
+// RootAt restituisce la root dell'MMR come era quando aveva `size` nodi
+func (m *MerkleMountainRange) RootAt(size int) (HexString, error) {
+	peaks, err := m.peaksAt(size)
+	if err != nil {
+		return "", err
+	}
+	if len(peaks) == 0 {
+		return "", errors.New("impossibile calcolare la root di un MMR vuoto")
+	}
+	return BagMMRPeaks(peaks, m.NodeHash), nil
+}
+
+// GetProof genera la proof di inclusione della foglia in posizione `leafPos`
+// rispetto all'MMR storico di dimensione `size`
+func (m *MerkleMountainRange) GetProof(leafPos int, size int) (MMRProof, error) {
+	if err := m.checkSize(size); err != nil {
+		return MMRProof{}, err
+	}
+	if leafPos < 0 || leafPos >= size || MMRPosHeight(leafPos) != 0 {
+		return MMRProof{}, fmt.Errorf("posizione %d non è una foglia di un MMR di dimensione %d", leafPos, size)
+	}
+
+	peakPositions := MMRPeakPositions(size)
+	peakIndex := mmrPeakIndex(peakPositions, leafPos)
+
+	// Risaliamo dalla foglia fino al picco che la contiene
+	var siblings []HexString
+	pos, height := leafPos, 0
+	for pos < peakPositions[peakIndex] {
+		sibling, parent := mmrSiblingAndParent(pos, height)
+		siblings = append(siblings, m.Nodes[sibling])
+		pos = parent
+		height++
+	}
+
+	var peaks []HexString
+	for i, peakPos := range peakPositions {
+		if i != peakIndex {
+			peaks = append(peaks, m.Nodes[peakPos])
+		}
+	}
+
+	return MMRProof{
+		MMRSize:  size,
+		LeafPos:  leafPos,
+		Siblings: siblings,
+		Peaks:    peaks,
+	}, nil
+}
+
+// VerifyMMRProof verifica una proof di inclusione rispetto alla root attesa
+func VerifyMMRProof(root BytesLike, leaf BytesLike, proof MMRProof, nodeHash NodeHash) bool {
+	if nodeHash == nil {
+		nodeHash = OrderedNodeHash
+	}
+	if !IsValidMMRSize(proof.MMRSize) || proof.LeafPos < 0 || proof.LeafPos >= proof.MMRSize || MMRPosHeight(proof.LeafPos) != 0 {
+		return false
+	}
+	if !IsValidMerkleNode(leaf) {
+		return false
+	}
+
+	node, err := ToHex(leaf)
+	if err != nil {
+		return false
+	}
+	pos, height := proof.LeafPos, 0
+	siblings := proof.Siblings
+	peakPositions := MMRPeakPositions(proof.MMRSize)
+
+	peakIndex := mmrPeakIndex(peakPositions, proof.LeafPos)
+	for pos < peakPositions[peakIndex] {
+		if len(siblings) == 0 {
+			return false
+		}
+		sibling, parent := mmrSiblingAndParent(pos, height)
+		if sibling < pos {
+			node = nodeHash(siblings[0], node)
+		} else {
+			node = nodeHash(node, siblings[0])
+		}
+		siblings = siblings[1:]
+		pos = parent
+		height++
+	}
+	if len(siblings) != 0 || len(proof.Peaks) != len(peakPositions)-1 {
+		return false
+	}
+
+	peaks := make([]HexString, 0, len(peakPositions))
+	peaks = append(peaks, proof.Peaks[:peakIndex]...)
+	peaks = append(peaks, node)
+	peaks = append(peaks, proof.Peaks[peakIndex:]...)
+
+	rootHex, err := ToHex(root)
+	if err != nil {
+		return false
+	}
+	return BagMMRPeaks(peaks, nodeHash) == rootHex
+}
+
+// GetConsistencyProof dimostra che l'MMR di dimensione `newSize` estende quello di dimensione `oldSize`
+func (m *MerkleMountainRange) GetConsistencyProof(oldSize int, newSize int) (MMRConsistencyProof, error) {
+	if err := m.checkSize(oldSize); err != nil {
+		return MMRConsistencyProof{}, err
+	}
+	if err := m.checkSize(newSize); err != nil {
+		return MMRConsistencyProof{}, err
+	}
+	if oldSize == 0 || oldSize > newSize {
+		return MMRConsistencyProof{}, fmt.Errorf("dimensioni non valide: %d -> %d", oldSize, newSize)
+	}
+
+	newPeaks := MMRPeakPositions(newSize)
+	reached := make(map[int]bool)
+	proof := MMRConsistencyProof{OldSize: oldSize, NewSize: newSize}
+	for _, peakPos := range MMRPeakPositions(oldSize) {
+		proof.OldPeaks = append(proof.OldPeaks, m.Nodes[peakPos])
+
+		var path []HexString
+		pos, height := peakPos, MMRPosHeight(peakPos)
+		for !containsInt(newPeaks, pos) {
+			sibling, parent := mmrSiblingAndParent(pos, height)
+			path = append(path, m.Nodes[sibling])
+			pos = parent
+			height++
+		}
+		reached[pos] = true
+		proof.Paths = append(proof.Paths, path)
+	}
+	for _, peakPos := range newPeaks {
+		if !reached[peakPos] {
+			proof.ExtraPeaks = append(proof.ExtraPeaks, m.Nodes[peakPos])
+		}
+	}
+
+	return proof, nil
+}
+
+// VerifyMMRConsistency verifica che `newRoot` sia un'estensione append-only di `oldRoot`
+func VerifyMMRConsistency(oldRoot BytesLike, newRoot BytesLike, proof MMRConsistencyProof, nodeHash NodeHash) bool {
+	if nodeHash == nil {
+		nodeHash = OrderedNodeHash
+	}
+	if !IsValidMMRSize(proof.OldSize) || !IsValidMMRSize(proof.NewSize) || proof.OldSize == 0 || proof.OldSize > proof.NewSize {
+		return false
+	}
+	oldPeaks := MMRPeakPositions(proof.OldSize)
+	if len(proof.OldPeaks) != len(oldPeaks) || len(proof.Paths) != len(oldPeaks) {
+		return false
+	}
+
+	oldRootHex, err := ToHex(oldRoot)
+	if err != nil || BagMMRPeaks(proof.OldPeaks, nodeHash) != oldRootHex {
+		return false
+	}
+
+	// Ogni vecchio picco deve risalire fino a un nuovo picco, con risultati coerenti
+	newPeaks := MMRPeakPositions(proof.NewSize)
+	computed := make(map[int]HexString)
+	for i, peakPos := range oldPeaks {
+		node := proof.OldPeaks[i]
+		path := proof.Paths[i]
+		pos, height := peakPos, MMRPosHeight(peakPos)
+		for !containsInt(newPeaks, pos) {
+			if len(path) == 0 {
+				return false
+			}
+			sibling, parent := mmrSiblingAndParent(pos, height)
+			if sibling < pos {
+				node = nodeHash(path[0], node)
+			} else {
+				node = nodeHash(node, path[0])
+			}
+			path = path[1:]
+			pos = parent
+			height++
+		}
+		if len(path) != 0 {
+			return false
+		}
+		if previous, found := computed[pos]; found && previous != node {
+			return false
+		}
+		computed[pos] = node
+	}
+
+	extra := proof.ExtraPeaks
+	peaks := make([]HexString, 0, len(newPeaks))
+	for _, peakPos := range newPeaks {
+		if node, found := computed[peakPos]; found {
+			peaks = append(peaks, node)
+			continue
+		}
+		if len(extra) == 0 {
+			return false
+		}
+		peaks = append(peaks, extra[0])
+		extra = extra[1:]
+	}
+	if len(extra) != 0 {
+		return false
+	}
+
+	newRootHex, err := ToHex(newRoot)
+	if err != nil {
+		return false
+	}
+	return BagMMRPeaks(peaks, nodeHash) == newRootHex
+}
+
+// BagMMRPeaks combina i picchi da destra verso sinistra, come in ckb-merkle-mountain-range
+func BagMMRPeaks(peaks []HexString, nodeHash NodeHash) HexString {
+	if len(peaks) == 0 {
+		panic("❌ ERRORE: impossibile combinare 0 picchi")
+	}
+	bagged := peaks[len(peaks)-1]
+	for i := len(peaks) - 2; i >= 0; i-- {
+		bagged = nodeHash(bagged, peaks[i])
+	}
+	return bagged
+}
+
+// peaksAt restituisce gli hash dei picchi per un MMR storico di dimensione `size`
+func (m *MerkleMountainRange) peaksAt(size int) ([]HexString, error) {
+	if err := m.checkSize(size); err != nil {
+		return nil, err
+	}
+	positions := MMRPeakPositions(size)
+	peaks := make([]HexString, len(positions))
+	for i, pos := range positions {
+		peaks[i] = m.Nodes[pos]
+	}
+	return peaks, nil
+}
+
+// checkSize verifica che `size` sia una dimensione storica valida di questo MMR
+func (m *MerkleMountainRange) checkSize(size int) error {
+	if size < 0 || size > len(m.Nodes) {
+		return fmt.Errorf("dimensione MMR %d fuori dai limiti (max %d)", size, len(m.Nodes))
+	}
+	if !IsValidMMRSize(size) {
+		return fmt.Errorf("dimensione MMR %d non valida", size)
+	}
+	return nil
+}
+
+// Funzioni di supporto per la numerazione delle posizioni MMR
+
+// MMRPosHeight restituisce l'altezza del nodo in posizione `pos` (0 per le foglie)
+func MMRPosHeight(pos int) int {
+	p := uint64(pos) + 1
+	for !mmrAllOnes(p) {
+		p = mmrJumpLeft(p)
+	}
+	return bits.Len64(p) - 1
+}
+
+// MMRLeafIndexToPos restituisce la posizione MMR della foglia con indice `index`
+func MMRLeafIndexToPos(index int) int {
+	return MMRLeafIndexToSize(index) - bits.TrailingZeros64(uint64(index)+1) - 1
+}
+
+// MMRLeafIndexToSize restituisce la dimensione dell'MMR subito dopo l'inserimento della foglia `index`
+func MMRLeafIndexToSize(index int) int {
+	leaves := uint64(index) + 1
+	return int(2*leaves) - bits.OnesCount64(leaves)
+}
+
+// IsValidMMRSize verifica che `size` corrisponda a un MMR ben formato
+func IsValidMMRSize(size int) bool {
+	if size < 0 {
+		return false
+	}
+	remaining := size
+	for height := 62; height >= 0 && remaining > 0; height-- {
+		peakSize := (1 << (height + 1)) - 1
+		if peakSize <= remaining {
+			remaining -= peakSize
+		}
+	}
+	return remaining == 0
+}
+
+// MMRPeakPositions restituisce le posizioni dei picchi, da sinistra a destra
+func MMRPeakPositions(size int) []int {
+	if size == 0 {
+		return nil
+	}
+
+	// Picco più a sinistra: il più alto che sta nella dimensione
+	height, pos := 0, 0
+	for next := mmrPeakPosByHeight(1); next < size; next = mmrPeakPosByHeight(height + 1) {
+		height++
+		pos = next
+	}
+	peaks := []int{pos}
+
+	for height > 0 {
+		pos += mmrSiblingOffset(height)
+		for pos > size-1 {
+			if height == 0 {
+				return peaks
+			}
+			pos -= mmrParentOffset(height - 1)
+			height--
+		}
+		peaks = append(peaks, pos)
+	}
+	return peaks
+}
+
+// mmrPeakIndex restituisce l'indice del picco che contiene la posizione `pos`
+func mmrPeakIndex(peakPositions []int, pos int) int {
+	for i, peakPos := range peakPositions {
+		if peakPos >= pos {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("❌ ERRORE: posizione %d fuori dall'MMR", pos))
+}
+
+// mmrSiblingAndParent restituisce fratello e genitore del nodo `pos` di altezza `height`
+func mmrSiblingAndParent(pos int, height int) (int, int) {
+	if MMRPosHeight(pos+1) > height {
+		// `pos` è un figlio destro
+		return pos - mmrSiblingOffset(height), pos + 1
+	}
+	return pos + mmrSiblingOffset(height), pos + mmrParentOffset(height)
+}
+
+func mmrPeakPosByHeight(height int) int {
+	return (1 << (height + 1)) - 2
+}
+
+func mmrParentOffset(height int) int {
+	return 2 << height
+}
+
+func mmrSiblingOffset(height int) int {
+	return (2 << height) - 1
+}
+
+func mmrAllOnes(n uint64) bool {
+	return n != 0 && bits.OnesCount64(n) == bits.Len64(n)
+}
+
+func mmrJumpLeft(pos uint64) uint64 {
+	mostSignificantBit := uint64(1) << (bits.Len64(pos) - 1)
+	return pos - (mostSignificantBit - 1)
+}
+
+func containsInt(values []int, target int) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
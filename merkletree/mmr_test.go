@@ -0,0 +1,144 @@
+package merkletree
+
+import "testing"
+
+// mmrTestLeaf restituisce la foglia i dei vettori: keccak256(uint8 i)
+func mmrTestLeaf(i int) HexString {
+	return keccak256Hex([]byte{byte(i)})
+}
+
+// Root attese dopo n foglie, calcolate con un MMR di riferimento indipendente (pila di picchi,
+// merge keccak256(left || right), picchi combinati da destra come ckb-merkle-mountain-range)
+var mmrRootVectors = []struct {
+	leaves int
+	root   HexString
+}{
+	{1, "0xbc36789e7a1e281436464229828f817d6612f7b477d66591ff96a9e064bcc98a"},
+	{2, "0x57d772147cdf27f5f67d679f0f3a513f8b87622ce598a3cf0b048ab178ddfc6e"},
+	{3, "0x164ecbbf4da0661d9451952045fa2bc06e6393c406ebd682156dd735ec0c83f6"},
+	{4, "0xdd5115b5dcca3db0bffa31064a0d21f21362cd02e1263e47d69e38bbeec1d359"},
+	{5, "0x08b730432337897ddf71c364af857b7611dda5c8c378a783c4ac3679807281a8"},
+	{6, "0xbcfdb9e8437712658d95860bbc5af4f6d988f37d5d4ecb931f620770359f45ed"},
+	{7, "0x4dc9f223ea6f2cb405111c4d226e1bf46eff97c52427e576942571aa306be51b"},
+	{8, "0x791521f02a712f28265f5200914f9772b133bc2692260f8c8f426e176b1713ed"},
+	{9, "0x45ce97a0eedb021faf08e277797fcc3a22852bda38a1ae8f955ddeef141cc62f"},
+	{10, "0x9abc2c4e13fba8e597b0abf4e7b66c721e95215ba6c3acfcf7985e221c30dd0c"},
+	{11, "0x59d5dfabff33ea4520e961149dda2e67ba134bc1fef4f5b50201293627c921c1"},
+	{12, "0x96a7fd56360c112fd98842ac9aa3b1f19f72e6fe42a418c14726a6a0ddbd9e69"},
+	{13, "0xdc5291da509d02d093454c77ed62bd8157b009d1a3643ad3709b2dba8fe01da6"},
+	{14, "0x5ac002836bc97da523e9dae6d47e4d586674fa9b5914842d38a2e40d6534fef5"},
+	{15, "0x7b3914d11d0e865a09d32553e1195b5017cc719f1988d81020316f05070970e0"},
+	{16, "0x697bead87db24f50e7e851c6d364c121829786ebd8b1bea2811fa47a6a3716d8"},
+	{17, "0x88405d46ad411ff2e860825c8fb29a89e0b5b2d0a8d34c7895f801bf7aac2bff"},
+	{18, "0x39a8de2a1f06135fd43fc7e1bf79cbb59f268cbaa954790a48f47b13e567a5cd"},
+	{19, "0x276166b8c85884fe7a266aeb919d0fc3f06716472952dcd393903274e40aab48"},
+	{20, "0x52c4554e99519aef57f49de4e5325c1702fe5c7fe7099ffa925acbb3c09acf66"},
+}
+
+func TestMMRRootVectors(t *testing.T) {
+	mmr := NewMerkleMountainRange(nil)
+	for _, vector := range mmrRootVectors {
+		for mmr.LeafCount() < vector.leaves {
+			mmr.Append(mmrTestLeaf(mmr.LeafCount()))
+		}
+		if root := mmr.Root(); root != vector.root {
+			t.Errorf("%d foglie: root %s, attesa %s", vector.leaves, root, vector.root)
+		}
+	}
+}
+
+func TestMMRPositions(t *testing.T) {
+	heights := []int{0, 0, 1, 0, 0, 1, 2, 0, 0, 1, 0, 0, 1, 2, 3, 0, 0, 1, 0}
+	for pos, height := range heights {
+		if got := MMRPosHeight(pos); got != height {
+			t.Errorf("MMRPosHeight(%d) = %d, atteso %d", pos, got, height)
+		}
+	}
+
+	leafPositions := []int{0, 1, 3, 4, 7, 8, 10, 11, 15, 16, 18}
+	for index, pos := range leafPositions {
+		if got := MMRLeafIndexToPos(index); got != pos {
+			t.Errorf("MMRLeafIndexToPos(%d) = %d, atteso %d", index, got, pos)
+		}
+	}
+	sizes := []int{1, 3, 4, 7, 8, 10, 11, 15, 16, 18, 19}
+	for index, size := range sizes {
+		if got := MMRLeafIndexToSize(index); got != size {
+			t.Errorf("MMRLeafIndexToSize(%d) = %d, atteso %d", index, got, size)
+		}
+	}
+
+	valid := map[int]bool{0: true, 1: true, 3: true, 4: true, 7: true, 8: true, 10: true, 11: true, 15: true, 16: true, 18: true, 19: true}
+	for size := 0; size < 20; size++ {
+		if IsValidMMRSize(size) != valid[size] {
+			t.Errorf("IsValidMMRSize(%d) = %v", size, !valid[size])
+		}
+	}
+
+	peaks := map[int][]int{1: {0}, 3: {2}, 4: {2, 3}, 11: {6, 9, 10}, 19: {14, 17, 18}, 22: {14, 21}}
+	for size, expected := range peaks {
+		got := MMRPeakPositions(size)
+		if len(got) != len(expected) {
+			t.Errorf("MMRPeakPositions(%d) = %v, atteso %v", size, got, expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Errorf("MMRPeakPositions(%d) = %v, atteso %v", size, got, expected)
+			}
+		}
+	}
+}
+
+func TestMMRProofBindsPosition(t *testing.T) {
+	mmr := NewMerkleMountainRange(nil)
+	var positions []int
+	for i := 0; i < 11; i++ {
+		positions = append(positions, mmr.Append(mmrTestLeaf(i)))
+	}
+	root := mmr.Root()
+	for i, pos := range positions {
+		proof, err := mmr.GetProof(pos, mmr.Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyMMRProof(root, mmrTestLeaf(i), proof, nil) {
+			t.Fatalf("proof della foglia %d non valida", i)
+		}
+	}
+
+	// Con una funzione ordinata la foglia 0 non può essere spacciata per la foglia in posizione 1
+	proof, _ := mmr.GetProof(positions[0], mmr.Size())
+	proof.LeafPos = positions[1]
+	if VerifyMMRProof(root, mmrTestLeaf(0), proof, nil) {
+		t.Fatal("proof accettata con la posizione scambiata")
+	}
+}
+
+func TestMMRConsistency(t *testing.T) {
+	mmr := NewMerkleMountainRange(nil)
+	var sizes []int
+	for i := 0; i < 20; i++ {
+		mmr.Append(mmrTestLeaf(i))
+		sizes = append(sizes, mmr.Size())
+	}
+	for _, oldSize := range sizes {
+		for _, newSize := range sizes {
+			if oldSize > newSize {
+				continue
+			}
+			proof, err := mmr.GetConsistencyProof(oldSize, newSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			oldRoot, _ := mmr.RootAt(oldSize)
+			newRoot, _ := mmr.RootAt(newSize)
+			if !VerifyMMRConsistency(oldRoot, newRoot, proof, nil) {
+				t.Fatalf("consistenza %d -> %d non valida", oldSize, newSize)
+			}
+			if oldSize != newSize && VerifyMMRConsistency(newRoot, oldRoot, proof, nil) {
+				t.Fatalf("consistenza %d -> %d accettata con le root invertite", oldSize, newSize)
+			}
+		}
+	}
+}
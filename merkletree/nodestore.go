@@ -0,0 +1,445 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// NodeStore astrae la memorizzazione dei nodi dell'albero, indicizzati come in MakeMerkleTree
+type NodeStore interface {
+	Get(index int) (HexString, error)
+	Put(index int, node HexString) error
+	Len() int
+	Close() error
+}
+
+// MemoryNodeStore memorizza i nodi in uno slice in memoria
+type MemoryNodeStore struct {
+	Nodes []HexString
+}
+
+// NewMemoryNodeStore crea uno store in memoria a partire dai nodi dati
+func NewMemoryNodeStore(nodes []HexString) *MemoryNodeStore {
+	return &MemoryNodeStore{Nodes: nodes}
+}
+
+// Get restituisce il nodo con indice `index`
+func (s *MemoryNodeStore) Get(index int) (HexString, error) {
+	if index < 0 || index >= len(s.Nodes) {
+		return "", nodeIndexError(index, len(s.Nodes))
+	}
+	return s.Nodes[index], nil
+}
+
+// Put scrive il nodo con indice `index`; l'indice Len() aggiunge un nodo in coda
+func (s *MemoryNodeStore) Put(index int, node HexString) error {
+	switch {
+	case index >= 0 && index < len(s.Nodes):
+		s.Nodes[index] = node
+	case index == len(s.Nodes):
+		s.Nodes = append(s.Nodes, node)
+	default:
+		return nodeIndexError(index, len(s.Nodes))
+	}
+	return nil
+}
+
+// Len restituisce il numero di nodi
+func (s *MemoryNodeStore) Len() int {
+	return len(s.Nodes)
+}
+
+// Close non fa nulla per lo store in memoria
+func (s *MemoryNodeStore) Close() error {
+	return nil
+}
+
+// FileNodeStore memorizza i nodi in un file di record da 32 byte (il formato scritto da
+// BuildMerkleTreeStream). Le letture usano mmap dove disponibile. Le aggiunte in coda fanno
+// crescere il file a blocchi: la mappatura viene rifatta solo quando la capacità si esaurisce,
+// e Close riporta il file alla dimensione esatta dei nodi scritti.
+type FileNodeStore struct {
+	mu       sync.RWMutex
+	file     *os.File
+	mapped   []byte // Contenuto mappato in memoria, nil se mmap non è disponibile
+	count    int
+	capacity int // Nodi che il file può contenere senza crescere; oltre count è preallocato
+}
+
+// minFileNodeStoreGrowth è il numero minimo di nodi di cui cresce il file di un FileNodeStore
+const minFileNodeStoreGrowth = 1024
+
+// CreateFileNodeStore crea (o svuota) un file di nodi scrivibile
+func CreateFileNodeStore(path string) (*FileNodeStore, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileNodeStore{file: file}, nil
+}
+
+// OpenFileNodeStore apre un file di nodi esistente; se `writable` è false lo apre in sola lettura
+func OpenFileNodeStore(path string, writable bool) (*FileNodeStore, error) {
+	flag := os.O_RDONLY
+	if writable {
+		flag = os.O_RDWR
+	}
+	file, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size()%NodeSize != 0 {
+		file.Close()
+		return nil, fmt.Errorf("dimensione del file %d non multipla di %d", info.Size(), NodeSize)
+	}
+	count := int(info.Size() / NodeSize)
+	store := &FileNodeStore{file: file, count: count}
+	if err := store.remap(count); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Get restituisce il nodo con indice `index`
+func (s *FileNodeStore) Get(index int) (HexString, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if index < 0 || index >= s.count {
+		return "", nodeIndexError(index, s.count)
+	}
+	if s.mapped != nil {
+		return ToHex(s.mapped[index*NodeSize : (index+1)*NodeSize])
+	}
+	var buf [NodeSize]byte
+	if _, err := s.file.ReadAt(buf[:], int64(index)*NodeSize); err != nil {
+		return "", err
+	}
+	return ToHex(buf[:])
+}
+
+// Put scrive il nodo con indice `index`; l'indice Len() aggiunge un nodo in coda
+func (s *FileNodeStore) Put(index int, node HexString) error {
+	nodeBytes, err := nodeRecord(node)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 || index > s.count {
+		return nodeIndexError(index, s.count)
+	}
+	if index == s.capacity {
+		if err := s.grow(max(2*s.capacity, minFileNodeStoreGrowth)); err != nil {
+			return err
+		}
+	}
+	// La mappatura è condivisa: la scrittura è subito visibile alle letture
+	if _, err := s.file.WriteAt(nodeBytes, int64(index)*NodeSize); err != nil {
+		return err
+	}
+	if index == s.count {
+		s.count++
+	}
+	return nil
+}
+
+// Grow prealloca il file per almeno `count` nodi, così una serie di aggiunte in coda non
+// deve ridimensionarlo né rimapparlo
+func (s *FileNodeStore) Grow(count int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if count <= s.capacity {
+		return nil
+	}
+	return s.grow(count)
+}
+
+// Len restituisce il numero di nodi
+func (s *FileNodeStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.count
+}
+
+// Close rilascia la mappatura, rimuove lo spazio preallocato e chiude il file
+func (s *FileNodeStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	if s.mapped != nil {
+		errs = append(errs, munmapFile(s.mapped))
+		s.mapped = nil
+	}
+	if s.capacity > s.count {
+		errs = append(errs, s.file.Truncate(int64(s.count)*NodeSize))
+		s.capacity = s.count
+	}
+	errs = append(errs, s.file.Close())
+	return errors.Join(errs...)
+}
+
+// grow estende il file a `capacity` nodi e lo rimappa
+func (s *FileNodeStore) grow(capacity int) error {
+	if err := s.file.Truncate(int64(capacity) * NodeSize); err != nil {
+		return err
+	}
+	return s.remap(capacity)
+}
+
+// remap sostituisce la mappatura con una che copre `capacity` nodi
+func (s *FileNodeStore) remap(capacity int) error {
+	if s.mapped != nil {
+		if err := munmapFile(s.mapped); err != nil {
+			return err
+		}
+		s.mapped = nil
+	}
+	s.capacity = capacity
+	if capacity > 0 {
+		// Se mmap non è disponibile si ripiega su ReadAt
+		if mapped, err := mmapFile(s.file, capacity*NodeSize); err == nil {
+			s.mapped = mapped
+		}
+	}
+	return nil
+}
+
+// DefaultShardSize è il numero di nodi per file in uno ShardedNodeStore
+const DefaultShardSize = 1 << 20
+
+// shardedStoreMeta è il file di metadati di uno ShardedNodeStore
+type shardedStoreMeta struct {
+	ShardSize int `json:"shardSize"`
+	NodeSize  int `json:"nodeSize"`
+}
+
+// ShardedNodeStore distribuisce i nodi su più file da ShardSize record ciascuno
+type ShardedNodeStore struct {
+	mu        sync.RWMutex
+	dir       string
+	shardSize int
+	shards    []*os.File
+	count     int
+}
+
+// CreateShardedNodeStore crea uno store suddiviso in shard nella directory indicata
+func CreateShardedNodeStore(dir string, shardSize int) (*ShardedNodeStore, error) {
+	if shardSize <= 0 {
+		shardSize = DefaultShardSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	meta, err := json.Marshal(shardedStoreMeta{ShardSize: shardSize, NodeSize: NodeSize})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "meta.json"), meta, 0o644); err != nil {
+		return nil, err
+	}
+	return &ShardedNodeStore{dir: dir, shardSize: shardSize}, nil
+}
+
+// OpenShardedNodeStore apre uno store suddiviso in shard esistente
+func OpenShardedNodeStore(dir string) (*ShardedNodeStore, error) {
+	metaBytes, err := os.ReadFile(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		return nil, err
+	}
+	var meta shardedStoreMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, err
+	}
+	if meta.NodeSize != NodeSize || meta.ShardSize <= 0 {
+		return nil, fmt.Errorf("metadati dello store non validi: %+v", meta)
+	}
+
+	store := &ShardedNodeStore{dir: dir, shardSize: meta.ShardSize}
+	for {
+		file, err := os.OpenFile(store.shardPath(len(store.shards)), os.O_RDWR, 0)
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			store.Close()
+			return nil, err
+		}
+		store.shards = append(store.shards, file)
+		store.count += int(info.Size() / NodeSize)
+	}
+	return store, nil
+}
+
+// Get restituisce il nodo con indice `index`
+func (s *ShardedNodeStore) Get(index int) (HexString, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if index < 0 || index >= s.count {
+		return "", nodeIndexError(index, s.count)
+	}
+	var buf [NodeSize]byte
+	shard, offset := index/s.shardSize, index%s.shardSize
+	if _, err := s.shards[shard].ReadAt(buf[:], int64(offset)*NodeSize); err != nil {
+		return "", err
+	}
+	return ToHex(buf[:])
+}
+
+// Put scrive il nodo con indice `index`; l'indice Len() aggiunge un nodo in coda
+func (s *ShardedNodeStore) Put(index int, node HexString) error {
+	nodeBytes, err := nodeRecord(node)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 || index > s.count {
+		return nodeIndexError(index, s.count)
+	}
+	shard, offset := index/s.shardSize, index%s.shardSize
+	if shard == len(s.shards) {
+		file, err := os.OpenFile(s.shardPath(shard), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return err
+		}
+		s.shards = append(s.shards, file)
+	}
+	if _, err := s.shards[shard].WriteAt(nodeBytes, int64(offset)*NodeSize); err != nil {
+		return err
+	}
+	if index == s.count {
+		s.count++
+	}
+	return nil
+}
+
+// Len restituisce il numero di nodi
+func (s *ShardedNodeStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.count
+}
+
+// Close chiude tutti i file degli shard
+func (s *ShardedNodeStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, shard := range s.shards {
+		errs = append(errs, shard.Close())
+	}
+	s.shards = nil
+	return errors.Join(errs...)
+}
+
+func (s *ShardedNodeStore) shardPath(shard int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("shard-%06d.bin", shard))
+}
+
+// CopyNodes copia tutti i nodi di `src` in coda a `dst`; se `dst` lo consente (come
+// FileNodeStore) lo spazio viene preallocato una sola volta
+func CopyNodes(dst NodeStore, src NodeStore) error {
+	offset := dst.Len()
+	if grower, ok := dst.(interface{ Grow(count int) error }); ok {
+		if err := grower.Grow(offset + src.Len()); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < src.Len(); i++ {
+		node, err := src.Get(i)
+		if err != nil {
+			return err
+		}
+		if err := dst.Put(offset+i, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProofFromStore genera la proof di Merkle per la foglia con indice `index` nello store
+func GetProofFromStore(store NodeStore, index int) ([]HexString, error) {
+	if index < 0 || index >= store.Len() || LeftChildIndex(index) < store.Len() {
+		return nil, fmt.Errorf("l'indice %d non è una foglia", index)
+	}
+	var proof []HexString
+	for index > 0 {
+		sibling, err := store.Get(SiblingIndex(index))
+		if err != nil {
+			return nil, err
+		}
+		proof = append(proof, sibling)
+		index = ParentIndex(index)
+	}
+	return proof, nil
+}
+
+// IsValidNodeStore verifica che ogni nodo interno dello store sia l'hash dei suoi figli
+func IsValidNodeStore(store NodeStore, nodeHash NodeHash) (bool, error) {
+	if nodeHash == nil {
+		nodeHash = StandardNodeHash
+	}
+	if store.Len() == 0 {
+		return false, nil
+	}
+	for i := 0; RightChildIndex(i) < store.Len(); i++ {
+		node, err := store.Get(i)
+		if err != nil {
+			return false, err
+		}
+		left, err := store.Get(LeftChildIndex(i))
+		if err != nil {
+			return false, err
+		}
+		right, err := store.Get(RightChildIndex(i))
+		if err != nil {
+			return false, err
+		}
+		if nodeHash(left, right) != node {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// nodeRecord converte un nodo nel record da 32 byte scritto su disco
+func nodeRecord(node HexString) ([]byte, error) {
+	nodeBytes, err := ToBytes(node)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodeBytes) != NodeSize {
+		return nil, fmt.Errorf("nodo di %d byte, attesi %d", len(nodeBytes), NodeSize)
+	}
+	return nodeBytes, nil
+}
+
+func nodeIndexError(index int, count int) error {
+	return fmt.Errorf("indice del nodo %d fuori dai limiti (nodi: %d)", index, count)
+}
@@ -0,0 +1,140 @@
+package merkletree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testNodeStoreTree(t *testing.T, n int) []HexString {
+	t.Helper()
+	tree, _, err := PrepareMerkleTreeContext(context.Background(), testValues(n), DefaultOptions, StandardLeafHash[string], nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestFileNodeStoreGrowsInChunks(t *testing.T) {
+	tree := testNodeStoreTree(t, 3000)
+	path := filepath.Join(t.TempDir(), "tree.bin")
+	store, err := CreateFileNodeStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	growths, capacity := 0, 0
+	for i, node := range tree {
+		if err := store.Put(i, node); err != nil {
+			t.Fatal(err)
+		}
+		if store.capacity != capacity {
+			growths++
+			capacity = store.capacity
+		}
+	}
+	// 5999 nodi: 1024 -> 2048 -> 4096 -> 8192
+	if growths != 4 {
+		t.Fatalf("il file è cresciuto %d volte, attese 4", growths)
+	}
+	if valid, err := IsValidNodeStore(store, nil); err != nil || !valid {
+		t.Fatalf("store non valido: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(len(tree))*NodeSize {
+		t.Fatalf("dopo Close il file è di %d byte, attesi %d", info.Size(), len(tree)*NodeSize)
+	}
+
+	reopened, err := OpenFileNodeStore(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != len(tree) {
+		t.Fatalf("Len() = %d, attesi %d", reopened.Len(), len(tree))
+	}
+	for i, node := range tree {
+		if got, err := reopened.Get(i); err != nil || got != node {
+			t.Fatalf("nodo %d = %s, atteso %s", i, got, node)
+		}
+	}
+	if _, err := reopened.Get(len(tree)); err == nil {
+		t.Fatal("letto un nodo oltre la fine")
+	}
+}
+
+func TestCopyNodesPreallocatesFileStore(t *testing.T) {
+	tree := testNodeStoreTree(t, 2000)
+	store, err := CreateFileNodeStore(filepath.Join(t.TempDir(), "tree.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := CopyNodes(store, NewMemoryNodeStore(tree)); err != nil {
+		t.Fatal(err)
+	}
+	if store.capacity != len(tree) {
+		t.Fatalf("capacità %d, attesa %d (una sola preallocazione)", store.capacity, len(tree))
+	}
+	proof, err := GetProofFromStore(store, len(tree)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := GetProofFromStore(NewMemoryNodeStore(tree), len(tree)-1)
+	if !equalProofs(proof, expected) {
+		t.Fatal("proof dallo store su file diversa da quella in memoria")
+	}
+}
+
+func TestShardedNodeStoreRoundTrip(t *testing.T) {
+	tree := testNodeStoreTree(t, 20)
+	dir := t.TempDir()
+	store, err := CreateShardedNodeStore(dir, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyNodes(store, NewMemoryNodeStore(tree)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenShardedNodeStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != len(tree) {
+		t.Fatalf("Len() = %d, attesi %d", reopened.Len(), len(tree))
+	}
+	if valid, err := IsValidNodeStore(reopened, nil); err != nil || !valid {
+		t.Fatalf("store non valido: %v", err)
+	}
+}
+
+func TestTreeNodesDoesNotAllocate(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(16), MerkleTreeOptions{})
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = tree.nodes().Len()
+		_ = tree.Root()
+	})
+	if allocs != 0 {
+		t.Fatalf("nodes() alloca %.0f volte per chiamata", allocs)
+	}
+
+	// La vista segue le riassegnazioni di Tree
+	tree.Tree = append([]HexString{}, tree.Tree[:1]...)
+	if tree.nodes().Len() != 1 {
+		t.Fatal("nodes() non riflette il nuovo Tree")
+	}
+}
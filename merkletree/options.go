@@ -1,10 +1,15 @@
 package merkletree
 
-import "fmt"
+import "log/slog"
 
 // MerkleTreeOptions definisce le opzioni di configurazione per la costruzione dell'albero di Merkle.
 type MerkleTreeOptions struct {
-	SortLeaves bool `json:"sortLeaves"` // Se true, le foglie vengono ordinate per facilitare le multiproof
+	SortLeaves bool      `json:"sortLeaves"`           // Se true, le foglie vengono ordinate per facilitare le multiproof (ignorato con FixedDepth)
+	FixedDepth int       `json:"fixedDepth,omitempty"` // Se > 0, l'albero ha 2^FixedDepth foglie e ogni proof ha FixedDepth sibling
+	ZeroLeaf   HexString `json:"zeroLeaf,omitempty"`   // Foglia usata per le posizioni libere (default: 32 byte a zero)
+
+	// Logger riceve i messaggi diagnostici dell'albero; se nil si usa quello della libreria (Logger())
+	Logger *slog.Logger `json:"-"`
 }
 
 // DefaultOptions rappresenta la configurazione predefinita per un Merkle Tree
@@ -15,9 +20,33 @@ var DefaultOptions = MerkleTreeOptions{
 // NewMerkleTreeOptions crea un oggetto `MerkleTreeOptions` con valori predefiniti se non specificati
 func NewMerkleTreeOptions(options *MerkleTreeOptions) MerkleTreeOptions {
 	if options == nil {
-		fmt.Println(DefaultOptions)
+		Logger().Debug("opzioni non specificate, uso le opzioni predefinite", "opzioni", DefaultOptions)
 		return DefaultOptions
 	}
 	// sto ritornando sempre DefaultOptions perchè se non metto nulla prende che ho messo false
-	return DefaultOptions
+	result := DefaultOptions
+	result.FixedDepth = options.FixedDepth
+	result.ZeroLeaf = options.ZeroLeaf
+	result.Logger = options.Logger
+	if result.FixedDepth > 0 {
+		// Con FixedDepth i valori occupano le posizioni nell'ordine di inserimento, così gli append non rompono l'ordinamento
+		result.SortLeaves = false
+	}
+	return result
+}
+
+// logger restituisce il logger dell'albero, o quello della libreria se non impostato
+func (o MerkleTreeOptions) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return Logger()
+}
+
+// zeroLeaf restituisce la foglia usata per le posizioni libere di un albero a profondità fissa
+func (o MerkleTreeOptions) zeroLeaf() HexString {
+	if o.ZeroLeaf == "" {
+		return ZeroLeaf
+	}
+	return o.ZeroLeaf
 }
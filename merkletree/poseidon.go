@@ -0,0 +1,190 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// PoseidonHash calcola Poseidon su BN254 come circomlib (Poseidon(n) con n = len(inputs)).
+// Ogni input è un elemento del campo, codificato come intero big-endian.
+func PoseidonHash(inputs ...BytesLike) HexString {
+	elements := make([]*big.Int, len(inputs))
+	for i, input := range inputs {
+		element, err := fieldElement(input)
+		if err != nil {
+			panic(fmt.Sprintf("❌ ERRORE: input Poseidon %d non valido: %v", i, err))
+		}
+		elements[i] = element
+	}
+	hash, err := poseidon.Hash(elements)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: hash Poseidon non riuscito: %v", err))
+	}
+	return fieldHex(hash)
+}
+
+// PoseidonLeafHash calcola l'hash di una foglia come Poseidon([value]), come il circuito
+// che riceve il valore della foglia e ne calcola l'hash con circomlib
+func PoseidonLeafHash(value *big.Int) HexString {
+	return PoseidonHash(value)
+}
+
+// PoseidonNodeHash calcola l'hash di un nodo come Poseidon([left, right]), rispettando
+// l'ordine dei figli come i circuiti Merkle di circomlib
+func PoseidonNodeHash(left BytesLike, right BytesLike) HexString {
+	return PoseidonHash(left, right)
+}
+
+// PoseidonMerkleTree è un albero a profondità fissa con hash Poseidon, per le proof
+// di appartenenza nei circuiti circom e gnark. Le foglie non usate valgono zero.
+type PoseidonMerkleTree struct {
+	MerkleTreeImpl[*big.Int]
+	Depth int
+}
+
+// NewPoseidonMerkleTree crea un albero con 2^depth foglie: i valori in ordine, seguiti da
+// foglie zero. Le foglie non vengono ordinate, così l'indice del valore è la sua posizione.
+func NewPoseidonMerkleTree(values []*big.Int, depth int) (*PoseidonMerkleTree, error) {
+	for i, value := range values {
+		if value == nil || value.Sign() < 0 || value.Cmp(constants.Q) >= 0 {
+			return nil, fmt.Errorf("il valore %d non è un elemento del campo BN254", i)
+		}
+	}
+
+	hashes := make([]BytesLike, len(values))
+	for i, value := range values {
+		hashes[i] = PoseidonLeafHash(value)
+	}
+	zeroLeaf := fieldHex(new(big.Int))
+	tree, err := MakeFixedDepthMerkleTree(hashes, depth, zeroLeaf, PoseidonNodeHash)
+	if err != nil {
+		return nil, err
+	}
+
+	firstLeaf := len(tree) - (1 << depth)
+	indexedValues := make([]struct {
+		Value     *big.Int
+		TreeIndex int
+	}, len(values))
+	for i, value := range values {
+		indexedValues[i].Value = new(big.Int).Set(value)
+		indexedValues[i].TreeIndex = firstLeaf + i
+	}
+
+	poseidonTree := &PoseidonMerkleTree{
+		MerkleTreeImpl: MerkleTreeImpl[*big.Int]{
+			Tree:     tree,
+			Values:   indexedValues,
+			LeafHash: PoseidonLeafHash,
+			NodeHash: PoseidonNodeHash,
+			Options:  MerkleTreeOptions{SortLeaves: false, FixedDepth: depth, ZeroLeaf: zeroLeaf},
+		},
+		Depth: depth,
+	}
+	poseidonTree.rebuildHashLookup()
+	return poseidonTree, nil
+}
+
+// Verify verifica una proof per un valore (o per l'indice di un valore). PoseidonNodeHash
+// rispetta l'ordine dei figli, quindi la proof viene ricalcolata dalla posizione della foglia.
+func (m *PoseidonMerkleTree) Verify(leaf interface{}, proof []HexString) bool {
+	valueIndex, err := m.poseidonValueIndex(leaf)
+	if err != nil {
+		return false
+	}
+	treeIndex := m.Values[valueIndex].TreeIndex
+	if len(proof) != treeDepth(treeIndex) {
+		return false
+	}
+	proofNodes := make([]BytesLike, len(proof))
+	for i, node := range proof {
+		if !IsValidMerkleNode(node) {
+			return false
+		}
+		proofNodes[i] = node
+	}
+	return ProcessProofAt(m.node(treeIndex), treeIndex, proofNodes, PoseidonNodeHash) == m.Root()
+}
+
+// GetMultiProof genera una proof multipla per i valori con gli indici dati. Le foglie della
+// proof seguono l'ordine decrescente degli indici, lo stesso atteso da VerifyMultiProof.
+func (m *PoseidonMerkleTree) GetMultiProof(indices []int) (MultiProof, error) {
+	treeIndices, err := m.multiProofIndices(indices)
+	if err != nil {
+		return MultiProof{}, err
+	}
+	nodes := make([]BytesLike, len(m.Tree))
+	for i, node := range m.Tree {
+		nodes[i] = node
+	}
+	multiproof := GetMultiProof(nodes, treeIndices)
+	for i, treeIndex := range treeIndices {
+		multiproof.Leaves[i] = m.Tree[treeIndex]
+	}
+	return multiproof, nil
+}
+
+// VerifyMultiProof verifica una proof multipla generata con GetMultiProof per gli stessi
+// indici, combinando ogni coppia di nodi nell'ordine sinistra-destra dell'albero
+func (m *PoseidonMerkleTree) VerifyMultiProof(multiproof MultiProof, indices []int) bool {
+	treeIndices, err := m.multiProofIndices(indices)
+	if err != nil {
+		return false
+	}
+	root, err := ProcessMultiProofAt(multiproof, treeIndices, PoseidonNodeHash)
+	return err == nil && root == m.Root()
+}
+
+// multiProofIndices converte gli indici dei valori in TreeIndex distinti, in ordine decrescente
+func (m *PoseidonMerkleTree) multiProofIndices(indices []int) ([]int, error) {
+	if len(indices) == 0 {
+		return nil, errors.New("impossibile generare una proof multipla per 0 elementi")
+	}
+	treeIndices := make([]int, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= len(m.Values) {
+			return nil, fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(m.Values)-1)
+		}
+		treeIndices[i] = m.Values[index].TreeIndex
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(treeIndices)))
+	for i := 1; i < len(treeIndices); i++ {
+		if treeIndices[i] == treeIndices[i-1] {
+			return nil, errors.New("indice ripetuto nella proof multipla")
+		}
+	}
+	return treeIndices, nil
+}
+
+// fieldElement converte un valore in un elemento del campo BN254
+func fieldElement(value BytesLike) (*big.Int, error) {
+	var element *big.Int
+	switch v := value.(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, errors.New("valore nil")
+		}
+		element = v
+	default:
+		valueBytes, err := ToBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		element = new(big.Int).SetBytes(valueBytes)
+	}
+	if element.Sign() < 0 || element.Cmp(constants.Q) >= 0 {
+		return nil, errors.New("valore fuori dal campo BN254")
+	}
+	return element, nil
+}
+
+// fieldHex codifica un elemento del campo come nodo di 32 byte
+func fieldHex(element *big.Int) HexString {
+	hex, _ := ToHex(element.FillBytes(make([]byte, 32)))
+	return hex
+}
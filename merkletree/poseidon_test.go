@@ -0,0 +1,189 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Vettori di circomlib/circomlibjs (test di poseidon.js) per Poseidon su BN254
+var poseidonVectors = []struct {
+	inputs []int64
+	hash   HexString
+}{
+	{[]int64{1, 2}, "0x115cc0f5e7d690413df64c6b9662e9cf2a3617f2743245519e19607a4417189a"},
+	{[]int64{0, 0}, "0x2098f5fb9e239eab3ceac3f27b81e481dc3124d55ffed523a839ee8446b64864"},
+}
+
+func TestPoseidonVectors(t *testing.T) {
+	for _, vector := range poseidonVectors {
+		inputs := make([]BytesLike, len(vector.inputs))
+		for i, input := range vector.inputs {
+			inputs[i] = big.NewInt(input)
+		}
+		if hash := PoseidonHash(inputs...); hash != vector.hash {
+			t.Errorf("Poseidon(%v) = %s, atteso %s", vector.inputs, hash, vector.hash)
+		}
+	}
+	if PoseidonNodeHash(big.NewInt(1), big.NewInt(2)) == PoseidonNodeHash(big.NewInt(2), big.NewInt(1)) {
+		t.Fatal("PoseidonNodeHash non rispetta l'ordine dei figli")
+	}
+}
+
+func TestPoseidonFixedDepthRoot(t *testing.T) {
+	values := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	tree, err := NewPoseidonMerkleTree(values, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Root ricalcolata livello per livello: la quarta foglia è la foglia zero (0, non Poseidon(0))
+	zero := big.NewInt(0)
+	left := PoseidonNodeHash(PoseidonLeafHash(values[0]), PoseidonLeafHash(values[1]))
+	right := PoseidonNodeHash(PoseidonLeafHash(values[2]), zero)
+	if root := PoseidonNodeHash(left, right); tree.Root() != root {
+		t.Fatalf("root %s, attesa %s", tree.Root(), root)
+	}
+
+	zeros := tree.FixedDepthZeroHashes()
+	if len(zeros) != 3 || zeros[0] != fieldHex(zero) || zeros[1] != poseidonVectors[1].hash {
+		t.Fatalf("hash dei sottoalberi vuoti %v", zeros)
+	}
+
+	// Un albero vuoto ha per root l'hash del sottoalbero vuoto di altezza pari alla profondità
+	empty, err := NewPoseidonMerkleTree(nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Root() != zeros[2] {
+		t.Fatalf("root dell'albero vuoto %s, attesa %s", empty.Root(), zeros[2])
+	}
+
+	if _, err := NewPoseidonMerkleTree([]*big.Int{big.NewInt(-1)}, 2); err == nil {
+		t.Fatal("valore fuori dal campo accettato")
+	}
+	if _, err := NewPoseidonMerkleTree(append(values, big.NewInt(4), big.NewInt(5)), 2); err == nil {
+		t.Fatal("più valori delle foglie disponibili accettati")
+	}
+}
+
+func TestPoseidonVerifyEveryLeaf(t *testing.T) {
+	values := []*big.Int{big.NewInt(11), big.NewInt(22), big.NewInt(33), big.NewInt(44), big.NewInt(55)}
+	tree, err := NewPoseidonMerkleTree(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, value := range values {
+		proof := tree.GetProof(i)
+		if !tree.Verify(i, proof) || !tree.Verify(value, proof) {
+			t.Fatalf("proof della foglia %d non valida", i)
+		}
+		// La proof della foglia vicina ha gli stessi fratelli ai livelli alti, ma non al primo
+		if other := (i + 1) % len(values); tree.Verify(other, proof) {
+			t.Fatalf("proof della foglia %d accettata per la foglia %d", i, other)
+		}
+		if tree.Verify(i, proof[1:]) {
+			t.Fatalf("proof troncata della foglia %d accettata", i)
+		}
+	}
+	if tree.Verify(big.NewInt(66), tree.GetProof(0)) || tree.Verify(len(values), tree.GetProof(0)) {
+		t.Fatal("valore assente accettato")
+	}
+
+	for _, indices := range [][]int{{0}, {1, 2}, {4, 0, 3}, {0, 1, 2, 3, 4}} {
+		multiproof, err := tree.GetMultiProof(indices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tree.VerifyMultiProof(multiproof, indices) {
+			t.Fatalf("proof multipla per %v non valida", indices)
+		}
+		tampered := multiproof
+		tampered.Leaves = append([]HexString(nil), multiproof.Leaves...)
+		tampered.Leaves[0] = PoseidonLeafHash(big.NewInt(66))
+		if tree.VerifyMultiProof(tampered, indices) {
+			t.Fatalf("proof multipla per %v accettata con una foglia modificata", indices)
+		}
+	}
+	if _, err := tree.GetMultiProof([]int{1, 1}); err == nil {
+		t.Fatal("indice ripetuto accettato")
+	}
+}
+
+func TestPoseidonWitness(t *testing.T) {
+	values := []*big.Int{big.NewInt(10), big.NewInt(20), big.NewInt(30)}
+	tree, err := NewPoseidonMerkleTree(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	witness, err := tree.CircomWitness(big.NewInt(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Il valore 30 è la foglia 2: sinistra al primo livello, destra al secondo, sinistra al terzo
+	if witness.Leaf != "30" || len(witness.PathElements) != 3 {
+		t.Fatalf("witness %+v", witness)
+	}
+	if expected := []int{0, 1, 0}; witness.PathIndices[0] != expected[0] || witness.PathIndices[1] != expected[1] || witness.PathIndices[2] != expected[2] {
+		t.Fatalf("pathIndices %v, attesi %v", witness.PathIndices, expected)
+	}
+	if witness.PathElements[0] != "0" {
+		t.Fatalf("il fratello della foglia 2 è la foglia zero, trovato %s", witness.PathElements[0])
+	}
+	siblingPair := new(big.Int).SetBytes(mustBytes(t, PoseidonNodeHash(PoseidonLeafHash(values[0]), PoseidonLeafHash(values[1]))))
+	if witness.PathElements[1] != siblingPair.String() {
+		t.Fatalf("pathElements[1] = %s, atteso %s", witness.PathElements[1], siblingPair)
+	}
+	root := new(big.Int).SetBytes(mustBytes(t, tree.Root()))
+	if witness.Root != root.String() {
+		t.Fatalf("root %s, attesa %s", witness.Root, root)
+	}
+	if !VerifyCircomWitness(witness) {
+		t.Fatal("witness non valido")
+	}
+
+	tampered := witness
+	tampered.PathIndices = []int{1, 1, 0}
+	if VerifyCircomWitness(tampered) {
+		t.Fatal("witness accettato con pathIndices modificati")
+	}
+
+	byIndex, err := tree.GnarkWitness(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byIndex.Root.String() != witness.Root || byIndex.PathElements[1].String() != witness.PathElements[1] {
+		t.Fatal("witness gnark diverso da quello circom")
+	}
+
+	path := filepath.Join(t.TempDir(), "input.json")
+	if err := tree.WriteCircomWitness(path, 2); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written map[string]interface{}
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+	for _, signal := range []string{"root", "leaf", "pathElements", "pathIndices"} {
+		if _, found := written[signal]; !found {
+			t.Fatalf("segnale %s mancante nel file di input", signal)
+		}
+	}
+}
+
+func mustBytes(t *testing.T, value BytesLike) []byte {
+	t.Helper()
+	data, err := ToBytes(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,152 @@
+package merkletree
+
+import (
+	"context"
+	"slices"
+)
+
+// BuildPhase identifica una fase della costruzione dell'albero
+type BuildPhase string
+
+const (
+	PhaseLeafHashing BuildPhase = "leaf-hashing" // Calcolo degli hash delle foglie
+	PhaseSorting     BuildPhase = "sorting"      // Ordinamento delle foglie (solo con SortLeaves)
+	PhaseNodeHashing BuildPhase = "node-hashing" // Calcolo dei nodi interni
+	PhaseIndexing    BuildPhase = "indexing"     // Assegnazione degli indici delle foglie
+)
+
+// BuildProgress descrive l'avanzamento di una fase: Done elementi elaborati su Total
+type BuildProgress struct {
+	Phase BuildPhase
+	Done  int
+	Total int
+}
+
+// ProgressFunc riceve gli aggiornamenti di avanzamento della costruzione.
+// Viene chiamata dalla goroutine che costruisce l'albero, quindi deve essere veloce.
+type ProgressFunc func(BuildProgress)
+
+// ProgressChannel adatta un canale a una ProgressFunc: gli aggiornamenti vengono scartati
+// se il canale è pieno, così un lettore lento non rallenta la costruzione
+func ProgressChannel(ch chan<- BuildProgress) ProgressFunc {
+	return func(progress BuildProgress) {
+		select {
+		case ch <- progress:
+		default:
+		}
+	}
+}
+
+// progressInterval è ogni quanti elementi vengono controllati il contesto e segnalato l'avanzamento
+const progressInterval = 1 << 14
+
+// progressReporter controlla il contesto e segnala l'avanzamento di una fase
+type progressReporter struct {
+	ctx      context.Context
+	progress ProgressFunc
+	phase    BuildPhase
+	total    int
+}
+
+func newProgressReporter(ctx context.Context, progress ProgressFunc, phase BuildPhase, total int) progressReporter {
+	reporter := progressReporter{ctx: ctx, progress: progress, phase: phase, total: total}
+	reporter.report(0)
+	return reporter
+}
+
+// step va chiamato prima di elaborare l'elemento `done`; ogni progressInterval elementi
+// restituisce ctx.Err() se il contesto è stato annullato
+func (r progressReporter) step(done int) error {
+	if done%progressInterval != 0 {
+		return nil
+	}
+	return r.at(done)
+}
+
+// at restituisce ctx.Err() se il contesto è stato annullato, altrimenti segnala `done`
+func (r progressReporter) at(done int) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if done > 0 {
+		r.report(done)
+	}
+	return nil
+}
+
+// done segnala il completamento della fase
+func (r progressReporter) done() {
+	r.report(r.total)
+}
+
+func (r progressReporter) report(done int) {
+	if r.progress != nil {
+		r.progress(BuildProgress{Phase: r.phase, Done: done, Total: r.total})
+	}
+}
+
+// sortContext ordina `items` con un merge sort a blocchi che si può interrompere: i blocchi
+// di progressInterval elementi vengono ordinati uno alla volta e poi fusi a coppie, e il
+// contesto viene controllato tra un blocco e l'altro e ogni progressInterval elementi fusi.
+// L'avanzamento (PhaseSorting) conta gli elementi elaborati in ogni passata: l'ordinamento
+// dei blocchi più una passata per ogni livello di fusione. L'ordinamento è stabile.
+func sortContext[E any](ctx context.Context, progress ProgressFunc, items []E, cmp func(a, b E) int) error {
+	passes := 1
+	for width := progressInterval; width < len(items); width *= 2 {
+		passes++
+	}
+	reporter := newProgressReporter(ctx, progress, PhaseSorting, passes*len(items))
+
+	for start := 0; start < len(items); start += progressInterval {
+		if err := reporter.at(start); err != nil {
+			return err
+		}
+		slices.SortStableFunc(items[start:min(start+progressInterval, len(items))], cmp)
+	}
+	if len(items) <= progressInterval {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		reporter.done()
+		return nil
+	}
+
+	src, dst := items, make([]E, len(items))
+	pass := 1
+	for width := progressInterval; width < len(items); width *= 2 {
+		for start := 0; start < len(items); start += 2 * width {
+			mid, end := min(start+width, len(items)), min(start+2*width, len(items))
+			if err := mergeRuns(reporter, pass*len(items)+start, dst[start:end], src[start:mid], src[mid:end], cmp); err != nil {
+				return err
+			}
+		}
+		src, dst = dst, src
+		pass++
+	}
+	if &src[0] != &items[0] {
+		copy(items, src)
+	}
+	reporter.done()
+	return nil
+}
+
+// mergeRuns fonde in `dst` le sequenze ordinate `a` e `b`, preferendo `a` a parità.
+// `offset` è il numero di elementi già elaborati dall'ordinamento prima di questa fusione.
+func mergeRuns[E any](reporter progressReporter, offset int, dst []E, a []E, b []E, cmp func(a, b E) int) error {
+	i, j := 0, 0
+	for k := range dst {
+		if k%progressInterval == 0 {
+			if err := reporter.at(offset + k); err != nil {
+				return err
+			}
+		}
+		if j == len(b) || (i < len(a) && cmp(a[i], b[j]) <= 0) {
+			dst[k] = a[i]
+			i++
+		} else {
+			dst[k] = b[j]
+			j++
+		}
+	}
+	return nil
+}
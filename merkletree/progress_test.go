@@ -0,0 +1,145 @@
+package merkletree
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestSortContextSorts(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{0, 1, 100, progressInterval, 3*progressInterval + 7} {
+		items := make([]int, n)
+		for i := range items {
+			items[i] = random.IntN(n + 1)
+		}
+		expected := slices.Clone(items)
+		slices.Sort(expected)
+
+		if err := sortContext(context.Background(), nil, items, cmp.Compare[int]); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(items, expected) {
+			t.Fatalf("n=%d: elementi non ordinati", n)
+		}
+	}
+}
+
+func TestSortContextIsStable(t *testing.T) {
+	type item struct{ key, order int }
+	items := make([]item, 2*progressInterval+3)
+	for i := range items {
+		items[i] = item{key: (len(items) - i) % 5, order: i}
+	}
+	err := sortContext(context.Background(), nil, items, func(a, b item) int { return cmp.Compare(a.key, b.key) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i-1].key == items[i].key && items[i-1].order > items[i].order {
+			t.Fatalf("ordinamento non stabile alla posizione %d", i)
+		}
+	}
+}
+
+func TestSortContextCancelled(t *testing.T) {
+	items := make([]int, 4*progressInterval)
+	for i := range items {
+		items[i] = len(items) - i
+	}
+
+	// Annullato durante l'ordinamento: nessun panic, restituisce l'errore del contesto
+	ctx, cancel := context.WithCancel(context.Background())
+	comparisons := 0
+	err := sortContext(ctx, nil, items, func(a, b int) int {
+		if comparisons++; comparisons == 1000 {
+			cancel()
+		}
+		return cmp.Compare(a, b)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("errore %v, atteso context.Canceled", err)
+	}
+}
+
+func TestPrepareMerkleTreeContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := PrepareMerkleTreeContext(ctx, testValues(10), DefaultOptions, StandardLeafHash[string], nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("errore %v, atteso context.Canceled", err)
+	}
+}
+
+func TestPrepareMerkleTreeContextReportsPhases(t *testing.T) {
+	var phases []BuildPhase
+	progress := func(p BuildProgress) {
+		if p.Done == p.Total && (len(phases) == 0 || phases[len(phases)-1] != p.Phase) {
+			phases = append(phases, p.Phase)
+		}
+	}
+	_, _, err := PrepareMerkleTreeContext(context.Background(), testValues(10), DefaultOptions, StandardLeafHash[string], nil, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []BuildPhase{PhaseLeafHashing, PhaseSorting, PhaseNodeHashing, PhaseIndexing}
+	if !slices.Equal(phases, expected) {
+		t.Fatalf("fasi %v, attese %v", phases, expected)
+	}
+}
+
+func TestSortContextReportsProgress(t *testing.T) {
+	items := make([]int, 3*progressInterval+5)
+	for i := range items {
+		items[i] = len(items) - i
+	}
+
+	var reports []BuildProgress
+	progress := func(p BuildProgress) { reports = append(reports, p) }
+	if err := sortContext(context.Background(), progress, items, cmp.Compare[int]); err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 blocchi ordinati e 2 livelli di fusione: 3 passate su tutti gli elementi
+	total := 3 * len(items)
+	if len(reports) < 3 || reports[0].Done != 0 || reports[len(reports)-1].Done != total {
+		t.Fatalf("avanzamento %v, atteso da 0 a %d", reports, total)
+	}
+	for i, p := range reports {
+		if p.Phase != PhaseSorting || p.Total != total || (i > 0 && p.Done <= reports[i-1].Done) {
+			t.Fatalf("aggiornamento %d non valido: %+v", i, p)
+		}
+	}
+}
+
+func TestMakeMerkleTreeContextReturnsErrors(t *testing.T) {
+	if _, err := MakeMerkleTreeContext(context.Background(), nil, StandardNodeHash, nil); err == nil {
+		t.Fatal("albero vuoto accettato")
+	}
+	leaves := []BytesLike{StandardLeafHash("a"), "non esadecimale"}
+	if _, err := MakeMerkleTreeContext(context.Background(), leaves, StandardNodeHash, nil); err == nil {
+		t.Fatal("foglia non valida accettata")
+	}
+
+	tree, err := MakeMerkleTreeContext(context.Background(), []BytesLike{StandardLeafHash("a"), StandardLeafHash("b")}, StandardNodeHash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree[0] != StandardNodeHash(tree[1], tree[2]) {
+		t.Fatal("root non calcolata dai figli")
+	}
+
+	// MakeMerkleTree mantiene la firma originale e va in panic sugli stessi errori
+	if root := MakeMerkleTree([]BytesLike{tree[1], tree[2]}, StandardNodeHash)[0]; root != tree[0] {
+		t.Fatalf("root %s, attesa %s", root, tree[0])
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MakeMerkleTree non è andato in panic su un albero vuoto")
+		}
+	}()
+	MakeMerkleTree(nil, StandardNodeHash)
+}
@@ -0,0 +1,180 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RangeProof dimostra che le foglie Leaves occupano esattamente le posizioni [Start, End)
+// dell'albero, senza buchi. Le posizioni sono in ordine di albero (dopo l'eventuale ordinamento).
+type RangeProof struct {
+	LeafCount int         `json:"leafCount"`
+	Start     int         `json:"start"`
+	End       int         `json:"end"`
+	Leaves    []HexString `json:"leaves"` // Hash delle foglie, in ordine di posizione
+	Proof     []HexString `json:"proof"`  // Nodi fratelli lungo i bordi sinistro e destro dell'intervallo
+}
+
+// GetRangeProof genera una proof per le foglie nelle posizioni [start, end).
+// Servono solo i fratelli lungo i due percorsi di bordo, quindi la proof è più piccola
+// di una multiproof sulle stesse foglie. L'albero deve usare una NodeHash non simmetrica
+// (ad esempio SortedMerkleTree), altrimenti restituisce ErrSymmetricNodeHash.
+func (m *MerkleTreeImpl[T]) GetRangeProof(start int, end int) (RangeProof, error) {
+	if isSymmetricNodeHash(m.nodeHash()) {
+		return RangeProof{}, ErrSymmetricNodeHash
+	}
+	store := m.nodes()
+	leafCount := m.leafCount()
+	if start < 0 || end > leafCount || start >= end {
+		return RangeProof{}, fmt.Errorf("intervallo [%d, %d) non valido (foglie: %d)", start, end, leafCount)
+	}
+
+	firstLeaf := leafCount - 1
+	leaves := make([]HexString, 0, end-start)
+	for position := start; position < end; position++ {
+		leaf, err := store.Get(firstLeaf + position)
+		if err != nil {
+			return RangeProof{}, err
+		}
+		leaves = append(leaves, leaf)
+	}
+
+	var proof []HexString
+	_, err := processRange(firstLeaf+start, leaves, func(index int) (HexString, error) {
+		sibling, err := store.Get(index)
+		if err != nil {
+			return "", err
+		}
+		proof = append(proof, sibling)
+		return sibling, nil
+	}, m.nodeHash())
+	if err != nil {
+		return RangeProof{}, err
+	}
+
+	return RangeProof{
+		LeafCount: leafCount,
+		Start:     start,
+		End:       end,
+		Leaves:    leaves,
+		Proof:     proof,
+	}, nil
+}
+
+// VerifyRangeProof verifica una range proof rispetto alla root di un albero con `leafCount`
+// foglie. Il numero di foglie viene dal chiamante perché la root non lo vincola: una proof
+// che ne dichiara un altro sposterebbe le posizioni. Se nodeHash è nil si usa OrderedNodeHash;
+// una NodeHash simmetrica (come StandardNodeHash) permette di scambiare i figli di ogni nodo
+// e viene rifiutata. Le foglie dovrebbero usare un hash distinto da quello dei nodi interni
+// (come SortedLeafHash).
+func VerifyRangeProof(root BytesLike, leafCount int, proof RangeProof, nodeHash NodeHash) bool {
+	if nodeHash == nil {
+		nodeHash = OrderedNodeHash
+	}
+	if isSymmetricNodeHash(nodeHash) || proof.LeafCount != leafCount {
+		return false
+	}
+	if proof.Start < 0 || proof.End > proof.LeafCount || proof.Start >= proof.End || len(proof.Leaves) != proof.End-proof.Start {
+		return false
+	}
+	rootHex, err := ToHex(root)
+	if err != nil {
+		return false
+	}
+	for _, node := range append(append([]HexString{}, proof.Leaves...), proof.Proof...) {
+		if !IsValidMerkleNode(node) {
+			return false
+		}
+	}
+
+	remaining := proof.Proof
+	computedRoot, err := processRange(proof.LeafCount-1+proof.Start, proof.Leaves, func(int) (HexString, error) {
+		if len(remaining) == 0 {
+			return "", errors.New("proof troppo corta")
+		}
+		sibling := remaining[0]
+		remaining = remaining[1:]
+		return sibling, nil
+	}, nodeHash)
+
+	return err == nil && len(remaining) == 0 && computedRoot == rootHex
+}
+
+// isSymmetricNodeHash verifica se scambiando i figli l'hash del nodo non cambia
+func isSymmetricNodeHash(nodeHash NodeHash) bool {
+	left := keccak256Hex([]byte("sinistro"))
+	right := keccak256Hex([]byte("destro"))
+	return nodeHash(left, right) == nodeHash(right, left)
+}
+
+// processRange calcola la root a partire da foglie contigue con indici [first, first+len(leaves)).
+// I nodi vengono elaborati in ordine di indice decrescente, così ogni figlio precede il genitore;
+// `sibling` fornisce i fratelli mancanti, nell'ordine in cui servono.
+func processRange(first int, leaves []HexString, sibling func(index int) (HexString, error), nodeHash NodeHash) (HexString, error) {
+	type rangeNode struct {
+		index int
+		hash  HexString
+	}
+
+	// Due code decrescenti: le foglie ancora da elaborare e i genitori già calcolati
+	pending := make([]rangeNode, len(leaves))
+	for i, leaf := range leaves {
+		pending[len(leaves)-1-i] = rangeNode{index: first + i, hash: leaf}
+	}
+	var parents []rangeNode
+
+	pop := func() (rangeNode, bool) {
+		switch {
+		case len(pending) > 0 && (len(parents) == 0 || pending[0].index > parents[0].index):
+			node := pending[0]
+			pending = pending[1:]
+			return node, true
+		case len(parents) > 0:
+			node := parents[0]
+			parents = parents[1:]
+			return node, true
+		default:
+			return rangeNode{}, false
+		}
+	}
+	peek := func() int {
+		index := -1
+		if len(pending) > 0 {
+			index = pending[0].index
+		}
+		if len(parents) > 0 && parents[0].index > index {
+			index = parents[0].index
+		}
+		return index
+	}
+
+	for {
+		node, _ := pop()
+		if node.index == 0 {
+			if _, more := pop(); more {
+				return "", errors.New("nodi in eccesso nella range proof")
+			}
+			return node.hash, nil
+		}
+
+		siblingIndex := SiblingIndex(node.index)
+		var siblingHash HexString
+		if siblingIndex == node.index-1 && peek() == siblingIndex {
+			siblingNode, _ := pop()
+			siblingHash = siblingNode.hash
+		} else {
+			var err error
+			if siblingHash, err = sibling(siblingIndex); err != nil {
+				return "", err
+			}
+		}
+
+		var parentHash HexString
+		if node.index%2 == 1 {
+			parentHash = nodeHash(node.hash, siblingHash)
+		} else {
+			parentHash = nodeHash(siblingHash, node.hash)
+		}
+		parents = append(parents, rangeNode{index: ParentIndex(node.index), hash: parentHash})
+	}
+}
@@ -0,0 +1,77 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRangeProofAllRanges(t *testing.T) {
+	for _, n := range []int{1, 2, 5, 8, 11} {
+		tree := NewSortedMerkleTree(testValues(n))
+		for start := 0; start < n; start++ {
+			for end := start + 1; end <= n; end++ {
+				proof, err := tree.GetRangeProof(start, end)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !VerifyRangeProof(tree.Root(), n, proof, nil) {
+					t.Fatalf("%d foglie: intervallo [%d, %d) non verificato", n, start, end)
+				}
+			}
+		}
+	}
+}
+
+func TestRangeProofRejectsSwappedLeaves(t *testing.T) {
+	tree := NewSortedMerkleTree(testValues(8))
+	proof, err := tree.GetRangeProof(2, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := proof
+	swapped.Leaves = append([]HexString{}, proof.Leaves...)
+	swapped.Leaves[0], swapped.Leaves[1] = swapped.Leaves[1], swapped.Leaves[0]
+	if VerifyRangeProof(tree.Root(), 8, swapped, nil) {
+		t.Fatal("range proof accettata con le foglie scambiate")
+	}
+}
+
+func TestRangeProofRejectsShiftedRange(t *testing.T) {
+	tree := NewSortedMerkleTree(testValues(8))
+	proof, err := tree.GetRangeProof(2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stesse foglie e fratelli, intervallo dichiarato in un'altra posizione
+	shifted := proof
+	shifted.Start, shifted.End = 4, 6
+	if VerifyRangeProof(tree.Root(), 8, shifted, nil) {
+		t.Fatal("range proof accettata con l'intervallo spostato")
+	}
+
+	// Un numero di foglie diverso da quello atteso sposta le posizioni
+	for _, leafCount := range []int{7, 9, 16} {
+		forged := proof
+		forged.LeafCount = leafCount
+		if VerifyRangeProof(tree.Root(), 8, forged, nil) || VerifyRangeProof(tree.Root(), leafCount, forged, nil) {
+			t.Fatalf("range proof accettata con %d foglie", leafCount)
+		}
+	}
+}
+
+func TestRangeProofRejectsSymmetricHash(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(8), MerkleTreeOptions{})
+	if _, err := tree.GetRangeProof(0, 2); !errors.Is(err, ErrSymmetricNodeHash) {
+		t.Fatalf("errore %v, atteso ErrSymmetricNodeHash", err)
+	}
+
+	sorted := NewSortedMerkleTree(testValues(8))
+	proof, err := sorted.GetRangeProof(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyRangeProof(sorted.Root(), 8, proof, StandardNodeHash) {
+		t.Fatal("range proof verificata con una NodeHash simmetrica")
+	}
+}
@@ -0,0 +1,246 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+)
+
+// Prefissi di dominio per l'hashing di foglie e nodi (RFC 6962, sezione 2.1)
+const (
+	RFC6962LeafPrefix byte = 0x00
+	RFC6962NodePrefix byte = 0x01
+)
+
+// RFC6962MerkleTree è un Merkle Tree in stile Certificate Transparency (RFC 6962 / RFC 9162).
+// A differenza del layout a heap di MakeMerkleTree, l'albero è diviso ricorsivamente
+// alla più grande potenza di due minore della dimensione (albero sbilanciato a sinistra).
+type RFC6962MerkleTree struct {
+	Leaves []HexString // Hash delle foglie, in ordine di inserimento
+}
+
+// RFC6962LeafHash calcola l'hash di una foglia: SHA-256(0x00 || data)
+func RFC6962LeafHash(data BytesLike) HexString {
+	dataBytes, err := ToBytes(data)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: foglia non valida: %v", err))
+	}
+	return sha256Hex([]byte{RFC6962LeafPrefix}, dataBytes)
+}
+
+// RFC6962NodeHash calcola l'hash di un nodo interno: SHA-256(0x01 || left || right)
+func RFC6962NodeHash(left BytesLike, right BytesLike) HexString {
+	concatenated, err := Concat(left, right)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: nodo non valido: %v", err))
+	}
+	return sha256Hex([]byte{RFC6962NodePrefix}, concatenated)
+}
+
+// RFC6962EmptyRoot restituisce la root dell'albero vuoto: SHA-256("")
+func RFC6962EmptyRoot() HexString {
+	return sha256Hex()
+}
+
+// NewRFC6962MerkleTree crea un albero a partire dalle voci del log
+func NewRFC6962MerkleTree(entries []BytesLike) *RFC6962MerkleTree {
+	tree := &RFC6962MerkleTree{Leaves: make([]HexString, 0, len(entries))}
+	for _, entry := range entries {
+		tree.AppendEntry(entry)
+	}
+	return tree
+}
+
+// AppendEntry aggiunge una voce al log e restituisce il suo indice
+func (t *RFC6962MerkleTree) AppendEntry(data BytesLike) int {
+	t.Leaves = append(t.Leaves, RFC6962LeafHash(data))
+	return len(t.Leaves) - 1
+}
+
+// Size restituisce il numero di foglie dell'albero
+func (t *RFC6962MerkleTree) Size() int {
+	return len(t.Leaves)
+}
+
+// Root restituisce la root dell'albero corrente
+func (t *RFC6962MerkleTree) Root() HexString {
+	return rfc6962SubtreeHash(t.Leaves)
+}
+
+// RootAt restituisce la root dell'albero come era con `size` foglie
+func (t *RFC6962MerkleTree) RootAt(size int) (HexString, error) {
+	if size < 0 || size > len(t.Leaves) {
+		return "", fmt.Errorf("dimensione %d fuori dai limiti (max %d)", size, len(t.Leaves))
+	}
+	return rfc6962SubtreeHash(t.Leaves[:size]), nil
+}
+
+// GetInclusionProof restituisce l'audit path PATH(index, D[size]) della foglia `index`
+func (t *RFC6962MerkleTree) GetInclusionProof(index int, size int) ([]HexString, error) {
+	if size < 0 || size > len(t.Leaves) {
+		return nil, fmt.Errorf("dimensione %d fuori dai limiti (max %d)", size, len(t.Leaves))
+	}
+	if index < 0 || index >= size {
+		return nil, fmt.Errorf("indice %d fuori dai limiti per un albero di dimensione %d", index, size)
+	}
+	return rfc6962AuditPath(index, t.Leaves[:size]), nil
+}
+
+// GetConsistencyProof restituisce la proof PROOF(m, D[n]) che l'albero di dimensione `m`
+// è un prefisso di quello di dimensione `n`
+func (t *RFC6962MerkleTree) GetConsistencyProof(m int, n int) ([]HexString, error) {
+	if n < 0 || n > len(t.Leaves) {
+		return nil, fmt.Errorf("dimensione %d fuori dai limiti (max %d)", n, len(t.Leaves))
+	}
+	if m < 0 || m > n {
+		return nil, fmt.Errorf("dimensioni non valide: %d -> %d", m, n)
+	}
+	if m == 0 || m == n {
+		return []HexString{}, nil
+	}
+	return rfc6962SubProof(m, t.Leaves[:n], true), nil
+}
+
+// VerifyRFC6962Inclusion verifica un audit path secondo RFC 9162, sezione 2.1.3.2
+func VerifyRFC6962Inclusion(root BytesLike, leafHash BytesLike, index int, size int, proof []HexString) bool {
+	if index < 0 || index >= size {
+		return false
+	}
+	r, err := ToHex(leafHash)
+	if err != nil {
+		return false
+	}
+
+	fn, sn := uint64(index), uint64(size-1)
+	for _, p := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = RFC6962NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = RFC6962NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	rootHex, err := ToHex(root)
+	if err != nil {
+		return false
+	}
+	return sn == 0 && r == rootHex
+}
+
+// VerifyRFC6962Consistency verifica una proof di consistenza secondo RFC 9162, sezione 2.1.4.2
+func VerifyRFC6962Consistency(oldRoot BytesLike, newRoot BytesLike, m int, n int, proof []HexString) bool {
+	oldRootHex, err := ToHex(oldRoot)
+	if err != nil {
+		return false
+	}
+	newRootHex, err := ToHex(newRoot)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case m < 0 || m > n:
+		return false
+	case m == 0:
+		return len(proof) == 0
+	case m == n:
+		return len(proof) == 0 && oldRootHex == newRootHex
+	case len(proof) == 0:
+		return false
+	}
+
+	path := proof
+	if bits.OnesCount64(uint64(m)) == 1 {
+		path = append([]HexString{oldRootHex}, proof...)
+	}
+
+	fn, sn := uint64(m-1), uint64(n-1)
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := path[0], path[0]
+	for _, c := range path[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = RFC6962NodeHash(c, fr)
+			sr = RFC6962NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = RFC6962NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	return fr == oldRootHex && sr == newRootHex && sn == 0
+}
+
+// rfc6962SubtreeHash calcola MTH(D[n]) per le foglie date
+func rfc6962SubtreeHash(leaves []HexString) HexString {
+	switch len(leaves) {
+	case 0:
+		return RFC6962EmptyRoot()
+	case 1:
+		return leaves[0]
+	}
+	k := rfc6962Split(len(leaves))
+	return RFC6962NodeHash(rfc6962SubtreeHash(leaves[:k]), rfc6962SubtreeHash(leaves[k:]))
+}
+
+// rfc6962AuditPath calcola PATH(m, D[n])
+func rfc6962AuditPath(m int, leaves []HexString) []HexString {
+	if len(leaves) <= 1 {
+		return []HexString{}
+	}
+	k := rfc6962Split(len(leaves))
+	if m < k {
+		return append(rfc6962AuditPath(m, leaves[:k]), rfc6962SubtreeHash(leaves[k:]))
+	}
+	return append(rfc6962AuditPath(m-k, leaves[k:]), rfc6962SubtreeHash(leaves[:k]))
+}
+
+// rfc6962SubProof calcola SUBPROOF(m, D[n], b)
+func rfc6962SubProof(m int, leaves []HexString, complete bool) []HexString {
+	if m == len(leaves) {
+		if complete {
+			return []HexString{}
+		}
+		return []HexString{rfc6962SubtreeHash(leaves)}
+	}
+	k := rfc6962Split(len(leaves))
+	if m <= k {
+		return append(rfc6962SubProof(m, leaves[:k], complete), rfc6962SubtreeHash(leaves[k:]))
+	}
+	return append(rfc6962SubProof(m-k, leaves[k:], false), rfc6962SubtreeHash(leaves[:k]))
+}
+
+// rfc6962Split restituisce la più grande potenza di due strettamente minore di n (n > 1)
+func rfc6962Split(n int) int {
+	return 1 << (bits.Len64(uint64(n-1)) - 1)
+}
+
+// sha256Hex calcola lo SHA-256 della concatenazione delle parti
+func sha256Hex(parts ...[]byte) HexString {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+	}
+	hashed, _ := ToHex(hash.Sum(nil))
+	return hashed
+}
@@ -0,0 +1,179 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Voci e risultati dei vettori di test di RFC 9162 / Certificate Transparency
+var rfc6962Entries = []string{
+	"",
+	"00",
+	"10",
+	"2021",
+	"3031",
+	"40414243",
+	"5051525354555657",
+	"606162636465666768696a6b6c6d6e6f",
+}
+
+var rfc6962Roots = []HexString{
+	"0x6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+	"0xfac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	"0xaeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+	"0xd37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	"0x4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+	"0x76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+	"0xddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+	"0x5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+}
+
+var rfc6962InclusionVectors = []struct {
+	index, size int
+	proof       []HexString
+}{
+	{0, 1, nil},
+	{0, 8, []HexString{
+		"0x96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+		"0x5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"0x6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+	}},
+	{5, 8, []HexString{
+		"0xbc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		"0xca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+		"0xd37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	}},
+	{2, 3, []HexString{
+		"0xfac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	}},
+	{1, 5, []HexString{
+		"0x6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"0x5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"0xbc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+	}},
+}
+
+var rfc6962ConsistencyVectors = []struct {
+	m, n  int
+	proof []HexString
+}{
+	{1, 1, nil},
+	{1, 8, []HexString{
+		"0x96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+		"0x5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"0x6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+	}},
+	{6, 8, []HexString{
+		"0x0ebc5d3437fbe2db158b9f126a1d118e308181031d0a949f8dededebc558ef6a",
+		"0xca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+		"0xd37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	}},
+	{2, 5, []HexString{
+		"0x5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"0xbc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+	}},
+}
+
+func rfc6962TestTree(t *testing.T) *RFC6962MerkleTree {
+	t.Helper()
+	entries := make([]BytesLike, len(rfc6962Entries))
+	for i, entry := range rfc6962Entries {
+		data, err := hex.DecodeString(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[i] = data
+	}
+	return NewRFC6962MerkleTree(entries)
+}
+
+func equalProofs(a []HexString, b []HexString) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRFC6962Roots(t *testing.T) {
+	tree := rfc6962TestTree(t)
+	if empty := RFC6962EmptyRoot(); empty != "0xe3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatalf("root vuota %s", empty)
+	}
+	for i, expected := range rfc6962Roots {
+		root, err := tree.RootAt(i + 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != expected {
+			t.Errorf("dimensione %d: root %s, attesa %s", i+1, root, expected)
+		}
+	}
+}
+
+func TestRFC6962InclusionVectors(t *testing.T) {
+	tree := rfc6962TestTree(t)
+	for _, vector := range rfc6962InclusionVectors {
+		proof, err := tree.GetInclusionProof(vector.index, vector.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalProofs(proof, vector.proof) {
+			t.Errorf("PATH(%d, D[%d]) = %v, atteso %v", vector.index, vector.size, proof, vector.proof)
+		}
+		root := rfc6962Roots[vector.size-1]
+		if !VerifyRFC6962Inclusion(root, tree.Leaves[vector.index], vector.index, vector.size, vector.proof) {
+			t.Errorf("PATH(%d, D[%d]) non verificata", vector.index, vector.size)
+		}
+		if vector.size > 1 && VerifyRFC6962Inclusion(root, tree.Leaves[vector.index], (vector.index+1)%vector.size, vector.size, vector.proof) {
+			t.Errorf("PATH(%d, D[%d]) accettata con un altro indice", vector.index, vector.size)
+		}
+	}
+}
+
+func TestRFC6962ConsistencyVectors(t *testing.T) {
+	tree := rfc6962TestTree(t)
+	for _, vector := range rfc6962ConsistencyVectors {
+		proof, err := tree.GetConsistencyProof(vector.m, vector.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalProofs(proof, vector.proof) {
+			t.Errorf("PROOF(%d, D[%d]) = %v, atteso %v", vector.m, vector.n, proof, vector.proof)
+		}
+		if !VerifyRFC6962Consistency(rfc6962Roots[vector.m-1], rfc6962Roots[vector.n-1], vector.m, vector.n, vector.proof) {
+			t.Errorf("PROOF(%d, D[%d]) non verificata", vector.m, vector.n)
+		}
+	}
+}
+
+func TestRFC6962AllProofsVerify(t *testing.T) {
+	tree := rfc6962TestTree(t)
+	for n := 1; n <= len(rfc6962Entries); n++ {
+		for index := 0; index < n; index++ {
+			proof, err := tree.GetInclusionProof(index, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyRFC6962Inclusion(rfc6962Roots[n-1], tree.Leaves[index], index, n, proof) {
+				t.Errorf("inclusione %d in %d non verificata", index, n)
+			}
+		}
+		for m := 1; m <= n; m++ {
+			proof, err := tree.GetConsistencyProof(m, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyRFC6962Consistency(rfc6962Roots[m-1], rfc6962Roots[n-1], m, n, proof) {
+				t.Errorf("consistenza %d -> %d non verificata", m, n)
+			}
+			if m < n && VerifyRFC6962Consistency(rfc6962Roots[n-1], rfc6962Roots[m-1], m, n, proof) {
+				t.Errorf("consistenza %d -> %d accettata con le root invertite", m, n)
+			}
+		}
+	}
+}
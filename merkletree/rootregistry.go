@@ -0,0 +1,306 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// rootRegistryIndex è il file con i metadati di tutte le versioni
+const rootRegistryIndex = "index.json"
+
+// RootVersion contiene i metadati di una versione pubblicata dell'albero
+type RootVersion struct {
+	Epoch      uint64    `json:"epoch"`
+	Root       HexString `json:"root"`
+	CreatedAt  time.Time `json:"createdAt"`
+	SourceHash string    `json:"sourceHash"` // SHA-256 del file da cui è stato costruito l'albero
+	LeafCount  int       `json:"leafCount"`
+	File       string    `json:"file"` // Dump JSON dell'albero, relativo alla directory del registro
+}
+
+// PrunePolicy definisce quali versioni conservare. Una versione viene conservata solo se
+// rispetta tutti i limiti impostati (quelli a zero sono ignorati); l'ultima versione
+// non viene mai rimossa.
+type PrunePolicy struct {
+	KeepLast int           // Numero di versioni più recenti da conservare
+	MaxAge   time.Duration // Età massima delle versioni, rispetto a CreatedAt
+}
+
+// RootRegistry memorizza su disco ogni versione pubblicata di un albero, con i relativi
+// metadati, e genera proof per qualsiasi root o epoca ancora conservata.
+// Gli alberi vengono caricati dal disco alla prima richiesta e poi tenuti in cache.
+type RootRegistry[T any] struct {
+	mu       sync.RWMutex
+	dir      string
+	versions []RootVersion // In ordine di epoca crescente
+	cache    *TreeRegistry[T]
+}
+
+// OpenRootRegistry apre (o crea) un registro nella directory indicata
+func OpenRootRegistry[T any](dir string) (*RootRegistry[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	registry := &RootRegistry[T]{dir: dir, cache: NewTreeRegistry[T]()}
+
+	data, err := os.ReadFile(filepath.Join(dir, rootRegistryIndex))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return registry, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &registry.versions); err != nil {
+		return nil, fmt.Errorf("indice del registro non valido: %w", err)
+	}
+	return registry, nil
+}
+
+// HashSourceFile calcola lo SHA-256 di un file sorgente, da usare come SourceHash
+func HashSourceFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Publish salva una nuova versione dell'albero. L'epoca deve essere maggiore di quella
+// dell'ultima versione pubblicata.
+func (r *RootRegistry[T]) Publish(epoch uint64, tree *StandardMerkleTree[T], sourceHash string) (RootVersion, error) {
+	handle, err := NewTreeHandle(&tree.MerkleTreeImpl)
+	if err != nil {
+		return RootVersion{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.versions) > 0 && epoch <= r.versions[len(r.versions)-1].Epoch {
+		return RootVersion{}, fmt.Errorf("l'epoca %d non è successiva all'ultima pubblicata (%d)", epoch, r.versions[len(r.versions)-1].Epoch)
+	}
+
+	data, err := json.Marshal(handle.Dump())
+	if err != nil {
+		return RootVersion{}, err
+	}
+	version := RootVersion{
+		Epoch:      epoch,
+		Root:       handle.Root(),
+		CreatedAt:  time.Now().UTC(),
+		SourceHash: sourceHash,
+		LeafCount:  handle.Len(),
+		File:       fmt.Sprintf("epoch-%012d.json", epoch),
+	}
+	if err := writeFileAtomic(filepath.Join(r.dir, version.File), data); err != nil {
+		return RootVersion{}, err
+	}
+	if err := r.writeIndex(append(r.versions, version)); err != nil {
+		os.Remove(filepath.Join(r.dir, version.File))
+		return RootVersion{}, err
+	}
+
+	r.versions = append(r.versions, version)
+	r.cache.Add(handle)
+	return version, nil
+}
+
+// Versions restituisce i metadati di tutte le versioni conservate, in ordine di epoca
+func (r *RootRegistry[T]) Versions() []RootVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]RootVersion(nil), r.versions...)
+}
+
+// Latest restituisce l'ultima versione pubblicata
+func (r *RootRegistry[T]) Latest() (RootVersion, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.versions) == 0 {
+		return RootVersion{}, false
+	}
+	return r.versions[len(r.versions)-1], true
+}
+
+// VersionByEpoch restituisce la versione pubblicata per l'epoca data
+func (r *RootRegistry[T]) VersionByEpoch(epoch uint64) (RootVersion, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, version := range r.versions {
+		if version.Epoch == epoch {
+			return version, true
+		}
+	}
+	return RootVersion{}, false
+}
+
+// VersionByRoot restituisce la versione più recente con la root data
+func (r *RootRegistry[T]) VersionByRoot(root HexString) (RootVersion, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		if r.versions[i].Root == root {
+			return r.versions[i], true
+		}
+	}
+	return RootVersion{}, false
+}
+
+// Tree restituisce l'albero di una versione, caricandolo dal disco se necessario
+func (r *RootRegistry[T]) Tree(version RootVersion) (*TreeHandle[T], error) {
+	if handle, found := r.cache.Get(version.Root); found {
+		return handle, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, version.File))
+	if err != nil {
+		return nil, err
+	}
+	var dump StandardMerkleTreeData[T]
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, fmt.Errorf("dump dell'epoca %d non valido: %w", version.Epoch, err)
+	}
+	tree, err := LoadStandardMerkleTree(dump)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Root() != version.Root {
+		return nil, fmt.Errorf("la root del dump dell'epoca %d (%s) non corrisponde ai metadati (%s)", version.Epoch, tree.Root(), version.Root)
+	}
+	// La root da sola non basta: nodi interni e valori potrebbero essere stati modificati
+	if !IsValidMerkleTree(tree.Tree, tree.nodeHash()) {
+		return nil, fmt.Errorf("il dump dell'epoca %d contiene nodi non coerenti con i figli", version.Epoch)
+	}
+	for i, v := range tree.Values {
+		if tree.LeafHash(v.Value) != tree.Tree[v.TreeIndex] {
+			return nil, fmt.Errorf("il valore %d del dump dell'epoca %d non corrisponde alla sua foglia", i, version.Epoch)
+		}
+	}
+	handle, err := NewTreeHandle(&tree.MerkleTreeImpl)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Add(handle)
+	return handle, nil
+}
+
+// GetProofByRoot genera la proof di un valore nell'albero con la root data
+func (r *RootRegistry[T]) GetProofByRoot(root HexString, value T) ([]HexString, error) {
+	version, found := r.VersionByRoot(root)
+	if !found {
+		return nil, fmt.Errorf("nessuna versione con root %s", root)
+	}
+	return r.getProof(version, value)
+}
+
+// GetProofByEpoch genera la proof di un valore nell'albero pubblicato per l'epoca data
+func (r *RootRegistry[T]) GetProofByEpoch(epoch uint64, value T) ([]HexString, error) {
+	version, found := r.VersionByEpoch(epoch)
+	if !found {
+		return nil, fmt.Errorf("nessuna versione per l'epoca %d", epoch)
+	}
+	return r.getProof(version, value)
+}
+
+func (r *RootRegistry[T]) getProof(version RootVersion, value T) ([]HexString, error) {
+	handle, err := r.Tree(version)
+	if err != nil {
+		return nil, err
+	}
+	return handle.GetProofByValue(value)
+}
+
+// Prune rimuove le versioni che non rispettano la policy e restituisce quelle rimosse
+func (r *RootRegistry[T]) Prune(policy PrunePolicy, now time.Time) ([]RootVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var kept, removed []RootVersion
+	for i, version := range r.versions {
+		fromLatest := len(r.versions) - 1 - i
+		keep := fromLatest == 0 ||
+			((policy.KeepLast <= 0 || fromLatest < policy.KeepLast) &&
+				(policy.MaxAge <= 0 || now.Sub(version.CreatedAt) <= policy.MaxAge))
+		if keep {
+			kept = append(kept, version)
+		} else {
+			removed = append(removed, version)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	// Prima aggiorniamo l'indice, così un errore non lascia versioni senza file
+	if err := r.writeIndex(kept); err != nil {
+		return nil, err
+	}
+	r.versions = kept
+
+	var errs []error
+	for _, version := range removed {
+		if err := os.Remove(filepath.Join(r.dir, version.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		if !r.rootKept(version.Root) {
+			r.cache.Remove(version.Root)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// rootKept indica se una root è ancora usata da una versione conservata
+func (r *RootRegistry[T]) rootKept(root HexString) bool {
+	for _, version := range r.versions {
+		if version.Root == root {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIndex salva i metadati delle versioni
+func (r *RootRegistry[T]) writeIndex(versions []RootVersion) error {
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(r.dir, rootRegistryIndex), data)
+}
+
+// writeFileAtomic scrive un file passando da un file temporaneo, così non resta mai a metà
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
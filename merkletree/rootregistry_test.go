@@ -0,0 +1,178 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func publishTestVersions(t *testing.T, registry *RootRegistry[string], epochs ...uint64) []RootVersion {
+	t.Helper()
+	var versions []RootVersion
+	for _, epoch := range epochs {
+		tree := NewStandardMerkleTree(testValues(4+int(epoch)), MerkleTreeOptions{})
+		version, err := registry.Publish(epoch, tree, "sorgente")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version.Root != tree.Root() || version.LeafCount != 4+int(epoch) {
+			t.Fatalf("metadati dell'epoca %d non validi: %+v", epoch, version)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func TestRootRegistryPublishAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := OpenRootRegistry[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := publishTestVersions(t, registry, 1, 2, 3)
+	if _, err := registry.Publish(3, NewStandardMerkleTree(testValues(2), MerkleTreeOptions{}), ""); err == nil {
+		t.Fatal("pubblicata un'epoca non successiva all'ultima")
+	}
+
+	// Un nuovo registro sulla stessa directory carica gli alberi dal disco
+	reopened, err := OpenRootRegistry[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, found := reopened.Latest(); !found || latest.Epoch != 3 {
+		t.Fatalf("ultima versione %+v", latest)
+	}
+	for _, version := range versions {
+		proof, err := reopened.GetProofByEpoch(version.Epoch, "valore-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bytesProof := make([]BytesLike, len(proof))
+		for i, node := range proof {
+			bytesProof[i] = node
+		}
+		if !VerifyStandardMerkleTree(version.Root, "valore-1", bytesProof) {
+			t.Fatalf("proof dell'epoca %d non valida", version.Epoch)
+		}
+		if _, err := reopened.GetProofByRoot(version.Root, "valore-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := reopened.GetProofByEpoch(4, "valore-1"); err == nil {
+		t.Fatal("proof generata per un'epoca non pubblicata")
+	}
+	if _, err := reopened.GetProofByEpoch(1, "assente"); err == nil {
+		t.Fatal("proof generata per un valore assente")
+	}
+}
+
+func TestRootRegistryRejectsTamperedDump(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := OpenRootRegistry[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := publishTestVersions(t, registry, 1, 2)
+
+	// Sostituisce il dump dell'epoca 1 con quello dell'epoca 2
+	data, err := os.ReadFile(filepath.Join(dir, versions[1].File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, versions[0].File), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenRootRegistry[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.GetProofByEpoch(1, "valore-1"); err == nil {
+		t.Fatal("dump con una root diversa dai metadati accettato")
+	}
+
+	// Manomissioni che lasciano invariata la root: un valore e una foglia dell'epoca 2
+	tampers := map[string]func(*StandardMerkleTreeData[string]){
+		"valore": func(d *StandardMerkleTreeData[string]) { d.Values[0].Value = "manomesso" },
+		"foglia": func(d *StandardMerkleTreeData[string]) { d.Tree[len(d.Tree)-1] = StandardLeafHash("manomesso") },
+	}
+	for name, tamper := range tampers {
+		var dump StandardMerkleTreeData[string]
+		if err := json.Unmarshal(data, &dump); err != nil {
+			t.Fatal(err)
+		}
+		tamper(&dump)
+		tampered, err := json.Marshal(dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, versions[1].File), tampered, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		reopened, err := OpenRootRegistry[string](dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := reopened.Tree(versions[1]); err == nil {
+			t.Fatalf("dump con %s manomesso accettato", name)
+		}
+	}
+}
+
+func TestRootRegistryPrune(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := OpenRootRegistry[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := publishTestVersions(t, registry, 1, 2, 3, 4)
+
+	removed, err := registry.Prune(PrunePolicy{KeepLast: 2}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0].Epoch != 1 || removed[1].Epoch != 2 {
+		t.Fatalf("versioni rimosse %+v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, versions[0].File)); !os.IsNotExist(err) {
+		t.Fatal("il dump di una versione rimossa esiste ancora")
+	}
+	if _, err := registry.GetProofByEpoch(1, "valore-1"); err == nil {
+		t.Fatal("proof generata per una versione rimossa")
+	}
+
+	// L'ultima versione non viene mai rimossa, anche se troppo vecchia
+	removed, err = registry.Prune(PrunePolicy{MaxAge: time.Minute}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Epoch != 3 {
+		t.Fatalf("versioni rimosse %+v", removed)
+	}
+	if remaining := registry.Versions(); len(remaining) != 1 || remaining[0].Epoch != 4 {
+		t.Fatalf("versioni conservate %+v", remaining)
+	}
+
+	reopened, err := OpenRootRegistry[string](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := reopened.Versions(); len(remaining) != 1 || remaining[0].Epoch != 4 {
+		t.Fatalf("indice su disco non aggiornato: %+v", remaining)
+	}
+}
+
+func TestHashSourceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sorgente.csv")
+	if err := os.WriteFile(path, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashSourceFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("SHA-256 %s", hash)
+	}
+}
@@ -23,7 +23,9 @@ type SimpleMerkleTreeData struct {
 		Value     BytesLike
 		TreeIndex int
 	}
-	Hash string
+	Hash       string
+	FixedDepth int       `json:",omitempty"`
+	ZeroLeaf   HexString `json:",omitempty"`
 }
 
 // FormatLeaf converte un valore in un formato hashato per l'inserimento nel Merkle Tree
@@ -54,6 +56,7 @@ func NewSimpleMerkleTree(values []BytesLike, options SimpleMerkleTreeOptions) *S
 			LeafHash:   FormatLeaf,
 			NodeHash:   options.NodeHash,
 			HashLookup: hashLookup, // 🔹 Ora contiene tutti i valori correttamente
+			Options:    options.MerkleTreeOptions,
 		},
 	}
 }
@@ -73,14 +76,14 @@ func VerifySimpleMerkleTree(root BytesLike, leaf BytesLike, proof []BytesLike, n
 	// Confronto tra root derivata e attesa
 	computedRootVal, err := ToHex(computedRoot)
 	if err != nil {
-		fmt.Errorf("Error: ", err)
+		Logger().Warn("conversione della root derivata non riuscita", "err", err)
 	}
 	rootVal, err := ToHex(root)
 	if err != nil {
-		fmt.Errorf("Error: ", err)
+		Logger().Warn("conversione della root attesa non riuscita", "err", err)
 	}
 	if computedRootVal != rootVal {
-		fmt.Println("❌ ERRORE: Root derivata e root attesa non corrispondono!")
+		Logger().Debug("root derivata e root attesa non corrispondono", "derivata", computedRootVal, "attesa", rootVal)
 	}
 	return computedRootVal == rootVal
 }
@@ -88,9 +91,38 @@ func VerifySimpleMerkleTree(root BytesLike, leaf BytesLike, proof []BytesLike, n
 // Dump esporta i dati dell'albero per debugging o archiviazione
 func (m *SimpleMerkleTree) Dump() SimpleMerkleTreeData {
 	return SimpleMerkleTreeData{
-		Format: "simple-v1",
-		Tree:   m.Tree,
-		Values: m.Values,
-		Hash:   "custom",
+		Format:     "simple-v1",
+		Tree:       m.Tree,
+		Values:     m.Values,
+		FixedDepth: m.Options.FixedDepth,
+		ZeroLeaf:   m.Options.ZeroLeaf,
+		Hash:       "custom",
 	}
 }
+
+// LoadSimpleMerkleTree ricostruisce un SimpleMerkleTree a partire dai dati esportati con Dump
+func LoadSimpleMerkleTree(data SimpleMerkleTreeData, nodeHash NodeHash) (*SimpleMerkleTree, error) {
+	if data.Format != "simple-v1" {
+		return nil, fmt.Errorf("formato non supportato: %q", data.Format)
+	}
+	if err := checkDumpIndices(data.Tree, len(data.Values), func(i int) int { return data.Values[i].TreeIndex }); err != nil {
+		return nil, err
+	}
+	if err := checkFixedDepthTree(data.Tree, data.FixedDepth); err != nil {
+		return nil, err
+	}
+	options := NewMerkleTreeOptions(&MerkleTreeOptions{FixedDepth: data.FixedDepth, ZeroLeaf: data.ZeroLeaf})
+
+	simpleTree := &SimpleMerkleTree{
+		MerkleTreeImpl[BytesLike]{
+			Tree:     data.Tree,
+			Values:   data.Values,
+			LeafHash: FormatLeaf,
+			NodeHash: nodeHash,
+			Options:  options,
+		},
+	}
+	simpleTree.rebuildHashLookup()
+
+	return simpleTree, nil
+}
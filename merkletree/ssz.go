@@ -0,0 +1,476 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// sszChunkSize è la dimensione di un chunk SSZ
+const sszChunkSize = 32
+
+// sszMaxDepth è la profondità massima dei sottoalberi di zeri precalcolati
+const sszMaxDepth = 64
+
+// SSZValue è un valore SSZ di cui si può calcolare hash_tree_root
+type SSZValue interface {
+	// HashTreeRoot restituisce hash_tree_root(value)
+	HashTreeRoot() HexString
+	// sszTree restituisce l'albero di merkleizzazione del valore
+	sszTree() *sszNode
+}
+
+// sszBasic è un tipo base (uintN, boolean): viene impacchettato nei chunk insieme ai vicini
+type sszBasic interface {
+	SSZValue
+	sszSerialize() []byte
+}
+
+// sszComposite è un tipo composto in cui si può navigare con un generalized index
+type sszComposite interface {
+	SSZValue
+	sszIsList() bool       // Le liste hanno la lunghezza mescolata nella root (mix_in_length)
+	sszChunkLimit() uint64 // Numero di chunk (massimo, per le liste) del contenuto
+	sszItem(index int) (uint64, SSZValue, error)
+}
+
+// sszNode è un nodo dell'albero SSZ; i sottoalberi di zeri hanno figli impliciti
+type sszNode struct {
+	hash        [32]byte
+	left, right *sszNode
+	zeroDepth   int // Altezza del sottoalbero di zeri (-1 se il nodo non è un sottoalbero di zeri)
+}
+
+var sszZeroNodes = func() []*sszNode {
+	nodes := make([]*sszNode, sszMaxDepth+1)
+	nodes[0] = &sszNode{}
+	for i := 1; i <= sszMaxDepth; i++ {
+		nodes[i] = &sszNode{hash: sszHash(nodes[i-1].hash, nodes[i-1].hash), zeroDepth: i}
+	}
+	return nodes
+}()
+
+// children restituisce i figli del nodo (nil se è un chunk)
+func (n *sszNode) children() (*sszNode, *sszNode) {
+	if n.left != nil {
+		return n.left, n.right
+	}
+	if n.zeroDepth > 0 {
+		return sszZeroNodes[n.zeroDepth-1], sszZeroNodes[n.zeroDepth-1]
+	}
+	return nil, nil
+}
+
+func sszLeaf(chunk [32]byte) *sszNode {
+	return &sszNode{hash: chunk, zeroDepth: -1}
+}
+
+func sszPair(left *sszNode, right *sszNode) *sszNode {
+	return &sszNode{hash: sszHash(left.hash, right.hash), left: left, right: right, zeroDepth: -1}
+}
+
+// sszHash calcola SHA-256(left || right)
+func sszHash(left [32]byte, right [32]byte) [32]byte {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
+
+// SSZMerkleize calcola merkleize(chunks, limit) come nella specifica SSZ: i chunk vengono
+// completati con zeri fino alla potenza di due successiva a `limit` (o al numero di chunk
+// se `limit` è 0)
+func SSZMerkleize(chunks []HexString, limit uint64) (HexString, error) {
+	nodes := make([]*sszNode, len(chunks))
+	for i, chunk := range chunks {
+		node, err := toCMTNode(chunk)
+		if err != nil {
+			return "", fmt.Errorf("chunk %d non valido: %w", i, err)
+		}
+		nodes[i] = sszLeaf(node)
+	}
+	if limit == 0 {
+		limit = uint64(len(chunks))
+	}
+	root, err := sszMerkleize(nodes, limit)
+	if err != nil {
+		return "", err
+	}
+	return cmtNode(root.hash).hex(), nil
+}
+
+// sszMerkleize costruisce l'albero dei chunk; le parti vuote sono sottoalberi di zeri impliciti
+func sszMerkleize(chunks []*sszNode, limit uint64) (*sszNode, error) {
+	if uint64(len(chunks)) > limit && limit > 0 {
+		return nil, fmt.Errorf("%d chunk superano il limite di %d", len(chunks), limit)
+	}
+	return sszSubtree(chunks, sszDepth(limit)), nil
+}
+
+func sszSubtree(chunks []*sszNode, depth int) *sszNode {
+	if len(chunks) == 0 {
+		return sszZeroNodes[depth]
+	}
+	if depth == 0 {
+		return chunks[0]
+	}
+	half := 1 << (depth - 1)
+	if len(chunks) <= half {
+		return sszPair(sszSubtree(chunks, depth-1), sszZeroNodes[depth-1])
+	}
+	return sszPair(sszSubtree(chunks[:half], depth-1), sszSubtree(chunks[half:], depth-1))
+}
+
+// sszDepth restituisce la profondità dell'albero per `count` chunk: ceil(log2(count))
+func sszDepth(count uint64) int {
+	if count <= 1 {
+		return 0
+	}
+	return bits.Len64(count - 1)
+}
+
+// sszMixInLength calcola mix_in_length(root, length)
+func sszMixInLength(root *sszNode, length uint64) *sszNode {
+	var chunk [32]byte
+	binary.LittleEndian.PutUint64(chunk[:8], length)
+	return sszPair(root, sszLeaf(chunk))
+}
+
+// sszPack impacchetta dati serializzati in chunk da 32 byte, con zeri a destra
+func sszPack(data []byte) []*sszNode {
+	chunks := make([]*sszNode, 0, (len(data)+sszChunkSize-1)/sszChunkSize)
+	for start := 0; start < len(data); start += sszChunkSize {
+		var chunk [32]byte
+		copy(chunk[:], data[start:])
+		chunks = append(chunks, sszLeaf(chunk))
+	}
+	return chunks
+}
+
+// sszPackBits impacchetta i bit in little-endian, senza il bit delimitatore delle bitlist
+func sszPackBits(bitValues []bool) []*sszNode {
+	data := make([]byte, (len(bitValues)+7)/8)
+	for i, bit := range bitValues {
+		if bit {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return sszPack(data)
+}
+
+// sszUint è un intero senza segno SSZ di `size` byte
+type sszUint struct {
+	size  int
+	value []byte // Little-endian
+}
+
+// SSZUint crea un uintN SSZ con N = 8*size (size tra 1 e 32, potenza di due)
+func SSZUint(size int, value *big.Int) (SSZValue, error) {
+	if size < 1 || size > 32 || size&(size-1) != 0 {
+		return nil, fmt.Errorf("dimensione %d non valida per un uint SSZ", size)
+	}
+	if value == nil || value.Sign() < 0 || value.BitLen() > 8*size {
+		return nil, fmt.Errorf("valore non rappresentabile come uint%d", 8*size)
+	}
+	encoded := value.FillBytes(make([]byte, size))
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return sszUint{size: size, value: encoded}, nil
+}
+
+// SSZUint64 crea un uint64 SSZ
+func SSZUint64(value uint64) SSZValue {
+	encoded := make([]byte, 8)
+	binary.LittleEndian.PutUint64(encoded, value)
+	return sszUint{size: 8, value: encoded}
+}
+
+// SSZUint8 crea un uint8 SSZ (un byte)
+func SSZUint8(value uint8) SSZValue {
+	return sszUint{size: 1, value: []byte{value}}
+}
+
+// SSZBoolean crea un boolean SSZ
+func SSZBoolean(value bool) SSZValue {
+	if value {
+		return sszUint{size: 1, value: []byte{1}}
+	}
+	return sszUint{size: 1, value: []byte{0}}
+}
+
+func (u sszUint) sszSerialize() []byte { return u.value }
+func (u sszUint) sszTree() *sszNode    { return sszPack(u.value)[0] }
+func (u sszUint) HashTreeRoot() HexString {
+	return cmtNode(u.sszTree().hash).hex()
+}
+
+// SSZBytes32 crea un Bytes32 (Vector[byte, 32]), come root e hash nei container
+func SSZBytes32(value BytesLike) (SSZValue, error) {
+	data, err := ToBytes(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 32 {
+		return nil, fmt.Errorf("Bytes32 di %d byte", len(data))
+	}
+	return SSZByteVector(data)
+}
+
+// SSZByteVector crea un Vector[byte, N] con N = len(data)
+func SSZByteVector(data []byte) (SSZValue, error) {
+	elements := make([]SSZValue, len(data))
+	for i, b := range data {
+		elements[i] = SSZUint8(b)
+	}
+	return SSZVector(elements...)
+}
+
+// SSZByteList crea un List[byte, limit]
+func SSZByteList(limit uint64, data []byte) (SSZValue, error) {
+	elements := make([]SSZValue, len(data))
+	for i, b := range data {
+		elements[i] = SSZUint8(b)
+	}
+	return SSZBasicList(limit, 1, elements...)
+}
+
+// sszSequence è un vector o una lista di elementi
+type sszSequence struct {
+	elements    []SSZValue
+	elementSize int    // Dimensione degli elementi base (0 per gli elementi composti)
+	limit       uint64 // Capacità della lista (0 per i vector)
+	tree        *sszNode
+}
+
+// SSZVector crea un Vector[T, N] con N = len(elements). Gli elementi base vengono
+// impacchettati nei chunk, quelli composti contribuiscono con la loro root.
+func SSZVector(elements ...SSZValue) (SSZValue, error) {
+	if len(elements) == 0 {
+		return nil, errors.New("un vector SSZ non può essere vuoto")
+	}
+	elementSize := 0
+	if basic, ok := elements[0].(sszBasic); ok {
+		elementSize = len(basic.sszSerialize())
+	}
+	return newSSZSequence(elements, elementSize, 0)
+}
+
+// SSZList crea un List[T, limit] di elementi composti (container, vector, liste)
+func SSZList(limit uint64, elements ...SSZValue) (SSZValue, error) {
+	if limit == 0 {
+		return nil, errors.New("il limite di una lista SSZ deve essere positivo")
+	}
+	return newSSZSequence(elements, 0, limit)
+}
+
+// SSZBasicList crea un List[T, limit] di elementi base di `elementSize` byte
+func SSZBasicList(limit uint64, elementSize int, elements ...SSZValue) (SSZValue, error) {
+	if limit == 0 {
+		return nil, errors.New("il limite di una lista SSZ deve essere positivo")
+	}
+	if elementSize < 1 || elementSize > 32 {
+		return nil, fmt.Errorf("dimensione degli elementi %d non valida", elementSize)
+	}
+	return newSSZSequence(elements, elementSize, limit)
+}
+
+func newSSZSequence(elements []SSZValue, elementSize int, limit uint64) (*sszSequence, error) {
+	if limit > 0 && uint64(len(elements)) > limit {
+		return nil, fmt.Errorf("%d elementi superano il limite di %d", len(elements), limit)
+	}
+	sequence := &sszSequence{elements: elements, elementSize: elementSize, limit: limit}
+
+	var chunks []*sszNode
+	if elementSize > 0 {
+		data := make([]byte, 0, len(elements)*elementSize)
+		for i, element := range elements {
+			basic, ok := element.(sszBasic)
+			if !ok || len(basic.sszSerialize()) != elementSize {
+				return nil, fmt.Errorf("l'elemento %d non è un tipo base di %d byte", i, elementSize)
+			}
+			data = append(data, basic.sszSerialize()...)
+		}
+		chunks = sszPack(data)
+	} else {
+		chunks = make([]*sszNode, len(elements))
+		for i, element := range elements {
+			if _, ok := element.(sszBasic); ok {
+				return nil, fmt.Errorf("l'elemento %d è un tipo base: usare SSZBasicList", i)
+			}
+			chunks[i] = element.sszTree()
+		}
+	}
+
+	tree, err := sszMerkleize(chunks, sequence.sszChunkLimit())
+	if err != nil {
+		return nil, err
+	}
+	if sequence.sszIsList() {
+		tree = sszMixInLength(tree, uint64(len(elements)))
+	}
+	sequence.tree = tree
+	return sequence, nil
+}
+
+func (s *sszSequence) sszTree() *sszNode { return s.tree }
+func (s *sszSequence) sszIsList() bool   { return s.limit > 0 }
+func (s *sszSequence) HashTreeRoot() HexString {
+	return cmtNode(s.tree.hash).hex()
+}
+
+func (s *sszSequence) sszChunkLimit() uint64 {
+	count := uint64(len(s.elements))
+	if s.sszIsList() {
+		count = s.limit
+	}
+	if s.elementSize > 0 {
+		return (count*uint64(s.elementSize) + sszChunkSize - 1) / sszChunkSize
+	}
+	return count
+}
+
+// sszItem restituisce la posizione del chunk che contiene l'elemento e, per gli elementi
+// composti, l'elemento stesso (gli elementi base condividono il chunk con i vicini)
+func (s *sszSequence) sszItem(index int) (uint64, SSZValue, error) {
+	capacity := uint64(len(s.elements))
+	if s.sszIsList() {
+		capacity = s.limit
+	}
+	if index < 0 || uint64(index) >= capacity {
+		return 0, nil, fmt.Errorf("indice %d fuori dai limiti (capacità %d)", index, capacity)
+	}
+	var element SSZValue
+	if index < len(s.elements) {
+		element = s.elements[index]
+	}
+	if s.elementSize > 0 {
+		return uint64(index) * uint64(s.elementSize) / sszChunkSize, nil, nil
+	}
+	return uint64(index), element, nil
+}
+
+// sszBits è un Bitvector[N] o una Bitlist[N]
+type sszBits struct {
+	bits  []bool
+	limit uint64 // Capacità della bitlist (0 per i bitvector)
+	tree  *sszNode
+}
+
+// SSZBitvector crea un Bitvector[N] con N = len(bits)
+func SSZBitvector(bitValues []bool) (SSZValue, error) {
+	if len(bitValues) == 0 {
+		return nil, errors.New("un bitvector SSZ non può essere vuoto")
+	}
+	return newSSZBits(bitValues, 0)
+}
+
+// SSZBitlist crea una Bitlist[limit]
+func SSZBitlist(limit uint64, bitValues []bool) (SSZValue, error) {
+	if limit == 0 {
+		return nil, errors.New("il limite di una bitlist SSZ deve essere positivo")
+	}
+	if uint64(len(bitValues)) > limit {
+		return nil, fmt.Errorf("%d bit superano il limite di %d", len(bitValues), limit)
+	}
+	return newSSZBits(bitValues, limit)
+}
+
+// ParseSSZBitlist decodifica una Bitlist[limit] serializzata: i bit sono little-endian e
+// l'ultimo bit a 1 è il delimitatore che ne indica la lunghezza
+func ParseSSZBitlist(limit uint64, serialized []byte) (SSZValue, error) {
+	if len(serialized) == 0 || serialized[len(serialized)-1] == 0 {
+		return nil, errors.New("bitlist SSZ senza bit delimitatore")
+	}
+	last := serialized[len(serialized)-1]
+	length := uint64(len(serialized)-1) * 8
+	for last > 1 {
+		last >>= 1
+		length++
+	}
+	if length > limit {
+		return nil, fmt.Errorf("%d bit superano il limite di %d", length, limit)
+	}
+	bitValues := make([]bool, length)
+	for i := range bitValues {
+		bitValues[i] = serialized[i/8]>>(i%8)&1 == 1
+	}
+	return SSZBitlist(limit, bitValues)
+}
+
+func newSSZBits(bitValues []bool, limit uint64) (*sszBits, error) {
+	value := &sszBits{bits: append([]bool{}, bitValues...), limit: limit}
+	tree, err := sszMerkleize(sszPackBits(bitValues), value.sszChunkLimit())
+	if err != nil {
+		return nil, err
+	}
+	if value.sszIsList() {
+		tree = sszMixInLength(tree, uint64(len(bitValues)))
+	}
+	value.tree = tree
+	return value, nil
+}
+
+func (b *sszBits) sszTree() *sszNode { return b.tree }
+func (b *sszBits) sszIsList() bool   { return b.limit > 0 }
+func (b *sszBits) HashTreeRoot() HexString {
+	return cmtNode(b.tree.hash).hex()
+}
+
+func (b *sszBits) sszChunkLimit() uint64 {
+	count := uint64(len(b.bits))
+	if b.sszIsList() {
+		count = b.limit
+	}
+	return (count + 255) / 256
+}
+
+func (b *sszBits) sszItem(index int) (uint64, SSZValue, error) {
+	capacity := uint64(len(b.bits))
+	if b.sszIsList() {
+		capacity = b.limit
+	}
+	if index < 0 || uint64(index) >= capacity {
+		return 0, nil, fmt.Errorf("indice %d fuori dai limiti (capacità %d)", index, capacity)
+	}
+	return uint64(index) / 256, nil, nil
+}
+
+// sszContainer è un container SSZ: i campi sono identificati dalla loro posizione
+type sszContainer struct {
+	fields []SSZValue
+	tree   *sszNode
+}
+
+// SSZContainer crea un container con i campi dati, nell'ordine della definizione del tipo
+func SSZContainer(fields ...SSZValue) (SSZValue, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("un container SSZ deve avere almeno un campo")
+	}
+	chunks := make([]*sszNode, len(fields))
+	for i, field := range fields {
+		if field == nil {
+			return nil, fmt.Errorf("campo %d mancante", i)
+		}
+		chunks[i] = field.sszTree()
+	}
+	tree, err := sszMerkleize(chunks, uint64(len(fields)))
+	if err != nil {
+		return nil, err
+	}
+	return &sszContainer{fields: fields, tree: tree}, nil
+}
+
+func (c *sszContainer) sszTree() *sszNode     { return c.tree }
+func (c *sszContainer) sszIsList() bool       { return false }
+func (c *sszContainer) sszChunkLimit() uint64 { return uint64(len(c.fields)) }
+func (c *sszContainer) HashTreeRoot() HexString {
+	return cmtNode(c.tree.hash).hex()
+}
+
+func (c *sszContainer) sszItem(index int) (uint64, SSZValue, error) {
+	if index < 0 || index >= len(c.fields) {
+		return 0, nil, fmt.Errorf("campo %d inesistente (il container ha %d campi)", index, len(c.fields))
+	}
+	return uint64(index), c.fields[index], nil
+}
@@ -0,0 +1,261 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// SSZLengthPath è l'elemento di percorso che indica la lunghezza di una lista (`__len__`)
+const SSZLengthPath = "__len__"
+
+// SSZProof è una proof per un singolo nodo, identificato dal generalized index
+type SSZProof struct {
+	GeneralizedIndex uint64      `json:"generalizedIndex"`
+	Leaf             HexString   `json:"leaf"`
+	Branch           []HexString `json:"branch"` // Sibling dal basso verso la root
+}
+
+// SSZMultiProof è una proof per più nodi, con gli helper ordinati come get_helper_indices
+type SSZMultiProof struct {
+	Indices []uint64    `json:"indices"`
+	Leaves  []HexString `json:"leaves"`
+	Proof   []HexString `json:"proof"`
+}
+
+// SSZGeneralizedIndex calcola get_generalized_index(value, *path): ogni elemento del percorso
+// è la posizione di un campo o di un elemento (int), oppure SSZLengthPath per una lista
+func SSZGeneralizedIndex(value SSZValue, path ...interface{}) (uint64, error) {
+	root := uint64(1)
+	current := value
+	for i, step := range path {
+		composite, ok := current.(sszComposite)
+		if !ok {
+			return 0, fmt.Errorf("elemento %d del percorso: il valore non è un tipo composto", i)
+		}
+		if step == SSZLengthPath {
+			if !composite.sszIsList() {
+				return 0, fmt.Errorf("elemento %d del percorso: %s è valido solo per le liste", i, SSZLengthPath)
+			}
+			next, err := sszGeneralizedIndexChild(root, 2, 1)
+			if err != nil {
+				return 0, err
+			}
+			root, current = next, nil
+			continue
+		}
+		index, ok := step.(int)
+		if !ok {
+			return 0, fmt.Errorf("elemento %d del percorso non valido: %v", i, step)
+		}
+		position, element, err := composite.sszItem(index)
+		if err != nil {
+			return 0, fmt.Errorf("elemento %d del percorso: %w", i, err)
+		}
+		baseIndex := uint64(1)
+		if composite.sszIsList() {
+			baseIndex = 2
+		}
+		width := uint64(1) << sszDepth(composite.sszChunkLimit())
+		if root, err = sszGeneralizedIndexChild(root, baseIndex*width, position); err != nil {
+			return 0, err
+		}
+		current = element
+	}
+	return root, nil
+}
+
+// sszGeneralizedIndexChild calcola root * factor + position controllando l'overflow
+func sszGeneralizedIndexChild(root uint64, factor uint64, position uint64) (uint64, error) {
+	high, low := bits.Mul64(root, factor)
+	if high != 0 || low+position < low {
+		return 0, errors.New("generalized index oltre 64 bit")
+	}
+	return low + position, nil
+}
+
+// SSZNode restituisce il nodo con generalized index `gindex` nell'albero del valore
+func SSZNode(value SSZValue, gindex uint64) (HexString, error) {
+	node, _, err := sszWalk(value.sszTree(), gindex)
+	if err != nil {
+		return "", err
+	}
+	return cmtNode(node.hash).hex(), nil
+}
+
+// GetSSZProof restituisce la proof del nodo con generalized index `gindex`
+func GetSSZProof(value SSZValue, gindex uint64) (SSZProof, error) {
+	node, siblings, err := sszWalk(value.sszTree(), gindex)
+	if err != nil {
+		return SSZProof{}, err
+	}
+	proof := SSZProof{GeneralizedIndex: gindex, Leaf: cmtNode(node.hash).hex(), Branch: make([]HexString, len(siblings))}
+	for i, sibling := range siblings {
+		proof.Branch[len(siblings)-1-i] = cmtNode(sibling.hash).hex()
+	}
+	return proof, nil
+}
+
+// sszWalk scende dalla root seguendo i bit di `gindex` e restituisce il nodo e i sibling
+// incontrati, dalla root verso il basso
+func sszWalk(root *sszNode, gindex uint64) (*sszNode, []*sszNode, error) {
+	if gindex == 0 {
+		return nil, nil, errors.New("generalized index 0 non valido")
+	}
+	depth := bits.Len64(gindex) - 1
+	node := root
+	siblings := make([]*sszNode, 0, depth)
+	for i := depth - 1; i >= 0; i-- {
+		left, right := node.children()
+		if left == nil {
+			return nil, nil, fmt.Errorf("il generalized index %d va oltre le foglie dell'albero", gindex)
+		}
+		if (gindex>>i)&1 == 1 {
+			node = right
+			siblings = append(siblings, left)
+		} else {
+			node = left
+			siblings = append(siblings, right)
+		}
+	}
+	return node, siblings, nil
+}
+
+// VerifySSZProof verifica una proof come is_valid_merkle_branch
+func VerifySSZProof(root BytesLike, proof SSZProof) bool {
+	computed, err := CalculateSSZRoot(proof)
+	if err != nil {
+		return false
+	}
+	expected, err := ToHex(root)
+	return err == nil && computed == expected
+}
+
+// CalculateSSZRoot calcola la root a partire da una proof, come calculate_merkle_root
+func CalculateSSZRoot(proof SSZProof) (HexString, error) {
+	if proof.GeneralizedIndex == 0 || len(proof.Branch) != bits.Len64(proof.GeneralizedIndex)-1 {
+		return "", fmt.Errorf("proof di %d elementi non valida per il generalized index %d", len(proof.Branch), proof.GeneralizedIndex)
+	}
+	node, err := toCMTNode(proof.Leaf)
+	if err != nil {
+		return "", err
+	}
+	for i, branch := range proof.Branch {
+		sibling, err := toCMTNode(branch)
+		if err != nil {
+			return "", fmt.Errorf("elemento %d della proof non valido: %w", i, err)
+		}
+		if (proof.GeneralizedIndex>>i)&1 == 1 {
+			node = sszHash(sibling, node)
+		} else {
+			node = sszHash(node, sibling)
+		}
+	}
+	return node.hex(), nil
+}
+
+// SSZHelperIndices calcola get_helper_indices: i nodi necessari a verificare gli indici
+// dati, in ordine decrescente
+func SSZHelperIndices(indices []uint64) []uint64 {
+	helpers := make(map[uint64]bool)
+	paths := make(map[uint64]bool)
+	for _, index := range indices {
+		for i := index; i > 1; i /= 2 {
+			helpers[i^1] = true
+			paths[i] = true
+		}
+	}
+	result := make([]uint64, 0, len(helpers))
+	for index := range helpers {
+		if !paths[index] {
+			result = append(result, index)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] > result[j] })
+	return result
+}
+
+// GetSSZMultiProof restituisce una multiproof per i generalized index dati
+func GetSSZMultiProof(value SSZValue, indices []uint64) (SSZMultiProof, error) {
+	if len(indices) == 0 {
+		return SSZMultiProof{}, errors.New("nessun generalized index")
+	}
+	multiproof := SSZMultiProof{Indices: append([]uint64{}, indices...)}
+	for _, index := range indices {
+		leaf, err := SSZNode(value, index)
+		if err != nil {
+			return SSZMultiProof{}, err
+		}
+		multiproof.Leaves = append(multiproof.Leaves, leaf)
+	}
+	for _, index := range SSZHelperIndices(indices) {
+		node, err := SSZNode(value, index)
+		if err != nil {
+			return SSZMultiProof{}, err
+		}
+		multiproof.Proof = append(multiproof.Proof, node)
+	}
+	return multiproof, nil
+}
+
+// CalculateSSZMultiRoot calcola la root a partire da una multiproof, come
+// calculate_multi_merkle_root
+func CalculateSSZMultiRoot(multiproof SSZMultiProof) (HexString, error) {
+	if len(multiproof.Leaves) != len(multiproof.Indices) {
+		return "", fmt.Errorf("%d foglie per %d indici", len(multiproof.Leaves), len(multiproof.Indices))
+	}
+	helpers := SSZHelperIndices(multiproof.Indices)
+	if len(multiproof.Proof) != len(helpers) {
+		return "", fmt.Errorf("proof di %d elementi, attesi %d", len(multiproof.Proof), len(helpers))
+	}
+
+	objects := make(map[uint64]cmtNode, len(multiproof.Indices)+len(helpers))
+	keys := make([]uint64, 0, len(objects))
+	for i, index := range multiproof.Indices {
+		node, err := toCMTNode(multiproof.Leaves[i])
+		if err != nil {
+			return "", fmt.Errorf("foglia %d non valida: %w", i, err)
+		}
+		if index == 0 {
+			return "", errors.New("generalized index 0 non valido")
+		}
+		objects[index] = node
+	}
+	for i, index := range helpers {
+		node, err := toCMTNode(multiproof.Proof[i])
+		if err != nil {
+			return "", fmt.Errorf("elemento %d della proof non valido: %w", i, err)
+		}
+		objects[index] = node
+	}
+	for index := range objects {
+		keys = append(keys, index)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+
+	for pos := 0; pos < len(keys); pos++ {
+		k := keys[pos]
+		_, hasSibling := objects[k^1]
+		_, hasParent := objects[k/2]
+		if k > 1 && hasSibling && !hasParent {
+			objects[k/2] = sszHash(objects[(k|1)^1], objects[k|1])
+			keys = append(keys, k/2)
+		}
+	}
+	root, found := objects[1]
+	if !found {
+		return "", errors.New("la multiproof non permette di calcolare la root")
+	}
+	return root.hex(), nil
+}
+
+// VerifySSZMultiProof verifica una multiproof rispetto alla root attesa
+func VerifySSZMultiProof(root BytesLike, multiproof SSZMultiProof) bool {
+	computed, err := CalculateSSZMultiRoot(multiproof)
+	if err != nil {
+		return false
+	}
+	expected, err := ToHex(root)
+	return err == nil && computed == expected
+}
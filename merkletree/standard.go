@@ -1,6 +1,10 @@
 package merkletree
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // StandardMerkleTree rappresenta un Merkle Tree con encoding standard
 type StandardMerkleTree[T any] struct {
@@ -9,19 +13,35 @@ type StandardMerkleTree[T any] struct {
 
 // NewStandardMerkleTree crea un nuovo StandardMerkleTree con i valori dati
 func NewStandardMerkleTree[T any](values []T, options MerkleTreeOptions) *StandardMerkleTree[T] {
+	standardTree, err := NewStandardMerkleTreeContext(context.Background(), values, options, nil)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: %v", err))
+	}
+	return standardTree
+}
+
+// NewStandardMerkleTreeContext è come NewStandardMerkleTree, ma può essere annullato tramite
+// il contesto e segnala l'avanzamento di ogni fase a `progress` (se non nil)
+func NewStandardMerkleTreeContext[T any](ctx context.Context, values []T, options MerkleTreeOptions, progress ProgressFunc) (*StandardMerkleTree[T], error) {
 	options = NewMerkleTreeOptions(&options) // Usa le opzioni predefinite se non specificate
 
-	tree, indexedValues := PrepareMerkleTree(values, options, StandardLeafHash[T], StandardNodeHash)
+	tree, indexedValues, err := PrepareMerkleTreeContext(ctx, values, options, StandardLeafHash[T], StandardNodeHash, progress)
+	if err != nil {
+		return nil, err
+	}
 
-	return &StandardMerkleTree[T]{
+	standardTree := &StandardMerkleTree[T]{
 		MerkleTreeImpl: MerkleTreeImpl[T]{
-			Tree:       tree,
-			Values:     indexedValues,
-			LeafHash:   StandardLeafHash[T],
-			NodeHash:   StandardNodeHash,
-			HashLookup: make(map[HexString]int),
+			Tree:     tree,
+			Values:   indexedValues,
+			LeafHash: StandardLeafHash[T],
+			NodeHash: StandardNodeHash,
+			Options:  options,
 		},
 	}
+	standardTree.rebuildHashLookup()
+
+	return standardTree, nil
 }
 
 // Verify verifica una proof di Merkle per un valore specifico
@@ -32,7 +52,7 @@ func VerifyStandardMerkleTree[T any](root BytesLike, leaf T, proof []BytesLike)
 	computedRoot := ProcessProof(leafHash, proof, StandardNodeHash)
 	computedRootVal, err := ToHex(computedRoot)
 	if err != nil {
-		fmt.Errorf("Error: %s", err)
+		Logger().Warn("conversione della root non riuscita", "err", err)
 	}
 	rootVal, err := ToHex(root)
 
@@ -48,13 +68,58 @@ type StandardMerkleTreeData[T any] struct {
 		Value     T
 		TreeIndex int
 	}
+	FixedDepth int       `json:",omitempty"`
+	ZeroLeaf   HexString `json:",omitempty"`
 }
 
 // Dump esporta i dati dell'albero per debugging o archiviazione
 func (m *StandardMerkleTree[T]) Dump() StandardMerkleTreeData[T] {
 	return StandardMerkleTreeData[T]{
-		Format: "standard-v1",
-		Tree:   m.Tree,
-		Values: m.Values,
+		Format:     "standard-v1",
+		Tree:       m.Tree,
+		Values:     m.Values,
+		FixedDepth: m.Options.FixedDepth,
+		ZeroLeaf:   m.Options.ZeroLeaf,
+	}
+}
+
+// LoadStandardMerkleTree ricostruisce un StandardMerkleTree a partire dai dati esportati con Dump
+func LoadStandardMerkleTree[T any](data StandardMerkleTreeData[T]) (*StandardMerkleTree[T], error) {
+	if data.Format != "standard-v1" {
+		return nil, fmt.Errorf("formato non supportato: %q", data.Format)
+	}
+	if err := checkDumpIndices(data.Tree, len(data.Values), func(i int) int { return data.Values[i].TreeIndex }); err != nil {
+		return nil, err
+	}
+	if err := checkFixedDepthTree(data.Tree, data.FixedDepth); err != nil {
+		return nil, err
+	}
+	options := NewMerkleTreeOptions(&MerkleTreeOptions{FixedDepth: data.FixedDepth, ZeroLeaf: data.ZeroLeaf})
+
+	standardTree := &StandardMerkleTree[T]{
+		MerkleTreeImpl: MerkleTreeImpl[T]{
+			Tree:     data.Tree,
+			Values:   data.Values,
+			LeafHash: StandardLeafHash[T],
+			NodeHash: StandardNodeHash,
+			Options:  options,
+		},
+	}
+	standardTree.rebuildHashLookup()
+
+	return standardTree, nil
+}
+
+// checkDumpIndices verifica che ogni valore esportato punti a una foglia dell'albero
+func checkDumpIndices(tree []HexString, count int, treeIndex func(int) int) error {
+	if len(tree) == 0 {
+		return errors.New("albero vuoto")
+	}
+	firstLeaf := len(tree) - (len(tree)+1)/2
+	for i := 0; i < count; i++ {
+		if index := treeIndex(i); index < firstLeaf || index >= len(tree) {
+			return fmt.Errorf("TreeIndex %d del valore %d non è una foglia dell'albero", index, i)
+		}
 	}
+	return nil
 }
@@ -0,0 +1,358 @@
+package merkletree
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// NodeSize è la dimensione in byte di ogni nodo nei file dell'albero
+const NodeSize = 32
+
+// leafRecordSize è la dimensione di un record foglia temporaneo: hash + indice del valore
+const leafRecordSize = NodeSize + 8
+
+// DefaultStreamChunkSize è il numero di foglie ordinate in memoria per ogni run
+const DefaultStreamChunkSize = 1 << 20
+
+// StreamBuildOptions definisce le opzioni per la costruzione di un albero fuori memoria
+type StreamBuildOptions struct {
+	MerkleTreeOptions
+	TempDir   string // Directory per i file temporanei (default: os.TempDir())
+	ChunkSize int    // Foglie ordinate in memoria per ogni run dell'ordinamento esterno
+	IndexPath string // Se indicato, scrive per ogni valore il suo TreeIndex (uint64 big-endian)
+	Unsorted  bool   // Mantiene le foglie nell'ordine dei valori invece di ordinarle
+}
+
+// ErrStreamFixedDepth indica che la costruzione fuori memoria non supporta FixedDepth
+var ErrStreamFixedDepth = errors.New("la costruzione fuori memoria non supporta FixedDepth")
+
+// StreamBuildResult descrive l'albero scritto su disco
+type StreamBuildResult struct {
+	Root      HexString
+	LeafCount int
+	NodeCount int
+	Path      string // File con i nodi: record da 32 byte, il nodo i all'offset i*32
+}
+
+// leafRecord è una foglia in attesa di essere posizionata nell'albero
+type leafRecord struct {
+	Hash       [NodeSize]byte
+	ValueIndex uint64
+}
+
+// BuildMerkleTreeStream costruisce un albero leggendo i valori da un iteratore, senza tenerli
+// in memoria. Le opzioni passano da NewMerkleTreeOptions come in NewStandardMerkleTree, quindi
+// le foglie sono ordinate (con un ordinamento esterno su disco) salvo Unsorted. Il layout dei
+// nodi è lo stesso di MakeMerkleTree, quindi la root coincide con quella della costruzione in memoria.
+func BuildMerkleTreeStream[T any](values iter.Seq[T], leafHash func(T) HexString, nodeHash NodeHash, path string, options StreamBuildOptions) (StreamBuildResult, error) {
+	if options.FixedDepth > 0 {
+		return StreamBuildResult{}, ErrStreamFixedDepth
+	}
+	options.MerkleTreeOptions = NewMerkleTreeOptions(&options.MerkleTreeOptions)
+	if options.Unsorted {
+		options.SortLeaves = false
+	}
+	if leafHash == nil {
+		leafHash = StandardLeafHash[T]
+	}
+	if nodeHash == nil {
+		nodeHash = StandardNodeHash
+	}
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = DefaultStreamChunkSize
+	}
+
+	tempDir, err := os.MkdirTemp(options.TempDir, "merkletree-stream-")
+	if err != nil {
+		return StreamBuildResult{}, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	// 1️⃣ Hash delle foglie, scritte in run (ordinate se richiesto)
+	runs, leafCount, err := writeLeafRuns(values, leafHash, tempDir, options)
+	if err != nil {
+		return StreamBuildResult{}, err
+	}
+	if leafCount == 0 {
+		return StreamBuildResult{}, errors.New("impossibile costruire un albero di Merkle con 0 elementi")
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return StreamBuildResult{}, err
+	}
+	defer out.Close()
+
+	nodeCount := 2*leafCount - 1
+	if err := out.Truncate(int64(nodeCount) * NodeSize); err != nil {
+		return StreamBuildResult{}, err
+	}
+
+	// 2️⃣ Fusione delle run e scrittura delle foglie in fondo all'albero
+	if err := writeLeaves(out, runs, leafCount, options.SortLeaves, options.IndexPath); err != nil {
+		return StreamBuildResult{}, err
+	}
+
+	// 3️⃣ Calcolo dei nodi interni dal basso verso l'alto
+	if err := hashInternalNodes(out, leafCount, nodeHash, options.ChunkSize); err != nil {
+		return StreamBuildResult{}, err
+	}
+
+	var rootBytes [NodeSize]byte
+	if _, err := out.ReadAt(rootBytes[:], 0); err != nil {
+		return StreamBuildResult{}, err
+	}
+	root, _ := ToHex(rootBytes[:])
+	if err := out.Sync(); err != nil {
+		return StreamBuildResult{}, err
+	}
+
+	return StreamBuildResult{
+		Root:      root,
+		LeafCount: leafCount,
+		NodeCount: nodeCount,
+		Path:      path,
+	}, nil
+}
+
+// writeLeafRuns calcola gli hash delle foglie e li scrive in run temporanee.
+// Con SortLeaves ogni run è ordinata; altrimenti le run seguono l'ordine dei valori.
+func writeLeafRuns[T any](values iter.Seq[T], leafHash func(T) HexString, tempDir string, options StreamBuildOptions) ([]string, int, error) {
+	var runs []string
+	chunk := make([]leafRecord, 0, options.ChunkSize)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if options.SortLeaves {
+			sort.Slice(chunk, func(i, j int) bool {
+				return bytes.Compare(chunk[i].Hash[:], chunk[j].Hash[:]) < 0
+			})
+		}
+		runPath := filepath.Join(tempDir, fmt.Sprintf("run-%06d", len(runs)))
+		if err := writeLeafRecords(runPath, chunk); err != nil {
+			return err
+		}
+		runs = append(runs, runPath)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	count := 0
+	var iterErr error
+	for value := range values {
+		hashBytes, err := ToBytes(leafHash(value))
+		if err != nil {
+			iterErr = err
+			break
+		}
+		if len(hashBytes) != NodeSize {
+			iterErr = fmt.Errorf("hash della foglia %d di %d byte, attesi %d", count, len(hashBytes), NodeSize)
+			break
+		}
+		record := leafRecord{ValueIndex: uint64(count)}
+		copy(record.Hash[:], hashBytes)
+		chunk = append(chunk, record)
+		count++
+
+		if len(chunk) == options.ChunkSize {
+			if iterErr = flush(); iterErr != nil {
+				break
+			}
+		}
+	}
+	if iterErr != nil {
+		return nil, 0, iterErr
+	}
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+
+	return runs, count, nil
+}
+
+// writeLeafRecords scrive dei record foglia in un nuovo file
+func writeLeafRecords(path string, records []leafRecord) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	var buf [leafRecordSize]byte
+	for _, record := range records {
+		copy(buf[:NodeSize], record.Hash[:])
+		binary.BigEndian.PutUint64(buf[NodeSize:], record.ValueIndex)
+		if _, err := writer.Write(buf[:]); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// leafRunReader legge in sequenza i record di una run
+type leafRunReader struct {
+	file    *os.File
+	reader  *bufio.Reader
+	current leafRecord
+}
+
+func (r *leafRunReader) next() (bool, error) {
+	var buf [leafRecordSize]byte
+	if _, err := io.ReadFull(r.reader, buf[:]); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	copy(r.current.Hash[:], buf[:NodeSize])
+	r.current.ValueIndex = binary.BigEndian.Uint64(buf[NodeSize:])
+	return true, nil
+}
+
+// leafRunHeap ordina le run per hash della foglia corrente (k-way merge)
+type leafRunHeap []*leafRunReader
+
+func (h leafRunHeap) Len() int { return len(h) }
+func (h leafRunHeap) Less(i, j int) bool {
+	return bytes.Compare(h[i].current.Hash[:], h[j].current.Hash[:]) < 0
+}
+func (h leafRunHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *leafRunHeap) Push(x any)   { *h = append(*h, x.(*leafRunReader)) }
+func (h *leafRunHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// writeLeaves scrive le foglie a partire dall'indice leafCount-1: con `sorted` fonde le run
+// ordinate, altrimenti le concatena nell'ordine originale
+func writeLeaves(out *os.File, runs []string, leafCount int, sorted bool, indexPath string) error {
+	var index *os.File
+	if indexPath != "" {
+		var err error
+		index, err = os.Create(indexPath)
+		if err != nil {
+			return err
+		}
+		defer index.Close()
+	}
+
+	readers := make(leafRunHeap, 0, len(runs))
+	defer func() {
+		for _, r := range readers {
+			r.file.Close()
+		}
+	}()
+	for _, runPath := range runs {
+		file, err := os.Open(runPath)
+		if err != nil {
+			return err
+		}
+		reader := &leafRunReader{file: file, reader: bufio.NewReader(file)}
+		readers = append(readers, reader)
+	}
+	active := make(leafRunHeap, 0, len(readers))
+	for _, reader := range readers {
+		ok, err := reader.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			active = append(active, reader)
+		}
+	}
+	if sorted {
+		heap.Init(&active)
+	}
+
+	firstLeaf := leafCount - 1
+	writer := bufio.NewWriter(io.NewOffsetWriter(out, int64(firstLeaf)*NodeSize))
+	var treeIndex [8]byte
+	for position := 0; active.Len() > 0; position++ {
+		reader := active[0]
+		if _, err := writer.Write(reader.current.Hash[:]); err != nil {
+			return err
+		}
+		if index != nil {
+			binary.BigEndian.PutUint64(treeIndex[:], uint64(firstLeaf+position))
+			if _, err := index.WriteAt(treeIndex[:], int64(reader.current.ValueIndex)*8); err != nil {
+				return err
+			}
+		}
+
+		ok, err := reader.next()
+		if err != nil {
+			return err
+		}
+		switch {
+		case ok && sorted:
+			heap.Fix(&active, 0)
+		case !ok && sorted:
+			heap.Pop(&active)
+		case !ok:
+			active = active[1:]
+		}
+	}
+	return writer.Flush()
+}
+
+// hashInternalNodes calcola i nodi interni a blocchi, dall'indice leafCount-2 fino alla root.
+// Ogni blocco [lo, hi] è scelto in modo che i figli (2i+1, 2i+2) siano tutti fuori dal blocco,
+// così basta una lettura contigua dei figli e una scrittura contigua del blocco.
+func hashInternalNodes(out *os.File, leafCount int, nodeHash NodeHash, blockSize int) error {
+	children := make([]byte, 0, 2*blockSize*NodeSize)
+	parents := make([]byte, 0, blockSize*NodeSize)
+
+	for hi := leafCount - 2; hi >= 0; {
+		lo := (hi + 1) / 2
+		if hi-lo+1 > blockSize {
+			lo = hi - blockSize + 1
+		}
+
+		firstChild := LeftChildIndex(lo)
+		childCount := RightChildIndex(hi) - firstChild + 1
+		children = children[:childCount*NodeSize]
+		if _, err := out.ReadAt(children, int64(firstChild)*NodeSize); err != nil {
+			return err
+		}
+
+		parents = parents[:(hi-lo+1)*NodeSize]
+		for i := lo; i <= hi; i++ {
+			left := LeftChildIndex(i) - firstChild
+			right := RightChildIndex(i) - firstChild
+			hashed, err := ToBytes(nodeHash(
+				children[left*NodeSize:(left+1)*NodeSize],
+				children[right*NodeSize:(right+1)*NodeSize],
+			))
+			if err != nil {
+				return err
+			}
+			if len(hashed) != NodeSize {
+				return fmt.Errorf("hash del nodo %d di %d byte, attesi %d", i, len(hashed), NodeSize)
+			}
+			copy(parents[(i-lo)*NodeSize:], hashed)
+		}
+		if _, err := out.WriteAt(parents, int64(lo)*NodeSize); err != nil {
+			return err
+		}
+
+		hi = lo - 1
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package merkletree
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBuildMerkleTreeStreamMatchesMemory(t *testing.T) {
+	values := testValues(37)
+	for _, sorted := range []bool{true, false} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "tree.bin")
+		indexPath := filepath.Join(dir, "tree.idx")
+		options := StreamBuildOptions{
+			TempDir:   dir,
+			ChunkSize: 4, // Più run: esercita la fusione dell'ordinamento esterno
+			IndexPath: indexPath,
+			Unsorted:  !sorted,
+		}
+		result, err := BuildMerkleTreeStream(slices.Values(values), StandardLeafHash[string], nil, path, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tree, indexed, err := PrepareMerkleTreeContext(context.Background(), values, MerkleTreeOptions{SortLeaves: sorted}, StandardLeafHash[string], nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Root != tree[0] || result.LeafCount != len(values) || result.NodeCount != len(tree) {
+			t.Fatalf("sorted=%v: risultato %+v, root attesa %s", sorted, result, tree[0])
+		}
+
+		store, err := OpenFileNodeStore(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range tree {
+			node, err := store.Get(i)
+			if err != nil || node != tree[i] {
+				t.Fatalf("sorted=%v: nodo %d = %s, atteso %s", sorted, i, node, tree[i])
+			}
+		}
+		store.Close()
+
+		index, err := os.ReadFile(indexPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range indexed {
+			if got := int(binary.BigEndian.Uint64(index[8*i:])); got != v.TreeIndex {
+				t.Fatalf("sorted=%v: TreeIndex del valore %d = %d, atteso %d", sorted, i, got, v.TreeIndex)
+			}
+		}
+	}
+}
+
+func TestBuildMerkleTreeStreamEmpty(t *testing.T) {
+	dir := t.TempDir()
+	_, err := BuildMerkleTreeStream(slices.Values([]string{}), nil, nil, filepath.Join(dir, "tree.bin"), StreamBuildOptions{TempDir: dir})
+	if err == nil {
+		t.Fatal("albero vuoto accettato")
+	}
+}
+
+func TestBuildMerkleTreeStreamDefaults(t *testing.T) {
+	values := testValues(21)
+	dir := t.TempDir()
+
+	// Con le opzioni a zero la root coincide con quella di NewStandardMerkleTree
+	result, err := BuildMerkleTreeStream(slices.Values(values), StandardLeafHash[string], nil, filepath.Join(dir, "tree.bin"), StreamBuildOptions{TempDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := NewStandardMerkleTree(values, MerkleTreeOptions{}).Root(); result.Root != expected {
+		t.Fatalf("root %s, attesa %s", result.Root, expected)
+	}
+
+	options := StreamBuildOptions{MerkleTreeOptions: MerkleTreeOptions{FixedDepth: 5}, TempDir: dir}
+	if _, err := BuildMerkleTreeStream(slices.Values(values), nil, nil, filepath.Join(dir, "fixed.bin"), options); !errors.Is(err, ErrStreamFixedDepth) {
+		t.Fatalf("FixedDepth: errore %v, atteso %v", err, ErrStreamFixedDepth)
+	}
+}
@@ -0,0 +1,220 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+var (
+	// ErrNegativeSum indica un saldo o una somma negativa
+	ErrNegativeSum = errors.New("saldo o somma negativa")
+	// ErrSumOverflow indica una somma che non entra in un uint256
+	ErrSumOverflow = errors.New("la somma supera uint256")
+)
+
+// SumNode è un nodo del Merkle Sum Tree: l'hash e la somma dei saldi del sottoalbero
+type SumNode struct {
+	Hash HexString `json:"hash"`
+	Sum  *big.Int  `json:"sum"`
+}
+
+// SumEntry è il saldo di un utente incluso nell'albero
+type SumEntry struct {
+	Account string   `json:"account"`
+	Balance *big.Int `json:"balance"`
+}
+
+// SumProof dimostra che il saldo di un utente contribuisce al totale pubblicato nella root
+type SumProof struct {
+	Account   string    `json:"account"`
+	Balance   *big.Int  `json:"balance"`
+	TreeIndex int       `json:"treeIndex"` // Indice della foglia nel layout a heap: determina la posizione dei sibling
+	Siblings  []SumNode `json:"siblings"`
+}
+
+// MerkleSumTree è un albero di Merkle in cui ogni nodo porta anche la somma dei saldi
+// sottostanti, per le proof-of-liabilities. Usa lo stesso layout a heap di MakeMerkleTree,
+// con le foglie nell'ordine dei saldi.
+type MerkleSumTree struct {
+	Nodes   []SumNode
+	Entries []SumEntry
+	lookup  map[string]int
+}
+
+// SumLeafHash calcola l'hash di una foglia: keccak256(keccak256(account) || uint256 balance)
+func SumLeafHash(account string, balance *big.Int) HexString {
+	accountHash := keccak256Hex([]byte(account))
+	accountBytes, _ := ToBytes(accountHash)
+	return keccak256Hex(append(accountBytes, math.U256Bytes(new(big.Int).Set(balance))...))
+}
+
+// SumNodeHash calcola il nodo padre: keccak256(left.hash || left.sum || right.hash || right.sum),
+// con la somma dei figli. Restituisce un errore se una somma è negativa o supera uint256.
+func SumNodeHash(left SumNode, right SumNode) (SumNode, error) {
+	if err := checkSum(left.Sum); err != nil {
+		return SumNode{}, err
+	}
+	if err := checkSum(right.Sum); err != nil {
+		return SumNode{}, err
+	}
+	sum := new(big.Int).Add(left.Sum, right.Sum)
+	if err := checkSum(sum); err != nil {
+		return SumNode{}, err
+	}
+
+	leftHash, err := ToBytes(left.Hash)
+	if err != nil {
+		return SumNode{}, err
+	}
+	rightHash, err := ToBytes(right.Hash)
+	if err != nil {
+		return SumNode{}, err
+	}
+	encoded := make([]byte, 0, 128)
+	encoded = append(encoded, leftHash...)
+	encoded = append(encoded, math.U256Bytes(new(big.Int).Set(left.Sum))...)
+	encoded = append(encoded, rightHash...)
+	encoded = append(encoded, math.U256Bytes(new(big.Int).Set(right.Sum))...)
+	return SumNode{Hash: keccak256Hex(encoded), Sum: sum}, nil
+}
+
+// NewMerkleSumTree costruisce l'albero a partire dai saldi, nell'ordine dato.
+// Rifiuta account duplicati, saldi negativi e totali che superano uint256.
+func NewMerkleSumTree(entries []SumEntry) (*MerkleSumTree, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("impossibile costruire un albero di Merkle con 0 elementi")
+	}
+
+	tree := &MerkleSumTree{
+		Nodes:   make([]SumNode, 2*len(entries)-1),
+		Entries: make([]SumEntry, len(entries)),
+		lookup:  make(map[string]int, len(entries)),
+	}
+	firstLeaf := len(entries) - 1
+	for i, entry := range entries {
+		if err := checkSum(entry.Balance); err != nil {
+			return nil, fmt.Errorf("saldo di %s: %w", entry.Account, err)
+		}
+		if _, found := tree.lookup[entry.Account]; found {
+			return nil, fmt.Errorf("account %s duplicato", entry.Account)
+		}
+		tree.lookup[entry.Account] = i
+		balance := new(big.Int).Set(entry.Balance)
+		tree.Entries[i] = SumEntry{Account: entry.Account, Balance: balance}
+		tree.Nodes[firstLeaf+i] = SumNode{Hash: SumLeafHash(entry.Account, balance), Sum: balance}
+	}
+
+	for i := firstLeaf - 1; i >= 0; i-- {
+		node, err := SumNodeHash(tree.Nodes[LeftChildIndex(i)], tree.Nodes[RightChildIndex(i)])
+		if err != nil {
+			return nil, err
+		}
+		tree.Nodes[i] = node
+	}
+	return tree, nil
+}
+
+// Root restituisce la root: l'hash da pubblicare e il totale delle passività
+func (t *MerkleSumTree) Root() SumNode {
+	return t.Nodes[0]
+}
+
+// Total restituisce la somma di tutti i saldi
+func (t *MerkleSumTree) Total() *big.Int {
+	return new(big.Int).Set(t.Nodes[0].Sum)
+}
+
+// GetProof restituisce la proof di inclusione di un account
+func (t *MerkleSumTree) GetProof(account string) (SumProof, error) {
+	index, found := t.lookup[account]
+	if !found {
+		return SumProof{}, fmt.Errorf("l'account %s non esiste nell'albero", account)
+	}
+	return t.GetProofAt(index)
+}
+
+// GetProofAt restituisce la proof di inclusione del saldo con indice `index`
+func (t *MerkleSumTree) GetProofAt(index int) (SumProof, error) {
+	if index < 0 || index >= len(t.Entries) {
+		return SumProof{}, fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(t.Entries)-1)
+	}
+
+	treeIndex := len(t.Entries) - 1 + index
+	proof := SumProof{
+		Account:   t.Entries[index].Account,
+		Balance:   new(big.Int).Set(t.Entries[index].Balance),
+		TreeIndex: treeIndex,
+	}
+	for i := treeIndex; i > 0; i = ParentIndex(i) {
+		sibling := t.Nodes[SiblingIndex(i)]
+		proof.Siblings = append(proof.Siblings, SumNode{Hash: sibling.Hash, Sum: new(big.Int).Set(sibling.Sum)})
+	}
+	return proof, nil
+}
+
+// ProcessSumProof ricalcola la root a partire dalla proof, controllando che nessun saldo
+// o somma intermedia sia negativa o superi uint256
+func ProcessSumProof(proof SumProof) (SumNode, error) {
+	if err := checkSum(proof.Balance); err != nil {
+		return SumNode{}, err
+	}
+	if proof.TreeIndex < 0 || len(proof.Siblings) != treeDepth(proof.TreeIndex) {
+		return SumNode{}, fmt.Errorf("proof di %d elementi non valida per l'indice %d", len(proof.Siblings), proof.TreeIndex)
+	}
+
+	node := SumNode{Hash: SumLeafHash(proof.Account, proof.Balance), Sum: proof.Balance}
+	index := proof.TreeIndex
+	for i, sibling := range proof.Siblings {
+		if !IsValidMerkleNode(sibling.Hash) {
+			return SumNode{}, fmt.Errorf("sibling %d non valido", i)
+		}
+		var err error
+		// Nel layout a heap i figli sinistri hanno indice dispari
+		if index%2 == 1 {
+			node, err = SumNodeHash(node, sibling)
+		} else {
+			node, err = SumNodeHash(sibling, node)
+		}
+		if err != nil {
+			return SumNode{}, fmt.Errorf("sibling %d: %w", i, err)
+		}
+		index = ParentIndex(index)
+	}
+	return node, nil
+}
+
+// VerifySumProof verifica che il saldo della proof contribuisca alla root pubblicata
+func VerifySumProof(root SumNode, proof SumProof) error {
+	if err := checkSum(root.Sum); err != nil {
+		return err
+	}
+	rootHash, err := ToHex(root.Hash)
+	if err != nil {
+		return err
+	}
+	computed, err := ProcessSumProof(proof)
+	if err != nil {
+		return err
+	}
+	if computed.Hash != rootHash || computed.Sum.Cmp(root.Sum) != 0 {
+		return errors.New("la proof non corrisponde alla root")
+	}
+	return nil
+}
+
+// checkSum verifica che una somma sia un uint256
+func checkSum(sum *big.Int) error {
+	if sum == nil {
+		return errors.New("somma mancante")
+	}
+	if sum.Sign() < 0 {
+		return ErrNegativeSum
+	}
+	if sum.Cmp(math.MaxBig256) > 0 {
+		return ErrSumOverflow
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package merkletree
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testSumEntries() []SumEntry {
+	return []SumEntry{
+		{Account: "alice", Balance: big.NewInt(100)},
+		{Account: "bob", Balance: big.NewInt(0)},
+		{Account: "carol", Balance: big.NewInt(250)},
+		{Account: "dave", Balance: big.NewInt(7)},
+		{Account: "erin", Balance: big.NewInt(43)},
+	}
+}
+
+func TestMerkleSumTreeMatchesManualEncoding(t *testing.T) {
+	entries := testSumEntries()[:3]
+	tree, err := NewMerkleSumTree(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Layout a heap con 3 foglie: la root unisce il nodo 1 (bob, carol) alla foglia di alice
+	leaf := func(entry SumEntry) []byte {
+		return crypto.Keccak256(crypto.Keccak256([]byte(entry.Account)), math.U256Bytes(new(big.Int).Set(entry.Balance)))
+	}
+	node := func(left []byte, leftSum int64, right []byte, rightSum int64) []byte {
+		return crypto.Keccak256(left, math.U256Bytes(big.NewInt(leftSum)), right, math.U256Bytes(big.NewInt(rightSum)))
+	}
+	inner := node(leaf(entries[1]), 0, leaf(entries[2]), 250)
+	root := node(inner, 250, leaf(entries[0]), 100)
+
+	if tree.Root().Hash != bytesHex(root) {
+		t.Fatalf("root %s, attesa %x", tree.Root().Hash, root)
+	}
+	if tree.Total().Int64() != 350 || tree.Nodes[1].Sum.Int64() != 250 {
+		t.Fatalf("totale %s, somma del nodo 1 %s", tree.Total(), tree.Nodes[1].Sum)
+	}
+}
+
+func TestMerkleSumTreeProofs(t *testing.T) {
+	entries := testSumEntries()
+	tree, err := NewMerkleSumTree(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Total().Int64() != 400 {
+		t.Fatalf("totale %s, atteso 400", tree.Total())
+	}
+
+	for _, entry := range entries {
+		proof, err := tree.GetProof(entry.Account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Balance.Cmp(entry.Balance) != 0 {
+			t.Fatalf("saldo di %s nella proof %s", entry.Account, proof.Balance)
+		}
+		if err := VerifySumProof(tree.Root(), proof); err != nil {
+			t.Fatalf("proof di %s: %v", entry.Account, err)
+		}
+
+		// Il saldo e la somma dei sibling coprono il totale pubblicato
+		covered := new(big.Int).Set(proof.Balance)
+		for _, sibling := range proof.Siblings {
+			covered.Add(covered, sibling.Sum)
+		}
+		if covered.Cmp(tree.Total()) != 0 {
+			t.Fatalf("la proof di %s copre %s, atteso %s", entry.Account, covered, tree.Total())
+		}
+	}
+
+	if _, err := tree.GetProof("mallory"); err == nil {
+		t.Fatal("proof generata per un account assente")
+	}
+	if _, err := tree.GetProofAt(len(entries)); err == nil {
+		t.Fatal("indice fuori dai limiti accettato")
+	}
+}
+
+func TestVerifySumProofRejectsTampering(t *testing.T) {
+	tree, err := NewMerkleSumTree(testSumEntries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := tree.GetProof("carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// copyProof duplica la proof, compresi i big.Int, per modificarla senza toccare l'originale
+	copyProof := func() SumProof {
+		copied := proof
+		copied.Balance = new(big.Int).Set(proof.Balance)
+		copied.Siblings = make([]SumNode, len(proof.Siblings))
+		for i, sibling := range proof.Siblings {
+			copied.Siblings[i] = SumNode{Hash: sibling.Hash, Sum: new(big.Int).Set(sibling.Sum)}
+		}
+		return copied
+	}
+
+	cases := map[string]struct {
+		tamper func(*SumProof)
+		err    error
+	}{
+		"saldo diverso":         {tamper: func(p *SumProof) { p.Balance.SetInt64(1) }},
+		"somma di un sibling":   {tamper: func(p *SumProof) { p.Siblings[0].Sum.Add(p.Siblings[0].Sum, big.NewInt(1)) }},
+		"account diverso":       {tamper: func(p *SumProof) { p.Account = "mallory" }},
+		"posizione diversa":     {tamper: func(p *SumProof) { p.TreeIndex++ }},
+		"sibling mancante":      {tamper: func(p *SumProof) { p.Siblings = p.Siblings[1:] }},
+		"saldo negativo":        {tamper: func(p *SumProof) { p.Balance.SetInt64(-250) }, err: ErrNegativeSum},
+		"sibling negativo":      {tamper: func(p *SumProof) { p.Siblings[0].Sum.SetInt64(-1) }, err: ErrNegativeSum},
+		"sibling oltre uint256": {tamper: func(p *SumProof) { p.Siblings[0].Sum.Set(math.MaxBig256) }, err: ErrSumOverflow},
+	}
+	for name, c := range cases {
+		tampered := copyProof()
+		c.tamper(&tampered)
+		err := VerifySumProof(tree.Root(), tampered)
+		if err == nil {
+			t.Errorf("%s: proof accettata", name)
+		} else if c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%s: errore %v, atteso %v", name, err, c.err)
+		}
+	}
+
+	// Una root con un totale diverso da quello della proof non viene accettata
+	root := tree.Root()
+	if err := VerifySumProof(SumNode{Hash: root.Hash, Sum: new(big.Int).Sub(root.Sum, big.NewInt(1))}, proof); err == nil {
+		t.Fatal("proof accettata con un totale diverso")
+	}
+	if err := VerifySumProof(SumNode{Hash: root.Hash, Sum: big.NewInt(-1)}, proof); !errors.Is(err, ErrNegativeSum) {
+		t.Fatalf("errore %v, atteso ErrNegativeSum", err)
+	}
+}
+
+func TestNewMerkleSumTreeRejectsInvalidEntries(t *testing.T) {
+	half := new(big.Int).Add(new(big.Int).Rsh(math.MaxBig256, 1), big.NewInt(1))
+	cases := map[string]struct {
+		entries []SumEntry
+		err     error
+	}{
+		"nessun saldo":      {entries: nil},
+		"account duplicato": {entries: []SumEntry{{Account: "a", Balance: big.NewInt(1)}, {Account: "a", Balance: big.NewInt(2)}}},
+		"saldo mancante":    {entries: []SumEntry{{Account: "a"}}},
+		"saldo negativo":    {entries: []SumEntry{{Account: "a", Balance: big.NewInt(-1)}}, err: ErrNegativeSum},
+		"totale oltre uint256": {
+			entries: []SumEntry{{Account: "a", Balance: half}, {Account: "b", Balance: half}},
+			err:     ErrSumOverflow,
+		},
+	}
+	for name, c := range cases {
+		_, err := NewMerkleSumTree(c.entries)
+		if err == nil {
+			t.Errorf("%s: albero costruito", name)
+		} else if c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%s: errore %v, atteso %v", name, err, c.err)
+		}
+	}
+
+	// Il saldo massimo entra se il totale resta un uint256
+	if _, err := NewMerkleSumTree([]SumEntry{{Account: "a", Balance: new(big.Int).Set(math.MaxBig256)}, {Account: "b", Balance: big.NewInt(0)}}); err != nil {
+		t.Fatal(err)
+	}
+}
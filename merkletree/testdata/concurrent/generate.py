@@ -0,0 +1,217 @@
+# Genera le fixture del Concurrent Merkle Tree (python3 testdata/concurrent/generate.py).
+# Modello di riferimento indipendente dall'implementazione Go: tiene tutte le foglie e
+# ricalcola l'albero intero dopo ogni operazione; del programma SPL account-compression
+# riproduce solo le regole del changelog (ricerca della root, fast-forward per critbit,
+# replay dell'intero buffer se la root non è più presente). Non sono registrazioni on-chain:
+# il campo `error` delle operazioni fallite è la variante di ConcurrentMerkleTreeError che il
+# modello si aspetta, non un errore restituito dal programma.
+import json, os
+
+RC = [0x0000000000000001,0x0000000000008082,0x800000000000808A,0x8000000080008000,0x000000000000808B,0x0000000080000001,0x8000000080008081,0x8000000000008009,0x000000000000008A,0x0000000000000088,0x0000000080008009,0x000000008000000A,0x000000008000808B,0x800000000000008B,0x8000000000008089,0x8000000000008003,0x8000000000008002,0x8000000000000080,0x000000000000800A,0x800000008000000A,0x8000000080008081,0x8000000000008080,0x0000000080000001,0x8000000080008008]
+ROT = [[0,36,3,41,18],[1,44,10,45,2],[62,6,43,15,61],[28,55,25,21,56],[27,20,39,8,14]]
+M = (1<<64)-1
+def rol(x,n): return ((x<<n)|(x>>(64-n)))&M if n else x
+def f(A):
+    for rc in RC:
+        C=[A[x][0]^A[x][1]^A[x][2]^A[x][3]^A[x][4] for x in range(5)]
+        D=[C[(x-1)%5]^rol(C[(x+1)%5],1) for x in range(5)]
+        A=[[A[x][y]^D[x] for y in range(5)] for x in range(5)]
+        B=[[0]*5 for _ in range(5)]
+        for x in range(5):
+            for y in range(5):
+                B[y][(2*x+3*y)%5]=rol(A[x][y],ROT[x][y])
+        A=[[B[x][y]^((~B[(x+1)%5][y])&B[(x+2)%5][y]) for y in range(5)] for x in range(5)]
+        A[0][0]^=rc
+    return A
+def keccak256(data):
+    rate=136
+    p=bytearray(data)+b'\x01'
+    while len(p)%rate: p+=b'\x00'
+    p[-1]|=0x80
+    A=[[0]*5 for _ in range(5)]
+    for off in range(0,len(p),rate):
+        blk=p[off:off+rate]
+        for i in range(rate//8):
+            x,y=i%5,i//5
+            A[x][y]^=int.from_bytes(blk[8*i:8*i+8],'little')
+        A=f(A)
+    out=b''
+    for i in range(4):
+        out+=A[i%5][i//5].to_bytes(8,'little')
+    return out
+
+ZERO = bytes(32)
+def H(a, b): return keccak256(a + b)
+def hx(b): return '0x' + b.hex()
+def leaf(i): return keccak256(b'leaf-%d' % i)
+
+class Reference:
+    def __init__(self, depth, buffer_size):
+        self.depth, self.buffer_size = depth, buffer_size
+        self.leaves = [ZERO] * (1 << depth)
+        self.next, self.seq = 0, 0
+        self.log = [(self.root(), 0, self.path(0))]
+
+    def levels(self):
+        levels = [self.leaves]
+        while len(levels[-1]) > 1:
+            prev = levels[-1]
+            levels.append([H(prev[i], prev[i + 1]) for i in range(0, len(prev), 2)])
+        return levels
+
+    def root(self): return self.levels()[-1][0]
+    def path(self, index): return [l[index >> i] for i, l in enumerate(self.levels()[:-1])]
+    def proof(self, index): return [l[(index >> i) ^ 1] for i, l in enumerate(self.levels()[:-1])]
+
+    def check(self, root, value, proof, index, allow_inferred):
+        entries = self.log[-self.buffer_size:]
+        found = [i for i, e in enumerate(entries) if e[0] == root]
+        if found:
+            applied = entries[found[-1] + 1:]
+        elif allow_inferred:
+            applied = entries
+        else:
+            return 'notfound'
+        proof = proof + [self.empty(i) for i in range(len(proof), self.depth)]
+        updated = value
+        for _, changed, path in applied:
+            if changed == index:
+                updated = path[0]
+            else:
+                critbit = (index ^ changed).bit_length() - 1
+                proof[critbit] = path[critbit]
+        if updated != value:
+            return 'modified'
+        if index > self.next:
+            return 'bounds'
+        node = updated
+        for i, sibling in enumerate(proof):
+            node = H(node, sibling) if (index >> i) & 1 == 0 else H(sibling, node)
+        return 'ok' if node == self.root() else 'invalid'
+
+    def empty(self, level):
+        node = ZERO
+        for _ in range(level):
+            node = H(node, node)
+        return node
+
+    def write(self, index, value):
+        self.leaves[index] = value
+        self.next = max(self.next, index + 1)
+        self.seq += 1
+        self.log.append((self.root(), index, self.path(index)))
+
+# Esito di Reference.check -> variante di ConcurrentMerkleTreeError (campo `error` della fixture)
+ERRORS = {'notfound': 'RootNotFound', 'modified': 'LeafContentsModified', 'invalid': 'InvalidProof',
+          'bounds': 'LeafIndexOutOfBounds', 'full': 'TreeFull'}
+
+class Recorder:
+    def __init__(self, depth, buffer_size, description):
+        self.tree = Reference(depth, buffer_size)
+        self.fixture = {'description': description, 'maxDepth': depth, 'maxBufferSize': buffer_size, 'operations': []}
+
+    def snapshot(self):
+        return self.tree.root(), list(self.tree.leaves), [self.tree.proof(i) for i in range(len(self.tree.leaves))]
+
+    def record(self, op, ok, note, error=None):
+        if ok:
+            op['expectedRoot'] = hx(self.tree.root())
+            op['seq'] = self.tree.seq
+        else:
+            op['failed'] = True
+            op['error'] = error
+        op['note'] = note
+        self.fixture['operations'].append(op)
+
+    def append(self, value, note):
+        error = 'CannotAppendEmptyNode' if value == ZERO else 'TreeFull' if self.tree.next == len(self.tree.leaves) else None
+        if error is None:
+            self.tree.write(self.tree.next, value)
+        self.record({'type': 'append', 'leaf': hx(value)}, error is None, note, error)
+
+    def replace(self, kind, snap, index, new, note, previous=None, canopy=0):
+        root, leaves, proofs = snap
+        previous = leaves[index] if previous is None else previous
+        proof = proofs[index][:self.tree.depth - canopy]
+        result = self.tree.check(root, previous, proof, index, True)
+        if result == 'ok':
+            self.tree.write(index, new)
+        self.record({'type': kind, 'root': hx(root), 'leaf': hx(previous), 'newLeaf': hx(new),
+                     'proof': [hx(p) for p in proof], 'index': index}, result == 'ok', note, ERRORS.get(result))
+
+    def verify(self, snap, index, note):
+        root, leaves, proofs = snap
+        result = self.tree.check(root, leaves[index], proofs[index], index, True)
+        self.record({'type': 'verify_leaf', 'root': hx(root), 'leaf': hx(leaves[index]),
+                     'proof': [hx(p) for p in proofs[index]], 'index': index}, result == 'ok', note, ERRORS.get(result))
+
+    def fill(self, snap, index, value, note):
+        root, _, proofs = snap
+        result = self.tree.check(root, ZERO, proofs[index], index, False)
+        if result == 'ok':
+            self.tree.write(index, value)
+        elif result == 'modified':
+            # Posizione già occupata: il programma ripiega su append
+            result = 'ok' if self.tree.next < len(self.tree.leaves) else 'full'
+            if result == 'ok':
+                self.tree.write(self.tree.next, value)
+        self.record({'type': 'fill_empty_or_append', 'root': hx(root), 'leaf': hx(value),
+                     'proof': [hx(p) for p in proofs[index]], 'index': index}, result == 'ok', note, ERRORS.get(result))
+
+def append_fixture():
+    r = Recorder(3, 8, 'append fino a riempire un albero di profondità 3, con verify_leaf su root correnti e passate')
+    r.append(ZERO, 'foglia vuota rifiutata')
+    for i in range(5):
+        r.append(leaf(i), 'append %d' % i)
+    early = r.snapshot()
+    for i in range(5, 8):
+        r.append(leaf(i), 'append %d' % i)
+    r.verify(r.snapshot(), 6, 'verify_leaf sulla root corrente')
+    r.verify(early, 2, 'verify_leaf con una proof precedente agli ultimi append')
+    r.append(leaf(8), 'albero pieno')
+    return r.fixture
+
+def replace_fixture():
+    r = Recorder(4, 4, 'replace e set_leaf con proof obsolete: fast-forward con root nel buffer, con buffer pieno e con modifiche uscite dal buffer')
+    for i in range(5):
+        r.append(leaf(i), 'append %d' % i)
+    stale = r.snapshot()
+    r.replace('replace', r.snapshot(), 1, leaf(101), 'proof aggiornata')
+    r.replace('replace', stale, 3, leaf(103), 'proof obsoleta, root nel buffer')
+    r.replace('replace', stale, 1, leaf(111), 'foglia modificata dopo la proof', previous=stale[1][1])
+    r.append(leaf(5), 'append 5')
+    r.append(leaf(6), 'append 6')
+    r.replace('replace', stale, 0, leaf(100), 'root uscita dal buffer: replay del buffer pieno')
+    lost = r.snapshot()
+    r.replace('set_leaf', r.snapshot(), 2, leaf(102), 'set_leaf sul sibling della foglia 3')
+    r.replace('replace', r.snapshot(), 5, leaf(105), 'proof aggiornata')
+    r.replace('replace', r.snapshot(), 6, leaf(106), 'proof aggiornata')
+    r.append(leaf(7), 'append 7')
+    r.replace('replace', r.snapshot(), 0, leaf(120), 'proof aggiornata')
+    r.replace('replace', lost, 3, leaf(113), 'modifica del sibling uscita dal buffer: proof non valida')
+    r.replace('replace', r.snapshot(), 8, leaf(108), 'scrittura della prima posizione libera')
+    r.append(leaf(9), 'append dopo set della posizione libera')
+    r.replace('replace', r.snapshot(), 12, leaf(112), 'indice oltre la foglia più a destra')
+    r.replace('replace', r.snapshot(), 4, leaf(104), 'proof senza i due livelli del canopy', canopy=2)
+    return r.fixture
+
+def fill_fixture():
+    r = Recorder(3, 4, 'fill_empty_or_append: posizione libera, posizione occupata dopo la proof, root non trovata; replace con canopy')
+    for i in range(3):
+        r.append(leaf(i), 'append %d' % i)
+    r.replace('replace', r.snapshot(), 1, leaf(201), 'proof senza il livello del canopy (sottoalbero vuoto)', canopy=1)
+    before = r.snapshot()
+    r.fill(r.snapshot(), 3, leaf(3), 'posizione libera')
+    r.fill(before, 3, leaf(4), 'posizione occupata dopo la proof: append')
+    for i in range(5, 8):
+        r.append(leaf(i), 'append %d' % i)
+    r.fill(before, 3, leaf(9), 'root non più nel buffer')
+    return r.fixture
+
+here = os.path.dirname(os.path.abspath(__file__))
+for name, fixture in [('append', append_fixture()), ('replace', replace_fixture()), ('fill', fill_fixture())]:
+    with open(os.path.join(here, name + '.json'), 'w') as out:
+        out.write('{\n "description": %s,\n "maxDepth": %d,\n "maxBufferSize": %d,\n "operations": [\n' % (
+            json.dumps(fixture['description'], ensure_ascii=False), fixture['maxDepth'], fixture['maxBufferSize']))
+        out.write(',\n'.join('  ' + json.dumps(op, ensure_ascii=False) for op in fixture['operations']))
+        out.write('\n ]\n}\n')
@@ -0,0 +1,131 @@
+// Genera merkletreejs.json: port dei metodi processLeaves/createHashes/getProof/verify di
+// merkletreejs v0.3.x (hash SHA-256), eseguito con node perché il pacchetto npm non era
+// installabile offline. Le fixture non sono quindi prodotte dalla libreria originale.
+// Uso: node testdata/merkletreejs.js > testdata/merkletreejs.json
+const crypto = require('crypto')
+const sha256 = b => crypto.createHash('sha256').update(b).digest()
+const reverse = b => Buffer.from(b).reverse()
+class MerkleTree {
+  constructor(leaves, opts) {
+    this.o = opts
+    if (opts.complete && (opts.isBitcoinTree || opts.duplicateOdd)) throw new Error('option "complete" is incompatible with "isBitcoinTree" and "duplicateOdd"')
+    if (opts.hashLeaves) leaves = leaves.map(sha256)
+    this.leaves = leaves.slice()
+    if (opts.sortLeaves) this.leaves = this.leaves.sort(Buffer.compare)
+    if (opts.fillDefaultHash) {
+      for (let i = this.leaves.length; i < Math.pow(2, Math.ceil(Math.log2(this.leaves.length))); i++) this.leaves.push(opts.fillDefaultHash)
+    }
+    this.createHashes(this.leaves)
+  }
+  createHashes(nodes) {
+    this.layers = [nodes]
+    this.leafLimit = nodes.length
+    while (nodes.length > 1) {
+      const li = this.layers.length
+      this.layers.push([])
+      // Con complete solo i primi layerLimit nodi delle foglie sono accoppiati, gli altri salgono di livello
+      const layerLimit = this.o.complete && li === 1 && !Number.isInteger(Math.log2(nodes.length))
+        ? 2 * nodes.length - Math.pow(2, Math.ceil(Math.log2(nodes.length))) : nodes.length
+      for (let i = 0; i < nodes.length; i += 2) {
+        if (i >= layerLimit) {
+          this.layers[li].push(...nodes.slice(layerLimit))
+          this.leafLimit = layerLimit
+          break
+        }
+        if (i + 1 === nodes.length && nodes.length % 2 === 1) {
+          let data = nodes[nodes.length - 1]
+          if (this.o.isBitcoinTree) {
+            data = Buffer.concat([reverse(data), reverse(data)])
+            this.layers[li].push(reverse(sha256(sha256(data))))
+            continue
+          } else if (!this.o.duplicateOdd) {
+            this.layers[li].push(nodes[i])
+            continue
+          }
+        }
+        const left = nodes[i]
+        const right = i + 1 === nodes.length ? left : nodes[i + 1]
+        const combined = this.o.isBitcoinTree ? [reverse(left), reverse(right)] : [left, right]
+        if (this.o.sortPairs) combined.sort(Buffer.compare)
+        let hash = sha256(Buffer.concat(combined))
+        if (this.o.isBitcoinTree) hash = reverse(sha256(hash))
+        this.layers[li].push(hash)
+      }
+      nodes = this.layers[li]
+    }
+  }
+  getRoot() { return this.layers[this.layers.length - 1][0] || Buffer.from([]) }
+  getProof(index) {
+    const proof = []
+    for (let i = 0; i < this.layers.length; i++) {
+      const layer = this.layers[i]
+      if (i === 0 && index >= this.leafLimit) {
+        // Foglia salita di livello senza sibling (albero complete)
+        index = this.leafLimit / 2 + index - this.leafLimit
+        continue
+      }
+      const isRightNode = index % 2
+      const pairIndex = isRightNode ? index - 1
+        : this.o.isBitcoinTree && index === layer.length - 1 && i < this.layers.length - 1 ? index : index + 1
+      if (pairIndex < layer.length) proof.push({ position: isRightNode ? 'left' : 'right', data: '0x' + layer[pairIndex].toString('hex') })
+      index = (index / 2) | 0
+    }
+    return proof
+  }
+  verify(proof, leaf, root) {
+    let hash = leaf
+    if (!leaf.length || !root.length) return false
+    for (const node of proof) {
+      const data = Buffer.from(node.data.slice(2), 'hex')
+      const isLeftNode = node.position === 'left'
+      if (this.o.isBitcoinTree) {
+        const buffers = isLeftNode ? [reverse(data), reverse(hash)] : [reverse(hash), reverse(data)]
+        hash = reverse(sha256(sha256(Buffer.concat(buffers))))
+      } else if (this.o.sortPairs) {
+        hash = sha256(Buffer.compare(hash, data) === -1 ? Buffer.concat([hash, data]) : Buffer.concat([data, hash]))
+      } else {
+        hash = sha256(isLeftNode ? Buffer.concat([data, hash]) : Buffer.concat([hash, data]))
+      }
+    }
+    return Buffer.compare(hash, root) === 0
+  }
+}
+const hex = b => '0x' + b.toString('hex')
+const fill = sha256(Buffer.from('fill'))
+const optionSets = {
+  default: {},
+  sortPairs: { sortPairs: true },
+  sortLeaves: { sortLeaves: true },
+  sortLeavesSortPairs: { sortLeaves: true, sortPairs: true },
+  duplicateOdd: { duplicateOdd: true },
+  duplicateOddSortPairs: { duplicateOdd: true, sortPairs: true },
+  isBitcoinTree: { isBitcoinTree: true },
+  fillDefaultHash: { fillDefaultHash: fill },
+  fillDefaultHashSortPairs: { fillDefaultHash: fill, sortPairs: true },
+  complete: { complete: true },
+  completeSortPairs: { complete: true, sortPairs: true },
+}
+const cases = []
+for (const [name, opts] of Object.entries(optionSets)) {
+  for (const hashLeaves of name === 'default' || name === 'sortLeavesSortPairs' ? [false, true] : [false]) {
+    for (const n of name === 'default' ? [0, 1, 2, 5, 7] : name.startsWith('complete') ? [1, 5, 6, 7] : [1, 5, 7]) {
+      const data = Array.from({ length: n }, (_, i) => hashLeaves ? Buffer.from('leaf-' + i) : sha256(Buffer.from('leaf-' + i)))
+      const tree = new MerkleTree(data, Object.assign({ hashLeaves }, opts))
+      cases.push({
+        name: `${name}${hashLeaves ? '/hashLeaves' : ''}/${n}`,
+        options: {
+          sortPairs: !!opts.sortPairs, sortLeaves: !!opts.sortLeaves, hashLeaves,
+          duplicateOdd: !!opts.duplicateOdd, isBitcoinTree: !!opts.isBitcoinTree, complete: !!opts.complete,
+          fillDefaultHash: opts.fillDefaultHash ? hex(opts.fillDefaultHash) : undefined,
+        },
+        leaves: data.map(hex),
+        root: hex(tree.getRoot()),
+        proofs: tree.leaves.map((_, i) => tree.getProof(i)),
+        // verify() della proof di ogni foglia, con la foglia stessa e con la foglia successiva
+        verified: tree.leaves.map((leaf, i) => tree.verify(tree.getProof(i), leaf, tree.getRoot())),
+        verifiedNextLeaf: tree.leaves.map((_, i) => tree.verify(tree.getProof(i), tree.leaves[(i + 1) % tree.leaves.length], tree.getRoot())),
+      })
+    }
+  }
+}
+process.stdout.write('[\n' + cases.map(c => ' ' + JSON.stringify(c)).join(',\n') + '\n]\n')
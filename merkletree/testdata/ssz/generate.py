@@ -0,0 +1,216 @@
+# Genera testdata/ssz/vectors.json (python3 testdata/ssz/generate.py).
+# Implementazione di riferimento scritta a partire dallo pseudocodice della specifica
+# (ssz/simple-serialize.md e ssz/merkle-proofs.md) con hashlib.sha256: serializzazione,
+# pack, merkleize, mix_in_length, get_generalized_index, get_helper_indices. Non sono i
+# vettori di consensus-spec-tests, che non erano scaricabili offline: di questi ci sono solo
+# i casi ssz_generic/bitlist/invalid (testdata/ssz/ssz_generic), che questi vettori integrano.
+import hashlib, json, os
+
+def sha256(data): return hashlib.sha256(data).digest()
+def hx(b): return '0x' + b.hex()
+ZERO = bytes(32)
+
+class Node:
+    def __init__(self, hash, left=None, right=None):
+        self.hash, self.left, self.right = hash, left, right
+
+ZEROS = [Node(ZERO)]
+for _ in range(64):
+    ZEROS.append(Node(sha256(ZEROS[-1].hash * 2), ZEROS[-1], ZEROS[-1]))
+
+def pair(left, right): return Node(sha256(left.hash + right.hash), left, right)
+
+def power_of_two_ceil(x):
+    return 1 if x <= 1 else 2 ** (x - 1).bit_length()
+
+def merkleize(chunks, limit=None):
+    # merkleize: completa con chunk a zero fino a next_pow_of_two(limit)
+    if limit is None:
+        limit = len(chunks)
+    assert len(chunks) <= limit
+    depth = power_of_two_ceil(limit).bit_length() - 1
+    def subtree(nodes, d):
+        if not nodes:
+            return ZEROS[d]
+        if d == 0:
+            return nodes[0]
+        half = 2 ** (d - 1)
+        return pair(subtree(nodes[:half], d - 1), subtree(nodes[half:], d - 1))
+    return subtree(chunks, depth)
+
+def mix_in_length(root, length):
+    return pair(root, Node(length.to_bytes(32, 'little')))
+
+def pack(data):
+    data = data + bytes(-len(data) % 32)
+    return [Node(data[i:i + 32]) for i in range(0, len(data), 32)]
+
+def pack_bits(bits):
+    data = bytearray((len(bits) + 7) // 8)
+    for i, bit in enumerate(bits):
+        if bit:
+            data[i // 8] |= 1 << (i % 8)
+    return pack(bytes(data))
+
+# Tipi: descrizione JSON del valore, la stessa letta dal test Go
+def is_basic(t): return t['kind'] in ('uint', 'boolean')
+def basic_size(t): return t['size'] if t['kind'] == 'uint' else 1
+def serialize_basic(t):
+    if t['kind'] == 'boolean':
+        return bytes([1 if t['value'] else 0])
+    return int(t['value']).to_bytes(t['size'], 'little')
+def is_list(t): return t['kind'] in ('list', 'bitlist', 'bytelist')
+
+def elements(t):
+    if t['kind'] == 'bytes32':
+        return [{'kind': 'uint', 'size': 1, 'value': str(b)} for b in bytes.fromhex(t['value'][2:])]
+    if t['kind'] == 'bytelist':
+        return [{'kind': 'uint', 'size': 1, 'value': str(b)} for b in bytes.fromhex(t['value'][2:])]
+    return t.get('elements', [])
+
+def element_size(t):
+    if t['kind'] in ('bytes32', 'bytelist'):
+        return 1
+    if t['kind'] == 'list':
+        return t['elementSize']
+    els = elements(t)
+    return basic_size(els[0]) if els and is_basic(els[0]) else 0
+
+def chunk_count(t):
+    kind = t['kind']
+    if kind == 'container':
+        return len(t['fields'])
+    if kind in ('bitvector', 'bitlist'):
+        n = len(t['bits']) if kind == 'bitvector' else t['limit']
+        return (n + 255) // 256
+    n = t['limit'] if is_list(t) else len(elements(t))
+    size = element_size(t)
+    return (n * size + 31) // 32 if size else n
+
+def tree(t):
+    kind = t['kind']
+    if is_basic(t):
+        return pack(serialize_basic(t))[0]
+    if kind == 'container':
+        return merkleize([tree(f) for f in t['fields']])
+    if kind in ('bitvector', 'bitlist'):
+        bits = [c == '1' for c in t['bits']]
+        root = merkleize(pack_bits(bits), chunk_count(t))
+        return mix_in_length(root, len(bits)) if kind == 'bitlist' else root
+    els = elements(t)
+    if element_size(t):
+        root = merkleize(pack(b''.join(serialize_basic(e) for e in els)), chunk_count(t))
+    else:
+        root = merkleize([tree(e) for e in els], chunk_count(t))
+    return mix_in_length(root, len(els)) if is_list(t) else root
+
+def get_generalized_index(t, path):
+    root = 1
+    for p in path:
+        assert t is not None and not is_basic(t)
+        if p == '__len__':
+            assert is_list(t)
+            root, t = root * 2 + 1, None
+            continue
+        base = 2 if is_list(t) else 1
+        width = power_of_two_ceil(chunk_count(t))
+        if t['kind'] == 'container':
+            pos, child = p, t['fields'][p]
+        else:
+            size = element_size(t)
+            pos = p * size // 32 if size else p
+            child = None if size else elements(t)[p]
+        root = root * base * width + pos
+        t = child
+    return root
+
+def node_at(root, gindex):
+    node = root
+    for bit in bin(gindex)[3:]:
+        node = node.right if bit == '1' else node.left
+    return node
+
+def branch(root, gindex):
+    out = []
+    while gindex > 1:
+        out.append(node_at(root, gindex ^ 1).hash)
+        gindex //= 2
+    return out
+
+def get_branch_indices(i):
+    o = [i ^ 1]
+    while o[-1] > 1:
+        o.append((o[-1] // 2) ^ 1)
+    return o[:-1]
+
+def get_path_indices(i):
+    o = [i]
+    while o[-1] > 1:
+        o.append(o[-1] // 2)
+    return o[:-1]
+
+def get_helper_indices(indices):
+    helpers, paths = set(), set()
+    for i in indices:
+        helpers |= set(get_branch_indices(i))
+        paths |= set(get_path_indices(i))
+    return sorted(helpers - paths, reverse=True)
+
+def uint(size, value): return {'kind': 'uint', 'size': size, 'value': str(value)}
+def boolean(value): return {'kind': 'boolean', 'value': value}
+def bytes32(seed): return {'kind': 'bytes32', 'value': hx(sha256(seed.encode()))}
+
+def vector(*els): return {'kind': 'vector', 'elements': list(els)}
+def lst(limit, size, *els): return {'kind': 'list', 'limit': limit, 'elementSize': size, 'elements': list(els)}
+def container(*fields): return {'kind': 'container', 'fields': list(fields)}
+
+def bits(n, f): return ''.join('1' if f(i) else '0' for i in range(n))
+
+validator = lambda i: container(bytes32('pubkey-%d' % i), uint(8, 32000000000 + i), boolean(i % 2 == 1), uint(8, 2 ** 64 - 1))
+nested = container(
+    uint(8, 42),
+    bytes32('stato'),
+    lst(4, 8, uint(8, 1), uint(8, 2), uint(8, 3)),
+    container(boolean(True), uint(2, 0xbeef)),
+    {'kind': 'bitlist', 'limit': 2048, 'bits': bits(9, lambda i: i % 3 == 0)},
+)
+
+VECTORS = [
+    ('uint8', uint(1, 255), [], []),
+    ('uint16', uint(2, 0x1234), [], []),
+    ('uint64 massimo', uint(8, 2 ** 64 - 1), [], []),
+    ('uint256', uint(32, 2 ** 255 + 12345), [], []),
+    ('boolean', boolean(True), [], []),
+    ('bytes32', bytes32('chunk'), [], []),
+    ('vector di uint16 su due chunk', vector(*[uint(2, i * 257) for i in range(20)]), [[0], [19]], [[0], [19]]),
+    ('vector di bytes32', vector(*[bytes32('v%d' % i) for i in range(3)]), [[2]], []),
+    ('lista di uint64', lst(10, 8, *[uint(8, i + 1) for i in range(5)]), [[4], ['__len__']], [[0], [4], ['__len__']]),
+    ('lista di uint64 vuota', lst(1024, 8), [['__len__']], []),
+    ('bitvector di 10 bit', {'kind': 'bitvector', 'bits': bits(10, lambda i: i in (0, 3, 9))}, [], []),
+    ('bitvector di 513 bit', {'kind': 'bitvector', 'bits': bits(513, lambda i: i % 7 == 0)}, [], []),
+    ('bitlist', {'kind': 'bitlist', 'limit': 2048, 'bits': bits(9, lambda i: i % 2 == 0)}, [['__len__']], []),
+    ('bitlist vuota', {'kind': 'bitlist', 'limit': 8, 'bits': ''}, [], []),
+    ('bytelist', {'kind': 'bytelist', 'limit': 100, 'value': hx(bytes(range(40)))}, [[33], ['__len__']], []),
+    ('lista di container', lst(2 ** 20, 0, *[validator(i) for i in range(3)]), [[2, 1], [0, 0], ['__len__']], [[2, 1], [1, 2], ['__len__']]),
+    ('container annidato', nested, [[1], [2, 2], [2, '__len__'], [3, 1], [4, '__len__']], [[0], [2, 1], [3, 1], [4, '__len__']]),
+]
+
+out = []
+for name, t, proof_paths, multi_paths in VECTORS:
+    root = tree(t)
+    vector_out = {'name': name, 'value': t, 'root': hx(root.hash), 'proofs': []}
+    for path in proof_paths:
+        g = get_generalized_index(t, path)
+        vector_out['proofs'].append({'path': path, 'generalizedIndex': g, 'leaf': hx(node_at(root, g).hash), 'branch': [hx(b) for b in branch(root, g)]})
+    if multi_paths:
+        indices = [get_generalized_index(t, p) for p in multi_paths]
+        vector_out['multiproof'] = {
+            'paths': multi_paths, 'indices': indices,
+            'leaves': [hx(node_at(root, i).hash) for i in indices],
+            'helpers': get_helper_indices(indices),
+            'proof': [hx(node_at(root, i).hash) for i in get_helper_indices(indices)],
+        }
+    out.append(vector_out)
+
+with open(os.path.join(os.path.dirname(os.path.abspath(__file__)), 'vectors.json'), 'w') as f:
+    f.write('[\n' + ',\n'.join(' ' + json.dumps(v) for v in out) + '\n]\n')
@@ -0,0 +1,271 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// UpdateLeaf sostituisce il valore con indice `index` e ricalcola solo il percorso
+// dalla foglia alla root (O(log n)). Con SortLeaves attivo, restituisce
+// ErrSortedLeavesRelayout se il nuovo hash non rispetta l'ordinamento delle foglie.
+func (m *MerkleTreeImpl[T]) UpdateLeaf(index int, newValue T) error {
+	if index < 0 || index >= len(m.Values) {
+		return fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(m.Values)-1)
+	}
+
+	store := m.nodes()
+	treeIndex := m.Values[index].TreeIndex
+	newHash := m.LeafHash(newValue)
+	oldHash, err := store.Get(treeIndex)
+	if err != nil {
+		return err
+	}
+
+	if m.Options.SortLeaves {
+		firstLeaf := store.Len() - m.leafCount()
+		if treeIndex > firstLeaf {
+			previous, err := store.Get(treeIndex - 1)
+			if err != nil {
+				return err
+			}
+			if compareHex(previous, newHash) > 0 {
+				return fmt.Errorf("%w: indice %d", ErrSortedLeavesRelayout, index)
+			}
+		}
+		// Con FixedDepth le posizioni libere dopo l'ultimo valore non partecipano all'ordinamento
+		if treeIndex < firstLeaf+len(m.Values)-1 {
+			next, err := store.Get(treeIndex + 1)
+			if err != nil {
+				return err
+			}
+			if compareHex(newHash, next) > 0 {
+				return fmt.Errorf("%w: indice %d", ErrSortedLeavesRelayout, index)
+			}
+		}
+	}
+
+	if err := store.Put(treeIndex, newHash); err != nil {
+		return err
+	}
+	if lookupIndex, found := m.HashLookup[oldHash]; found && lookupIndex == index {
+		delete(m.HashLookup, oldHash)
+	}
+	if m.HashLookup == nil {
+		m.HashLookup = make(map[HexString]int)
+	}
+	m.HashLookup[newHash] = index
+	m.Values[index].Value = newValue
+
+	return m.updatePath(store, treeIndex)
+}
+
+// updatePath ricalcola solo i genitori lungo il percorso dalla foglia `treeIndex` alla root
+func (m *MerkleTreeImpl[T]) updatePath(store NodeStore, treeIndex int) error {
+	nodeHash := m.nodeHash()
+	for treeIndex > 0 {
+		treeIndex = ParentIndex(treeIndex)
+		left, err := store.Get(LeftChildIndex(treeIndex))
+		if err != nil {
+			return err
+		}
+		right, err := store.Get(RightChildIndex(treeIndex))
+		if err != nil {
+			return err
+		}
+		if err := store.Put(treeIndex, nodeHash(left, right)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendLeaves aggiunge nuovi valori in coda. Non è un aggiornamento incrementale: nel layout
+// a heap l'aggiunta di foglie sposta tutte le foglie esistenti, quindi tutti i nodi interni
+// vengono ricostruiti (O(n)) a partire dagli hash delle foglie già presenti, senza ri-hashare
+// i valori esistenti.
+// Con SortLeaves attivo, restituisce ErrSortedLeavesRelayout se i nuovi hash non
+// seguono in ordine l'ultima foglia. Richiede un albero in memoria (Store non impostato).
+// Con FixedDepth la struttura non cambia: i valori occupano le prime posizioni libere
+// e vengono ricalcolati solo i loro percorsi, anche con uno Store.
+func (m *MerkleTreeImpl[T]) AppendLeaves(values ...T) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if m.Options.FixedDepth > 0 {
+		return m.appendFixedDepth(values)
+	}
+	if m.Store != nil {
+		return errors.New("AppendLeaves richiede un albero in memoria")
+	}
+
+	leaves := m.leafHashes()
+	for i, value := range values {
+		hash := m.LeafHash(value)
+		if m.Options.SortLeaves && len(leaves) > 0 && compareHex(leaves[len(leaves)-1], hash) > 0 {
+			return fmt.Errorf("%w: nuovo valore %d", ErrSortedLeavesRelayout, i)
+		}
+		leaves = append(leaves, hash)
+	}
+
+	firstLeaf := len(m.Tree) - m.leafCount()
+	newFirstLeaf := len(leaves) - 1
+	for i := range m.Values {
+		m.Values[i].TreeIndex = m.Values[i].TreeIndex - firstLeaf + newFirstLeaf
+	}
+	for i, value := range values {
+		m.Values = append(m.Values, struct {
+			Value     T
+			TreeIndex int
+		}{
+			Value:     value,
+			TreeIndex: newFirstLeaf + len(leaves) - len(values) + i,
+		})
+	}
+
+	return m.relayout(leaves)
+}
+
+// appendFixedDepth scrive i nuovi valori nelle posizioni libere e ricalcola solo i loro percorsi
+func (m *MerkleTreeImpl[T]) appendFixedDepth(values []T) error {
+	store := m.nodes()
+	capacity := m.leafCount()
+	if len(m.Values)+len(values) > capacity {
+		return fmt.Errorf("l'albero di profondità %d ha %d posizioni libere, richieste %d", m.Options.FixedDepth, capacity-len(m.Values), len(values))
+	}
+
+	firstLeaf := store.Len() - capacity
+	var last HexString
+	if len(m.Values) > 0 {
+		var err error
+		if last, err = store.Get(firstLeaf + len(m.Values) - 1); err != nil {
+			return err
+		}
+	}
+	hashes := make([]HexString, len(values))
+	for i, value := range values {
+		hashes[i] = m.LeafHash(value)
+		if m.Options.SortLeaves && last != "" && compareHex(last, hashes[i]) > 0 {
+			return fmt.Errorf("%w: nuovo valore %d", ErrSortedLeavesRelayout, i)
+		}
+		last = hashes[i]
+	}
+
+	if m.HashLookup == nil {
+		m.HashLookup = make(map[HexString]int)
+	}
+	for i, value := range values {
+		treeIndex := firstLeaf + len(m.Values)
+		if err := store.Put(treeIndex, hashes[i]); err != nil {
+			return err
+		}
+		m.Values = append(m.Values, struct {
+			Value     T
+			TreeIndex int
+		}{
+			Value:     value,
+			TreeIndex: treeIndex,
+		})
+		m.HashLookup[hashes[i]] = len(m.Values) - 1
+		if err := m.updatePath(store, treeIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveLeaf rimuove il valore con indice `index`. Come AppendLeaves non è incrementale:
+// le foglie successive si spostano, quindi tutti i nodi interni vengono ricostruiti (O(n))
+// a partire dagli hash delle foglie rimaste, anche con FixedDepth.
+// La rimozione non rompe mai l'ordinamento delle foglie. Richiede un albero in memoria.
+func (m *MerkleTreeImpl[T]) RemoveLeaf(index int) error {
+	if index < 0 || index >= len(m.Values) {
+		return fmt.Errorf("indice %d fuori dai limiti (max %d)", index, len(m.Values)-1)
+	}
+	if m.Store != nil {
+		return errors.New("RemoveLeaf richiede un albero in memoria")
+	}
+	if m.Options.FixedDepth <= 0 && m.leafCount() == 1 {
+		return errors.New("impossibile rimuovere l'unica foglia: l'albero resterebbe vuoto")
+	}
+
+	firstLeaf := len(m.Tree) - m.leafCount()
+	removedPosition := m.Values[index].TreeIndex - firstLeaf
+
+	leaves := m.leafHashes()
+	leaves = append(leaves[:removedPosition], leaves[removedPosition+1:]...)
+	m.Values = append(m.Values[:index], m.Values[index+1:]...)
+
+	newFirstLeaf := len(leaves) - 1
+	if m.Options.FixedDepth > 0 {
+		// Con FixedDepth la struttura non cambia: i valori successivi scalano di una posizione
+		newFirstLeaf = firstLeaf
+	}
+	for i := range m.Values {
+		position := m.Values[i].TreeIndex - firstLeaf
+		if position > removedPosition {
+			position--
+		}
+		m.Values[i].TreeIndex = newFirstLeaf + position
+	}
+
+	return m.relayout(leaves)
+}
+
+// relayout ricostruisce i nodi interni a partire dagli hash delle foglie, in ordine di albero
+func (m *MerkleTreeImpl[T]) relayout(leaves []HexString) error {
+	hashes := make([]BytesLike, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = leaf
+	}
+	var tree []HexString
+	var err error
+	if m.Options.FixedDepth > 0 {
+		tree, err = MakeFixedDepthMerkleTree(hashes[:len(m.Values)], m.Options.FixedDepth, m.Options.zeroLeaf(), m.nodeHash())
+	} else {
+		tree, err = MakeMerkleTreeContext(context.Background(), hashes, m.nodeHash(), nil)
+	}
+	if err != nil {
+		return err
+	}
+	m.Tree = tree
+	m.rebuildHashLookup()
+	return nil
+}
+
+// rebuildHashLookup ricostruisce la mappa hash della foglia -> indice del valore
+func (m *MerkleTreeImpl[T]) rebuildHashLookup() {
+	m.HashLookup = make(map[HexString]int, len(m.Values))
+	for i, v := range m.Values {
+		m.HashLookup[m.node(v.TreeIndex)] = i
+	}
+}
+
+// leafHashes restituisce una copia degli hash delle foglie, in ordine di albero
+func (m *MerkleTreeImpl[T]) leafHashes() []HexString {
+	leaves := make([]HexString, m.leafCount())
+	copy(leaves, m.Tree[len(m.Tree)-len(leaves):])
+	return leaves
+}
+
+// leafCount restituisce il numero di foglie dell'albero
+func (m *MerkleTreeImpl[T]) leafCount() int {
+	return (m.nodes().Len() + 1) / 2
+}
+
+// nodeHash restituisce la funzione di hash dei nodi, con StandardNodeHash come default
+func (m *MerkleTreeImpl[T]) nodeHash() NodeHash {
+	if m.NodeHash == nil {
+		return StandardNodeHash
+	}
+	return m.NodeHash
+}
+
+// compareHex confronta due hash esadecimali come interi
+func compareHex(a HexString, b HexString) int {
+	result, err := Compare(a, b)
+	if err != nil {
+		panic(fmt.Sprintf("❌ ERRORE: confronto tra %s e %s non riuscito: %v", a, b, err))
+	}
+	return result
+}
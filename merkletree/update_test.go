@@ -0,0 +1,159 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func testValues(n int) []string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("valore-%d", i)
+	}
+	return values
+}
+
+// fittingValue cerca un valore il cui hash accetta `accept`
+func fittingValue(t *testing.T, accept func(HexString) bool) string {
+	t.Helper()
+	for i := 0; i < 10000; i++ {
+		candidate := fmt.Sprintf("candidato-%d", i)
+		if accept(StandardLeafHash(candidate)) {
+			return candidate
+		}
+	}
+	t.Fatal("nessun valore candidato adatto")
+	return ""
+}
+
+func TestUpdateLeafRecomputesOnlyPath(t *testing.T) {
+	values := testValues(8)
+	tree := NewStandardMerkleTree(values, MerkleTreeOptions{})
+	calls := 0
+	tree.NodeHash = func(a BytesLike, b BytesLike) HexString {
+		calls++
+		return StandardNodeHash(a, b)
+	}
+
+	// Il nuovo hash deve restare tra le foglie vicine per rispettare SortLeaves
+	index := 3
+	treeIndex := tree.Values[index].TreeIndex
+	previous, next := tree.Tree[treeIndex-1], tree.Tree[treeIndex+1]
+	newValue := fittingValue(t, func(hash HexString) bool {
+		return compareHex(previous, hash) <= 0 && compareHex(hash, next) <= 0
+	})
+
+	if err := tree.UpdateLeaf(index, newValue); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("UpdateLeaf ha calcolato %d nodi, attesi 3 (la profondità)", calls)
+	}
+
+	var updated []string
+	for _, v := range tree.Values {
+		updated = append(updated, v.Value)
+	}
+	if expected := NewStandardMerkleTree(updated, MerkleTreeOptions{}).Root(); tree.Root() != expected {
+		t.Fatalf("root %s, attesa %s", tree.Root(), expected)
+	}
+}
+
+func TestUpdateLeafRejectsUnsorted(t *testing.T) {
+	tree := NewStandardMerkleTree(testValues(8), MerkleTreeOptions{})
+	root := tree.Root()
+	first := tree.Tree[len(tree.Tree)-8]
+	index := -1
+	for i, v := range tree.Values {
+		if v.TreeIndex == len(tree.Tree)-1 {
+			index = i
+		}
+	}
+	// L'ultima foglia non può assumere un hash minore della prima
+	newValue := fittingValue(t, func(hash HexString) bool { return compareHex(hash, first) < 0 })
+	if err := tree.UpdateLeaf(index, newValue); !errors.Is(err, ErrSortedLeavesRelayout) {
+		t.Fatalf("errore %v, atteso ErrSortedLeavesRelayout", err)
+	}
+	if tree.Root() != root {
+		t.Fatal("l'albero è stato modificato nonostante l'errore")
+	}
+}
+
+func TestAppendAndRemoveMatchRebuild(t *testing.T) {
+	values := testValues(5)
+	tree := NewStandardMerkleTree(values, MerkleTreeOptions{})
+
+	last := tree.Tree[len(tree.Tree)-1]
+	appended := fittingValue(t, func(hash HexString) bool { return compareHex(last, hash) <= 0 })
+	if err := tree.AppendLeaves(appended); err != nil {
+		t.Fatal(err)
+	}
+	all := append(append([]string{}, values...), appended)
+	if expected := NewStandardMerkleTree(all, MerkleTreeOptions{}).Root(); tree.Root() != expected {
+		t.Fatalf("dopo AppendLeaves root %s, attesa %s", tree.Root(), expected)
+	}
+
+	if err := tree.RemoveLeaf(0); err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, v := range all {
+		if v != values[0] {
+			remaining = append(remaining, v)
+		}
+	}
+	if expected := NewStandardMerkleTree(remaining, MerkleTreeOptions{}).Root(); tree.Root() != expected {
+		t.Fatalf("dopo RemoveLeaf root %s, attesa %s", tree.Root(), expected)
+	}
+	for i, v := range tree.Values {
+		if tree.Tree[v.TreeIndex] != StandardLeafHash(v.Value) {
+			t.Fatalf("il valore %d non punta alla sua foglia", i)
+		}
+		// HashLookup segue ogni modifica: i valori si ritrovano anche per valore
+		if index := tree.getLeafIndex(v.Value); index != i {
+			t.Fatalf("il valore %d è indicizzato come %d", i, index)
+		}
+	}
+	if _, found := tree.HashLookup[StandardLeafHash(values[0])]; found {
+		t.Fatal("il valore rimosso è ancora indicizzato")
+	}
+}
+
+func TestFixedDepthAppendIgnoresSortLeaves(t *testing.T) {
+	values := testValues(3)
+	tree := NewStandardMerkleTree(values, MerkleTreeOptions{FixedDepth: 3})
+	if tree.Options.SortLeaves {
+		t.Fatal("SortLeaves attivo su un albero a profondità fissa")
+	}
+
+	// Un hash minore dell'ultima foglia romperebbe l'ordinamento: con FixedDepth va accettato
+	last := tree.Tree[tree.Values[len(tree.Values)-1].TreeIndex]
+	appended := fittingValue(t, func(hash HexString) bool { return compareHex(hash, last) < 0 })
+	if err := tree.AppendLeaves(appended); err != nil {
+		t.Fatal(err)
+	}
+	all := append(append([]string{}, values...), appended)
+	if expected := NewStandardMerkleTree(all, MerkleTreeOptions{FixedDepth: 3}).Root(); tree.Root() != expected {
+		t.Fatalf("dopo AppendLeaves root %s, attesa %s", tree.Root(), expected)
+	}
+	if proof := tree.GetProof(len(all) - 1); len(proof) != 3 || !tree.Verify(len(all)-1, proof) {
+		t.Fatalf("proof del valore aggiunto non valida: %v", proof)
+	}
+
+	// Anche un albero ricaricato dal dump accetta append in ordine di inserimento
+	loaded, err := LoadStandardMerkleTree(tree.Dump())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Options.SortLeaves {
+		t.Fatal("SortLeaves attivo sull'albero ricaricato")
+	}
+	if err := loaded.AppendLeaves("valore-z", "valore-a"); err != nil {
+		t.Fatal(err)
+	}
+	all = append(all, "valore-z", "valore-a")
+	if expected := NewStandardMerkleTree(all, MerkleTreeOptions{FixedDepth: 3}).Root(); loaded.Root() != expected {
+		t.Fatalf("dopo il reload root %s, attesa %s", loaded.Root(), expected)
+	}
+}
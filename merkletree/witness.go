@@ -0,0 +1,156 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// CircomWitness contiene gli input di un circuito Merkle circom: i nomi dei campi JSON
+// corrispondono ai segnali di input (root, leaf, pathElements, pathIndices).
+// Gli elementi del campo sono stringhe decimali, come si aspetta snarkjs.
+type CircomWitness struct {
+	Root         string   `json:"root"`
+	Leaf         string   `json:"leaf"` // Valore della foglia: l'hash Poseidon viene calcolato nel circuito
+	PathElements []string `json:"pathElements"`
+	PathIndices  []int    `json:"pathIndices"` // 0 se il nodo è il figlio sinistro, 1 se è il destro
+}
+
+// GnarkWitness contiene gli stessi input per un circuito gnark: i campi possono essere
+// assegnati direttamente ai frontend.Variable del circuito
+type GnarkWitness struct {
+	Root         *big.Int
+	Leaf         *big.Int
+	PathElements []*big.Int
+	PathIndices  []*big.Int
+}
+
+// CircomWitness genera gli input del circuito per un valore (o per l'indice di un valore)
+func (m *PoseidonMerkleTree) CircomWitness(leaf interface{}) (CircomWitness, error) {
+	witness, err := m.GnarkWitness(leaf)
+	if err != nil {
+		return CircomWitness{}, err
+	}
+
+	circom := CircomWitness{
+		Root:         witness.Root.String(),
+		Leaf:         witness.Leaf.String(),
+		PathElements: make([]string, len(witness.PathElements)),
+		PathIndices:  make([]int, len(witness.PathIndices)),
+	}
+	for i := range witness.PathElements {
+		circom.PathElements[i] = witness.PathElements[i].String()
+		circom.PathIndices[i] = int(witness.PathIndices[i].Int64())
+	}
+	return circom, nil
+}
+
+// GnarkWitness genera gli input del circuito gnark per un valore (o per l'indice di un valore)
+func (m *PoseidonMerkleTree) GnarkWitness(leaf interface{}) (GnarkWitness, error) {
+	valueIndex, err := m.poseidonValueIndex(leaf)
+	if err != nil {
+		return GnarkWitness{}, err
+	}
+
+	treeIndex := m.Values[valueIndex].TreeIndex
+	proof, err := m.GetProofAt(treeIndex)
+	if err != nil {
+		return GnarkWitness{}, err
+	}
+	root, err := fieldElement(m.Root())
+	if err != nil {
+		return GnarkWitness{}, err
+	}
+
+	witness := GnarkWitness{
+		Root:         root,
+		Leaf:         new(big.Int).Set(m.Values[valueIndex].Value),
+		PathElements: make([]*big.Int, len(proof)),
+		PathIndices:  make([]*big.Int, len(proof)),
+	}
+	index := treeIndex
+	for i, sibling := range proof {
+		if witness.PathElements[i], err = fieldElement(sibling); err != nil {
+			return GnarkWitness{}, err
+		}
+		// Nel layout a heap i figli sinistri hanno indice dispari
+		witness.PathIndices[i] = big.NewInt(int64(1 - index%2))
+		index = ParentIndex(index)
+	}
+	return witness, nil
+}
+
+// WriteCircomWitness scrive gli input del circuito circom in un file JSON
+func (m *PoseidonMerkleTree) WriteCircomWitness(path string, leaf interface{}) error {
+	witness, err := m.CircomWitness(leaf)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(witness, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// VerifyCircomWitness ricalcola la root come il circuito: hash della foglia e poi
+// Poseidon lungo il percorso, con l'ordine dei figli dato da pathIndices
+func VerifyCircomWitness(witness CircomWitness) bool {
+	if len(witness.PathElements) != len(witness.PathIndices) {
+		return false
+	}
+	leaf, ok := new(big.Int).SetString(witness.Leaf, 10)
+	if !ok {
+		return false
+	}
+	if _, err := fieldElement(leaf); err != nil {
+		return false
+	}
+	root, ok := new(big.Int).SetString(witness.Root, 10)
+	if !ok {
+		return false
+	}
+
+	current := PoseidonLeafHash(leaf)
+	for i, element := range witness.PathElements {
+		sibling, ok := new(big.Int).SetString(element, 10)
+		if !ok {
+			return false
+		}
+		if _, err := fieldElement(sibling); err != nil {
+			return false
+		}
+		switch witness.PathIndices[i] {
+		case 0:
+			current = PoseidonNodeHash(current, sibling)
+		case 1:
+			current = PoseidonNodeHash(sibling, current)
+		default:
+			return false
+		}
+	}
+	return current == fieldHex(root)
+}
+
+// poseidonValueIndex risolve un valore o un indice nell'indice del valore
+func (m *PoseidonMerkleTree) poseidonValueIndex(leaf interface{}) (int, error) {
+	switch v := leaf.(type) {
+	case int:
+		if v < 0 || v >= len(m.Values) {
+			return 0, fmt.Errorf("indice %d fuori dai limiti (max %d)", v, len(m.Values)-1)
+		}
+		return v, nil
+	case *big.Int:
+		if _, err := fieldElement(v); err != nil {
+			return 0, err
+		}
+		index, found := m.HashLookup[PoseidonLeafHash(v)]
+		if !found {
+			return 0, fmt.Errorf("il valore %s non esiste nell'albero", v)
+		}
+		return index, nil
+	default:
+		return 0, fmt.Errorf("tipo %T non valido: atteso int o *big.Int", leaf)
+	}
+}